@@ -0,0 +1,81 @@
+// Command janitor deletes resources leaked by aborted test runs.
+//
+// It finds objects carrying the framework's labels (managed=openshift-testing by default),
+// optionally filtered by name prefix, age and run ID, and deletes them, including the
+// TemplateInstances created by util.CreateVM and ephemeral test namespaces.
+//
+// Example:
+//
+//	go run ./cmd/janitor --ttl 6h              # print what would be deleted
+//	go run ./cmd/janitor --ttl 6h --dry-run=false
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"myproject/consts"
+	"myproject/util"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+func main() {
+	var (
+		kubeconfig    = flag.String("kubeconfig", "", "Path to a kubeconfig file, defaults to in-cluster config or $KUBECONFIG")
+		namespaces    = flag.String("namespaces", "", "Comma separated namespaces to search, defaults to all namespaces. Admin network policies are always searched")
+		labelSelector = flag.String("selector", "", "Label selector identifying test resources, defaults to the framework's default labels")
+		namePrefix    = flag.String("prefix", consts.TestPrefix, "Only delete resources whose name starts with this prefix, empty for any name. Admin network policies are matched by label only")
+		ttl           = flag.Duration("ttl", 6*time.Hour, "Only delete resources older than this")
+		runID         = flag.String("run-id", "", "Only delete resources of this test run")
+		dryRun        = flag.Bool("dry-run", true, "Only print what would be deleted")
+		logLevel      = flag.String("log-level", "info", "Log level (debug, info, warn, error)")
+	)
+	flag.Parse()
+
+	if err := util.SetLogLevel(*logLevel); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid log level: %v\n", err)
+		os.Exit(1)
+	}
+
+	var config *rest.Config
+	var err error
+	if *kubeconfig != "" {
+		_, config, err = util.AuthenticateFile(*kubeconfig)
+	} else {
+		_, config, err = util.Authenticate()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to authenticate: %v\n", err)
+		os.Exit(1)
+	}
+
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create dynamic client: %v\n", err)
+		os.Exit(1)
+	}
+
+	options := util.JanitorOptions{
+		LabelSelector: *labelSelector,
+		NamePrefix:    *namePrefix,
+		TTL:           *ttl,
+		RunID:         *runID,
+		DryRun:        *dryRun,
+	}
+	if *namespaces != "" {
+		options.Namespaces = strings.Split(*namespaces, ",")
+	}
+
+	// CleanupOrphans logs every orphan it would delete in dry-run mode
+	orphans, err := util.CleanupOrphans(context.Background(), client, options)
+	fmt.Printf("%d orphaned resources found\n", len(orphans))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Janitor failed: %v\n", err)
+		os.Exit(1)
+	}
+}
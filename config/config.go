@@ -0,0 +1,301 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"myproject/consts"
+	"sigs.k8s.io/yaml"
+)
+
+// EnvConfigFile names the environment variable holding the path of the configuration file
+const EnvConfigFile = "TEST_CONFIG"
+
+// Environment variables overriding single values of the configuration file
+const (
+	EnvHttpdImage        = "TEST_HTTPD_IMAGE"
+	EnvClientImage       = "TEST_CLIENT_IMAGE"
+	EnvTemplateName      = "TEST_TEMPLATE_NAME"
+	EnvTemplateNamespace = "TEST_TEMPLATE_NAMESPACE"
+	EnvNamespacePrefix   = "TEST_NAMESPACE_PREFIX"
+	EnvVMCPURequest      = "TEST_VM_CPU_REQUEST"
+	EnvVMCPULimit        = "TEST_VM_CPU_LIMIT"
+	EnvVMMemoryRequest   = "TEST_VM_MEMORY_REQUEST"
+	EnvVMMemoryLimit     = "TEST_VM_MEMORY_LIMIT"
+	EnvPodReadyTimeout   = "TEST_POD_READY_TIMEOUT"
+	EnvVMReadyTimeout    = "TEST_VM_READY_TIMEOUT"
+	EnvCleanupTimeout    = "TEST_CLEANUP_TIMEOUT"
+	EnvLabels            = "TEST_LABELS" // Comma separated key=value pairs
+	EnvStandInHost       = "TEST_EGRESS_STANDIN_HOST"
+	EnvStandInListen     = "TEST_EGRESS_STANDIN_LISTEN"
+)
+
+// Config holds the settings that differ between clusters running the suite
+type Config struct {
+	Images     ImagesConfig      `json:"images"`
+	Template   TemplateConfig    `json:"template"`
+	Namespaces NamespacesConfig  `json:"namespaces"`
+	Resources  ResourcesConfig   `json:"resources"`
+	Timeouts   TimeoutsConfig    `json:"timeouts"`
+	Egress     EgressConfig      `json:"egress"`
+	Labels     map[string]string `json:"labels"` // Added by ctx.Labeler to the objects the helpers label with it
+}
+
+// ImagesConfig holds the container images used by the specs
+type ImagesConfig struct {
+	Httpd  string `json:"httpd"`
+	Client string `json:"client"`
+}
+
+// TemplateConfig selects the OpenShift template VMs are created from
+type TemplateConfig struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// NamespacesConfig controls the ephemeral namespaces the framework creates
+type NamespacesConfig struct {
+	Prefix string `json:"prefix"`
+}
+
+// ResourcesConfig holds the default resource requests and limits
+type ResourcesConfig struct {
+	VM ResourceRequirements `json:"vm"`
+}
+
+// ResourceRequirements are CPU and memory quantities such as "500m" or "2Gi"
+type ResourceRequirements struct {
+	CPURequest    string `json:"cpuRequest"`
+	CPULimit      string `json:"cpuLimit"`
+	MemoryRequest string `json:"memoryRequest"`
+	MemoryLimit   string `json:"memoryLimit"`
+}
+
+// TimeoutsConfig holds how long the framework waits for resources, written as Go durations ("5m")
+type TimeoutsConfig struct {
+	PodReady metav1.Duration `json:"podReady"`
+	VMReady  metav1.Duration `json:"vmReady"`
+	Cleanup  metav1.Duration `json:"cleanup"`
+}
+
+// EgressConfig describes the stand-in server that egress specs use as an external destination
+type EgressConfig struct {
+	StandInHost   string `json:"standInHost"`   // Address of the runner as seen from the cluster, egress specs are skipped when empty
+	StandInListen string `json:"standInListen"` // Local listen address of the stand-in server, e.g. ":8080"
+}
+
+// Default returns the configuration built from the values in consts
+func Default() *Config {
+	return &Config{
+		Images: ImagesConfig{
+			Httpd:  consts.HttpdImage,
+			Client: consts.ClientImage,
+		},
+		Template: TemplateConfig{
+			Name:      consts.DefaultTemplateName,
+			Namespace: consts.DefaultTemplateNamespace,
+		},
+		Namespaces: NamespacesConfig{
+			Prefix: consts.TestPrefix,
+		},
+		Resources: ResourcesConfig{
+			// The size CreateTestVM has always used, not consts.DefaultResources
+			VM: ResourceRequirements{CPURequest: "4000m", CPULimit: "4000m", MemoryRequest: "4Gi", MemoryLimit: "4Gi"},
+		},
+		Timeouts: TimeoutsConfig{
+			PodReady: metav1.Duration{Duration: 5 * time.Minute},
+			VMReady:  metav1.Duration{Duration: 5 * time.Minute},
+			Cleanup:  metav1.Duration{Duration: 3 * time.Minute},
+		},
+		Egress: EgressConfig{
+			StandInListen: ":0",
+		},
+		Labels: map[string]string{},
+	}
+}
+
+// Load builds the configuration from the defaults, the YAML or JSON file at path (if not empty)
+// and the environment variable overrides, and validates the result.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file %s: %v", path, err)
+		}
+		if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %v", path, err)
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// LoadFromEnv loads the configuration file named by TEST_CONFIG, or only defaults and overrides if it is unset
+func LoadFromEnv() (*Config, error) {
+	return Load(os.Getenv(EnvConfigFile))
+}
+
+// applyEnv overrides values with the environment variables that are set
+func (c *Config) applyEnv() error {
+	values := map[string]*string{
+		EnvHttpdImage:        &c.Images.Httpd,
+		EnvClientImage:       &c.Images.Client,
+		EnvTemplateName:      &c.Template.Name,
+		EnvTemplateNamespace: &c.Template.Namespace,
+		EnvNamespacePrefix:   &c.Namespaces.Prefix,
+		EnvVMCPURequest:      &c.Resources.VM.CPURequest,
+		EnvVMCPULimit:        &c.Resources.VM.CPULimit,
+		EnvVMMemoryRequest:   &c.Resources.VM.MemoryRequest,
+		EnvVMMemoryLimit:     &c.Resources.VM.MemoryLimit,
+		EnvStandInHost:       &c.Egress.StandInHost,
+		EnvStandInListen:     &c.Egress.StandInListen,
+	}
+	for env, target := range values {
+		if value, ok := os.LookupEnv(env); ok {
+			*target = value
+		}
+	}
+
+	durations := map[string]*metav1.Duration{
+		EnvPodReadyTimeout: &c.Timeouts.PodReady,
+		EnvVMReadyTimeout:  &c.Timeouts.VMReady,
+		EnvCleanupTimeout:  &c.Timeouts.Cleanup,
+	}
+	for env, target := range durations {
+		if value, ok := os.LookupEnv(env); ok {
+			duration, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("invalid duration in %s: %v", env, err)
+			}
+			target.Duration = duration
+		}
+	}
+
+	if value, ok := os.LookupEnv(EnvLabels); ok && value != "" {
+		labels, err := parseLabels(value)
+		if err != nil {
+			return fmt.Errorf("invalid labels in %s: %v", EnvLabels, err)
+		}
+		if c.Labels == nil {
+			c.Labels = map[string]string{}
+		}
+		for key, label := range labels {
+			c.Labels[key] = label
+		}
+	}
+	return nil
+}
+
+// Validate checks that the configuration is complete and well formed
+func (c *Config) Validate() error {
+	var problems []string
+	require := func(field, value string) {
+		if value == "" {
+			problems = append(problems, fmt.Sprintf("%s must not be empty", field))
+		}
+	}
+
+	require("images.httpd", c.Images.Httpd)
+	require("images.client", c.Images.Client)
+	require("template.name", c.Template.Name)
+	require("template.namespace", c.Template.Namespace)
+	require("namespaces.prefix", c.Namespaces.Prefix)
+	if c.Namespaces.Prefix != "" {
+		for _, msg := range validation.IsDNS1123Label(c.Namespaces.Prefix) {
+			problems = append(problems, fmt.Sprintf("namespaces.prefix: %s", msg))
+		}
+	}
+
+	quantities := map[string]string{
+		"resources.vm.cpuRequest":    c.Resources.VM.CPURequest,
+		"resources.vm.cpuLimit":      c.Resources.VM.CPULimit,
+		"resources.vm.memoryRequest": c.Resources.VM.MemoryRequest,
+		"resources.vm.memoryLimit":   c.Resources.VM.MemoryLimit,
+	}
+	for field, value := range quantities {
+		if value == "" {
+			continue
+		}
+		if _, err := resource.ParseQuantity(value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: invalid quantity %q", field, value))
+		}
+	}
+
+	timeouts := map[string]time.Duration{
+		"timeouts.podReady": c.Timeouts.PodReady.Duration,
+		"timeouts.vmReady":  c.Timeouts.VMReady.Duration,
+		"timeouts.cleanup":  c.Timeouts.Cleanup.Duration,
+	}
+	for field, value := range timeouts {
+		if value <= 0 {
+			problems = append(problems, fmt.Sprintf("%s must be greater than 0", field))
+		}
+	}
+
+	if _, _, err := net.SplitHostPort(c.Egress.StandInListen); err != nil {
+		problems = append(problems, fmt.Sprintf("egress.standInListen: %v", err))
+	}
+
+	for key, value := range c.Labels {
+		for _, msg := range validation.IsQualifiedName(key) {
+			problems = append(problems, fmt.Sprintf("labels key %q: %s", key, msg))
+		}
+		for _, msg := range validation.IsValidLabelValue(value) {
+			problems = append(problems, fmt.Sprintf("labels value %q: %s", value, msg))
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// VMResources returns the configured VM resources as Kubernetes resource requirements
+func (c *Config) VMResources() corev1.ResourceRequirements {
+	requirements := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{},
+		Limits:   corev1.ResourceList{},
+	}
+	set := func(list corev1.ResourceList, name corev1.ResourceName, value string) {
+		if quantity, err := resource.ParseQuantity(value); err == nil && value != "" {
+			list[name] = quantity
+		}
+	}
+	set(requirements.Requests, corev1.ResourceCPU, c.Resources.VM.CPURequest)
+	set(requirements.Limits, corev1.ResourceCPU, c.Resources.VM.CPULimit)
+	set(requirements.Requests, corev1.ResourceMemory, c.Resources.VM.MemoryRequest)
+	set(requirements.Limits, corev1.ResourceMemory, c.Resources.VM.MemoryLimit)
+	return requirements
+}
+
+// parseLabels parses "key=value,key2=value2"
+func parseLabels(value string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		key, label, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("expected key=value, got %q", pair)
+		}
+		labels[key] = label
+	}
+	return labels, nil
+}
@@ -0,0 +1,113 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"myproject/config"
+	"myproject/consts"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+var _ = Describe("Config", func() {
+	writeConfig := func(content string) string {
+		path := filepath.Join(GinkgoT().TempDir(), "config.yaml")
+		Expect(os.WriteFile(path, []byte(content), 0o644)).To(Succeed())
+		return path
+	}
+
+	It("defaults to the values in consts and the VM size CreateTestVM always used", func() {
+		cfg, err := config.Load("")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.Images.Httpd).To(Equal(consts.HttpdImage))
+		Expect(cfg.Images.Client).To(Equal(consts.ClientImage))
+		Expect(cfg.Template.Name).To(Equal(consts.DefaultTemplateName))
+		Expect(cfg.Template.Namespace).To(Equal(consts.DefaultTemplateNamespace))
+		Expect(cfg.Namespaces.Prefix).To(Equal(consts.TestPrefix))
+
+		resources := cfg.VMResources()
+		Expect(resources.Requests.Cpu().Equal(resource.MustParse("4000m"))).To(BeTrue())
+		Expect(resources.Limits.Cpu().Equal(resource.MustParse("4000m"))).To(BeTrue())
+		Expect(resources.Requests.Memory().Equal(resource.MustParse("4Gi"))).To(BeTrue())
+		Expect(resources.Limits.Memory().Equal(resource.MustParse("4Gi"))).To(BeTrue())
+	})
+
+	It("loads YAML files on top of the defaults", func() {
+		cfg, err := config.Load(writeConfig(`
+images:
+  httpd: registry.example.com/httpd:2.4
+template:
+  name: fedora
+timeouts:
+  podReady: 90s
+labels:
+  team: networking
+`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.Images.Httpd).To(Equal("registry.example.com/httpd:2.4"))
+		Expect(cfg.Images.Client).To(Equal(consts.ClientImage))
+		Expect(cfg.Template.Name).To(Equal("fedora"))
+		Expect(cfg.Template.Namespace).To(Equal(consts.DefaultTemplateNamespace))
+		Expect(cfg.Timeouts.PodReady.Duration).To(Equal(90 * time.Second))
+		Expect(cfg.Labels).To(HaveKeyWithValue("team", "networking"))
+	})
+
+	It("loads the example configuration", func() {
+		_, err := config.Load("example.yaml")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("lets environment variables override the file", func() {
+		GinkgoT().Setenv(config.EnvClientImage, "registry.example.com/curl")
+		GinkgoT().Setenv(config.EnvVMReadyTimeout, "10m")
+		GinkgoT().Setenv(config.EnvVMCPULimit, "2")
+		GinkgoT().Setenv(config.EnvLabels, "owner=qe,env=ci")
+
+		cfg, err := config.Load(writeConfig("images:\n  client: registry.example.com/ubi\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.Images.Client).To(Equal("registry.example.com/curl"))
+		Expect(cfg.Timeouts.VMReady.Duration).To(Equal(10 * time.Minute))
+		resources := cfg.VMResources()
+		Expect(resources.Limits.Cpu().String()).To(Equal("2"))
+		Expect(cfg.Labels).To(Equal(map[string]string{"owner": "qe", "env": "ci"}))
+	})
+
+	It("rejects unknown fields", func() {
+		_, err := config.Load(writeConfig("image:\n  httpd: typo\n"))
+		Expect(err).To(MatchError(ContainSubstring("failed to parse config file")))
+	})
+
+	It("reports every invalid value", func() {
+		_, err := config.Load(writeConfig(`
+images:
+  httpd: ""
+namespaces:
+  prefix: Not_A_Label
+resources:
+  vm:
+    cpuLimit: lots
+timeouts:
+  cleanup: 0s
+egress:
+  standInListen: "8080"
+labels:
+  "bad key!": value
+`))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("images.httpd must not be empty"))
+		Expect(err.Error()).To(ContainSubstring("namespaces.prefix"))
+		Expect(err.Error()).To(ContainSubstring(`resources.vm.cpuLimit: invalid quantity "lots"`))
+		Expect(err.Error()).To(ContainSubstring("timeouts.cleanup must be greater than 0"))
+		Expect(err.Error()).To(ContainSubstring("egress.standInListen"))
+		Expect(err.Error()).To(ContainSubstring(`labels key "bad key!"`))
+	})
+
+	It("rejects malformed environment overrides", func() {
+		GinkgoT().Setenv(config.EnvPodReadyTimeout, "soon")
+		_, err := config.Load("")
+		Expect(err).To(MatchError(ContainSubstring(config.EnvPodReadyTimeout)))
+	})
+})
@@ -11,6 +11,15 @@ const (
     DefaultTemplateName = "rhel8-4-az-a"
 )
 
+const (
+    // Labels added by the framework to the objects it creates
+    RunIDLabel = "openshift-testing/run-id"
+    EphemeralLabel = "openshift-testing/ephemeral"
+    SpecLabel = "openshift-testing/spec"
+    OwnerLabel = "openshift-testing/owner"
+    AppLabel = "app"
+)
+
 const (
     // Tests Consts
     TestPrefix = "functional-test"
@@ -30,6 +39,7 @@ var DefaultResources = corev1.ResourceRequirements{
     },
 }
 
+// DefaultLabels are shared by every test and must never be modified, copy them with util.MergeLabels
 var DefaultLabels = map[string]string{
     "managed": "openshift-testing",
 }
\ No newline at end of file
@@ -0,0 +1,48 @@
+package framework
+
+import (
+	"context"
+	"time"
+
+	"myproject/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// NewAdminNetworkPolicy starts an AdminNetworkPolicy carrying the spec's labels.
+// ANPs are cluster scoped, so the name should include ctx.RandomName.
+func (ctx *TestContext) NewAdminNetworkPolicy(policyName string, priority int32) *util.AdminNetworkPolicyBuilder {
+	return util.NewAdminNetworkPolicyBuilder(policyName, priority).WithLabels(ctx.Labeler.Labels())
+}
+
+// NewBaselineAdminNetworkPolicy starts the cluster's BaselineAdminNetworkPolicy carrying the spec's labels
+func (ctx *TestContext) NewBaselineAdminNetworkPolicy() *util.AdminNetworkPolicyBuilder {
+	return util.NewBaselineAdminNetworkPolicyBuilder().WithLabels(ctx.Labeler.Labels())
+}
+
+// ApplyAdminNetworkPolicy builds an ANP or BANP, creates it, tracks it for cleanup and waits until
+// the network plugin reports it as ready. The spec is skipped when the cluster already has a BANP.
+func (ctx *TestContext) ApplyAdminNetworkPolicy(builder *util.AdminNetworkPolicyBuilder, timeout time.Duration) *unstructured.Unstructured {
+	policy, err := builder.Build()
+	Expect(err).ToNot(HaveOccurred(), "Invalid admin network policy")
+
+	resourceType := ResourceAdminNetworkPolicy
+	if policy.GetKind() == util.BaselineAdminNetworkPolicyKind {
+		resourceType = ResourceBaselineAdminNetworkPolicy
+		// The BANP is a singleton, one that already exists belongs to someone else and must survive the spec
+		_, err := util.GetAdminPolicy(context.TODO(), ctx.DynamicClient, policy.GetKind(), policy.GetName())
+		if err == nil {
+			Skip("The cluster already has a BaselineAdminNetworkPolicy, not replacing it")
+		}
+		Expect(apierrors.IsNotFound(err)).To(BeTrue(), "Failed to check for an existing BaselineAdminNetworkPolicy: %v", err)
+	}
+	created, err := util.CreateAdminPolicy(ctx.DynamicClient, policy)
+	Expect(err).ToNot(HaveOccurred(), "Failed to create %s %s", policy.GetKind(), policy.GetName())
+	ctx.Track(resourceType, policy.GetName())
+
+	err = util.WaitForAdminPolicyReady(context.TODO(), ctx.DynamicClient, policy.GetKind(), policy.GetName(), util.ExponentialBackoff(time.Second, 10*time.Second, timeout))
+	Expect(err).ToNot(HaveOccurred(), "%s %s did not become ready", policy.GetKind(), policy.GetName())
+	return created
+}
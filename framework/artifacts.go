@@ -0,0 +1,179 @@
+package framework
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"myproject/util"
+	. "github.com/onsi/ginkgo/v2"
+	routev1 "github.com/openshift/api/route/v1"
+	templatev1 "github.com/openshift/api/template/v1"
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+// DefaultArtifactDir is used when the ARTIFACT_DIR environment variable is not set
+const DefaultArtifactDir = "_artifacts"
+
+// resourceKinds maps tracked resource types to the kind written into dumped YAML,
+// since objects returned by typed clients carry no TypeMeta
+var resourceKinds = map[string]schema.GroupVersionKind{
+	ResourcePod:              corev1.SchemeGroupVersion.WithKind("Pod"),
+	ResourceService:          corev1.SchemeGroupVersion.WithKind("Service"),
+	ResourceNamespace:        corev1.SchemeGroupVersion.WithKind("Namespace"),
+	ResourceNetworkPolicy:    netv1.SchemeGroupVersion.WithKind("NetworkPolicy"),
+	ResourceRoute:            routev1.GroupVersion.WithKind("Route"),
+	ResourceIngress:          netv1.SchemeGroupVersion.WithKind("Ingress"),
+	ResourceTemplateInstance: templatev1.GroupVersion.WithKind("TemplateInstance"),
+	ResourceVM:               kubevirtv1.GroupVersion.WithKind("VirtualMachine"),
+}
+
+// artifactDirFromEnv returns the configured artifact root directory
+func artifactDirFromEnv() string {
+	if dir := os.Getenv("ARTIFACT_DIR"); dir != "" {
+		return dir
+	}
+	return DefaultArtifactDir
+}
+
+// collectArtifactsOnFailure dumps the tracked resources if the current spec failed.
+// Setup registers it with DeferCleanup after CleanupAll, so it runs before anything is deleted.
+func (ctx *TestContext) collectArtifactsOnFailure() {
+	report := CurrentSpecReport()
+	if !report.Failed() {
+		return
+	}
+
+	dir, err := ctx.CollectArtifacts(report.FullText())
+	if err != nil {
+		util.LogWarn("Some artifacts could not be collected: %v", err)
+	}
+	AddReportEntry("artifacts", dir)
+	util.LogInfo("Failure artifacts for %q written to %s", report.FullText(), dir)
+}
+
+// CollectArtifacts writes YAML for every tracked resource, the logs of tracked pods and the
+// Events of the involved namespaces into <ArtifactDir>/<sanitized name>. It keeps going on errors
+// and returns the directory together with the errors it met.
+func (ctx *TestContext) CollectArtifacts(name string) (string, error) {
+	dir := filepath.Join(ctx.ArtifactDir, util.SanitizeFileName(name))
+	var failures []string
+	record := func(err error) {
+		if err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+
+	namespaces := map[string]bool{}
+	for _, resource := range ctx.TrackedResources() {
+		if resource.Namespace != "" {
+			namespaces[resource.Namespace] = true
+		}
+		if resource.Kind == ResourceNamespace {
+			namespaces[resource.Name] = true
+		}
+
+		if resource.Kind == ResourceSecret {
+			continue // Secrets hold private keys and are left out of the artifacts
+		}
+		record(ctx.dumpResource(dir, resource))
+		switch resource.Kind {
+		case ResourcePod:
+			record(ctx.dumpPodLogs(dir, resource))
+		case ResourceVM:
+			record(ctx.dumpVMI(dir, resource))
+		}
+	}
+	if ctx.Namespace != "" {
+		namespaces[ctx.Namespace] = true
+	}
+
+	for namespace := range namespaces {
+		events, err := ctx.KubeClient.CoreV1().Events(namespace).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			record(fmt.Errorf("failed to list events in %s: %v", namespace, err))
+			continue
+		}
+		events.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("EventList"))
+		record(util.WriteYAML(filepath.Join(dir, fmt.Sprintf("events-%s.yaml", namespace)), events))
+	}
+
+	if len(failures) > 0 {
+		return dir, fmt.Errorf("%s", strings.Join(failures, "; "))
+	}
+	return dir, nil
+}
+
+func (ctx *TestContext) dumpResource(dir string, resource TrackedResource) error {
+	obj, err := ctx.getResource(context.TODO(), resource)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get %s: %v", resource, err)
+	}
+
+	if gvk, ok := resourceKinds[resource.Kind]; ok {
+		obj.GetObjectKind().SetGroupVersionKind(gvk)
+	}
+	return util.WriteYAML(filepath.Join(dir, artifactFileName(resource)+".yaml"), obj)
+}
+
+func (ctx *TestContext) dumpPodLogs(dir string, resource TrackedResource) error {
+	pod, err := ctx.KubeClient.CoreV1().Pods(resource.Namespace).Get(context.TODO(), resource.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get %s: %v", resource, err)
+	}
+
+	var failures []string
+	containers := append([]corev1.Container{}, pod.Spec.InitContainers...)
+	containers = append(containers, pod.Spec.Containers...)
+	for _, container := range containers {
+		logs, err := util.GetContainerLogs(ctx.KubeClient, resource.Namespace, resource.Name, container.Name)
+		if err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+		path := filepath.Join(dir, fmt.Sprintf("%s-%s.log", artifactFileName(resource), container.Name))
+		if err := util.WriteText(path, logs); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+func (ctx *TestContext) dumpVMI(dir string, resource TrackedResource) error {
+	vmi, err := ctx.VirtClient.VirtualMachineInstance(resource.Namespace).Get(context.TODO(), resource.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get VMI %s/%s: %v", resource.Namespace, resource.Name, err)
+	}
+
+	vmi.SetGroupVersionKind(kubevirtv1.GroupVersion.WithKind("VirtualMachineInstance"))
+	vmiResource := TrackedResource{Kind: "vmi", Namespace: resource.Namespace, Name: resource.Name}
+	return util.WriteYAML(filepath.Join(dir, artifactFileName(vmiResource)+".yaml"), vmi)
+}
+
+// artifactFileName returns "<kind>-<namespace>-<name>" (or "<kind>-<name>" for cluster scoped objects)
+func artifactFileName(resource TrackedResource) string {
+	if resource.Namespace == "" {
+		return util.SanitizeFileName(fmt.Sprintf("%s-%s", resource.Kind, resource.Name))
+	}
+	return util.SanitizeFileName(fmt.Sprintf("%s-%s-%s", resource.Kind, resource.Namespace, resource.Name))
+}
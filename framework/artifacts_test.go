@@ -0,0 +1,55 @@
+package framework_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"myproject/framework"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	routev1 "github.com/openshift/api/route/v1"
+	routefake "github.com/openshift/client-go/route/clientset/versioned/fake"
+	templatefake "github.com/openshift/client-go/template/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("CollectArtifacts", func() {
+	It("should dump tracked resources, pod logs and events with fake clientsets", func() {
+		meta := metav1.ObjectMeta{Name: "server", Namespace: "ns1"}
+		kubeClient := fake.NewSimpleClientset(
+			&corev1.Pod{ObjectMeta: meta, Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "httpd"}}}},
+			&corev1.Service{ObjectMeta: meta},
+			&corev1.Event{ObjectMeta: metav1.ObjectMeta{Name: "server.1", Namespace: "ns1"}, Reason: "Scheduled"},
+		)
+		routeClient := routefake.NewSimpleClientset(&routev1.Route{ObjectMeta: meta})
+
+		ctx := framework.NewTestContext(nil, framework.Clients{Kube: kubeClient, Route: routeClient, Template: templatefake.NewSimpleClientset()}, "ns1")
+		ctx.ArtifactDir = GinkgoT().TempDir()
+		ctx.Track(framework.ResourcePod, "server")
+		ctx.Track(framework.ResourceService, "server")
+		ctx.Track(framework.ResourceRoute, "server")
+		ctx.Track(framework.ResourceNetworkPolicy, "already-gone")
+
+		dir, err := ctx.CollectArtifacts("Route spec [should work]")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(dir).To(Equal(filepath.Join(ctx.ArtifactDir, "Route_spec_should_work_")))
+
+		podYAML, err := os.ReadFile(filepath.Join(dir, "pod-ns1-server.yaml"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(podYAML)).To(ContainSubstring("kind: Pod"))
+
+		routeYAML, err := os.ReadFile(filepath.Join(dir, "route-ns1-server.yaml"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(routeYAML)).To(ContainSubstring("kind: Route"))
+
+		Expect(filepath.Join(dir, "service-ns1-server.yaml")).To(BeAnExistingFile())
+		Expect(filepath.Join(dir, "pod-ns1-server-httpd.log")).To(BeAnExistingFile())
+		Expect(filepath.Join(dir, "networkPolicy-ns1-already-gone.yaml")).ToNot(BeAnExistingFile())
+
+		events, err := os.ReadFile(filepath.Join(dir, "events-ns1.yaml"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(events)).To(ContainSubstring("Scheduled"))
+	})
+})
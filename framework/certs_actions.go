@@ -0,0 +1,33 @@
+package framework
+
+import (
+	"myproject/util/certs"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// NewCA creates a throwaway root CA for the spec
+func (ctx *TestContext) NewCA(commonName string) *certs.CA {
+	ca, err := certs.NewCA(commonName)
+	Expect(err).ToNot(HaveOccurred(), "Failed to create CA %s", commonName)
+	return ca
+}
+
+// NewServiceCert issues a server certificate for every in-cluster name of a service in the context's
+// namespace, e.g. for the pod behind a reencrypt route
+func (ctx *TestContext) NewServiceCert(ca *certs.CA, serviceName string) *certs.KeyPair {
+	names, err := certs.ServiceDNSNames(ctx.KubeClient, ctx.Namespace, serviceName)
+	Expect(err).ToNot(HaveOccurred(), "Failed to get the DNS names of service %s", serviceName)
+
+	kp, err := ca.NewServerCert("", names...)
+	Expect(err).ToNot(HaveOccurred(), "Failed to issue a certificate for service %s", serviceName)
+	return kp
+}
+
+// CreateTLSSecret stores the key pair in a kubernetes.io/tls Secret carrying the spec's labels and tracks it for cleanup
+func (ctx *TestContext) CreateTLSSecret(secretName string, kp *certs.KeyPair) *corev1.Secret {
+	ctx.Track(ResourceSecret, secretName)
+	secret, err := certs.CreateTLSSecret(ctx.KubeClient, ctx.Namespace, secretName, kp, ctx.Labeler.Labels())
+	Expect(err).ToNot(HaveOccurred(), "Failed to create TLS secret %s", secretName)
+	return secret
+}
@@ -2,28 +2,220 @@ package framework
 
 import (
 	"context"
+	"fmt"
+	"sync"
+	"time"
+
 	"myproject/util"
 	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Resource kinds understood by the TestContext tracking and cleanup
+const (
+	ResourcePod                        = "pod"
+	ResourceVM                         = "vm"
+	ResourceTemplateInstance           = "templateInstance"
+	ResourceService                    = "service"
+	ResourceRoute                      = "route"
+	ResourceIngress                    = "ingress" // The Routes generated for it are garbage collected with it
+	ResourceNetworkPolicy              = "networkPolicy"
+	ResourceSecret                     = "secret"
+	ResourceEgressFirewall             = "egressFirewall"             // Always named util.EgressFirewallName
+	ResourceNamespace                  = "namespace"                  // Cluster scoped, tracked with an empty namespace
+	ResourceAdminNetworkPolicy         = "adminNetworkPolicy"         // Cluster scoped
+	ResourceBaselineAdminNetworkPolicy = "baselineAdminNetworkPolicy" // Cluster scoped
 )
 
-// Cleanup cleans up resources such as VMs and Pods
+// TrackedResource identifies an object created through the TestContext helpers
+type TrackedResource struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+func (r TrackedResource) String() string {
+	if r.Namespace == "" {
+		return fmt.Sprintf("%s %s", r.Kind, r.Name)
+	}
+	return fmt.Sprintf("%s %s/%s", r.Kind, r.Namespace, r.Name)
+}
+
+// resourceTracker records created objects in creation order. It is shared by contexts derived from each other.
+type resourceTracker struct {
+	mu        sync.Mutex
+	resources []TrackedResource
+}
+
+func (t *resourceTracker) add(resource TrackedResource) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, existing := range t.resources {
+		if existing == resource {
+			return
+		}
+	}
+	t.resources = append(t.resources, resource)
+}
+
+func (t *resourceTracker) remove(resource TrackedResource) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, existing := range t.resources {
+		if existing == resource {
+			t.resources = append(t.resources[:i], t.resources[i+1:]...)
+			return
+		}
+	}
+}
+
+// drain returns all tracked resources in reverse creation order and forgets them
+func (t *resourceTracker) drain() []TrackedResource {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	reversed := make([]TrackedResource, 0, len(t.resources))
+	for i := len(t.resources) - 1; i >= 0; i-- {
+		reversed = append(reversed, t.resources[i])
+	}
+	t.resources = nil
+	return reversed
+}
+
+func (t *resourceTracker) list() []TrackedResource {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]TrackedResource{}, t.resources...)
+}
+
+// clusterScopedResources are tracked without a namespace
+var clusterScopedResources = map[string]bool{
+	ResourceNamespace:                  true,
+	ResourceAdminNetworkPolicy:         true,
+	ResourceBaselineAdminNetworkPolicy: true,
+}
+
+// trackedResource identifies an object of the context's namespace, or a cluster scoped object
+func (ctx *TestContext) trackedResource(resourceType, resourceName string) TrackedResource {
+	if clusterScopedResources[resourceType] {
+		return TrackedResource{Kind: resourceType, Name: resourceName}
+	}
+	return TrackedResource{Kind: resourceType, Namespace: ctx.Namespace, Name: resourceName}
+}
+
+// Track records an object in the context's namespace (or a cluster scoped object) so it is deleted when the spec ends.
+// The helpers call it for everything they create; specs only need it for objects created directly.
+func (ctx *TestContext) Track(resourceType, resourceName string) {
+	ctx.tracker.add(ctx.trackedResource(resourceType, resourceName))
+}
+
+// TrackedResources returns the objects currently tracked by the context, in creation order
+func (ctx *TestContext) TrackedResources() []TrackedResource {
+	return ctx.tracker.list()
+}
+
+// Cleanup cleans up resources such as VMs and Pods, ignoring objects that are already gone
 func (ctx *TestContext) CleanupResource(resourceName string, resourceType string) {
-	switch resourceType {
-	case "pod":
-		err := ctx.KubeClient.CoreV1().Pods(ctx.Namespace).Delete(context.TODO(), resourceName, metav1.DeleteOptions{})
-		Expect(err).ToNot(HaveOccurred(), "Failed to delete pod %s", resourceName)
-	case "vm":
-		err := ctx.VirtClient.VirtualMachine(ctx.Namespace).Delete(context.TODO(), resourceName, metav1.DeleteOptions{})
-		Expect(err).ToNot(HaveOccurred(), "Failed to delete VM %s", resourceName)
-	case "service":
-		err := ctx.KubeClient.CoreV1().Services(ctx.Namespace).Delete(context.TODO(), resourceName, metav1.DeleteOptions{})
-		Expect(err).ToNot(HaveOccurred(), "Failed to delete service %s", resourceName)
-	case "route":
-		err := ctx.RouteClient.RouteV1().Routes(ctx.Namespace).Delete(context.TODO(), resourceName, metav1.DeleteOptions{})
-		Expect(err).ToNot(HaveOccurred(), "Failed to delete route %s", resourceName)
-	case "networkPolicy":
-		err := util.DeleteNetworkPolicy(ctx.KubeClient, ctx.Namespace, resourceName)
-		Expect(err).ToNot(HaveOccurred(), "Failed to delete NetworkPolicy %s", resourceName)
-	}
-}
\ No newline at end of file
+	resource := ctx.trackedResource(resourceType, resourceName)
+	err := ctx.deleteAndWait(resource)
+	Expect(err).ToNot(HaveOccurred(), "Failed to delete %s", resource)
+	ctx.tracker.remove(resource)
+}
+
+// CleanupAll deletes every tracked resource in reverse creation order and waits for each one to disappear.
+// Setup registers it with Ginkgo's DeferCleanup, so specs don't need an AfterEach for cleanup.
+func (ctx *TestContext) CleanupAll() {
+	var failures []string
+	for _, resource := range ctx.tracker.drain() {
+		if err := ctx.deleteAndWait(resource); err != nil {
+			util.LogError("Failed to clean up %s: %v", resource, err)
+			failures = append(failures, fmt.Sprintf("%s: %v", resource, err))
+		}
+	}
+	Expect(failures).To(BeEmpty(), "Failed to clean up some resources")
+}
+
+// deleteAndWait deletes the resource and waits until it is gone. NotFound is not an error.
+func (ctx *TestContext) deleteAndWait(resource TrackedResource) error {
+	util.LogInfo("Cleaning up %s", resource)
+	err := ctx.deleteResource(resource)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// The cleanup timeout bounds how long we wait for a single object (and its finalizers) to go away
+	timeout := ctx.TestConfig.Timeouts.Cleanup.Duration
+	return util.WaitForDeletion(context.TODO(), resource.String(), util.ExponentialBackoff(time.Second, 10*time.Second, timeout), func(c context.Context) error {
+		_, err := ctx.getResource(c, resource)
+		return err
+	})
+}
+
+func (ctx *TestContext) deleteResource(resource TrackedResource) error {
+	c, options := context.TODO(), metav1.DeleteOptions{}
+	switch resource.Kind {
+	case ResourcePod:
+		return ctx.KubeClient.CoreV1().Pods(resource.Namespace).Delete(c, resource.Name, options)
+	case ResourceVM:
+		return ctx.VirtClient.VirtualMachine(resource.Namespace).Delete(c, resource.Name, options)
+	case ResourceTemplateInstance:
+		return ctx.TemplateClient.TemplateV1().TemplateInstances(resource.Namespace).Delete(c, resource.Name, options)
+	case ResourceService:
+		return ctx.KubeClient.CoreV1().Services(resource.Namespace).Delete(c, resource.Name, options)
+	case ResourceRoute:
+		return ctx.RouteClient.RouteV1().Routes(resource.Namespace).Delete(c, resource.Name, options)
+	case ResourceIngress:
+		return ctx.KubeClient.NetworkingV1().Ingresses(resource.Namespace).Delete(c, resource.Name, options)
+	case ResourceNetworkPolicy:
+		return ctx.KubeClient.NetworkingV1().NetworkPolicies(resource.Namespace).Delete(c, resource.Name, options)
+	case ResourceSecret:
+		return ctx.KubeClient.CoreV1().Secrets(resource.Namespace).Delete(c, resource.Name, options)
+	case ResourceEgressFirewall:
+		return util.DeleteEgressFirewall(ctx.DynamicClient, resource.Namespace)
+	case ResourceNamespace:
+		return ctx.KubeClient.CoreV1().Namespaces().Delete(c, resource.Name, options)
+	case ResourceAdminNetworkPolicy:
+		return util.DeleteAdminPolicy(ctx.DynamicClient, util.AdminNetworkPolicyKind, resource.Name)
+	case ResourceBaselineAdminNetworkPolicy:
+		return util.DeleteAdminPolicy(ctx.DynamicClient, util.BaselineAdminNetworkPolicyKind, resource.Name)
+	default:
+		return fmt.Errorf("unsupported resource type: %s", resource.Kind)
+	}
+}
+
+// getResource fetches the current version of a tracked resource
+func (ctx *TestContext) getResource(c context.Context, resource TrackedResource) (runtime.Object, error) {
+	options := metav1.GetOptions{}
+	switch resource.Kind {
+	case ResourcePod:
+		return ctx.KubeClient.CoreV1().Pods(resource.Namespace).Get(c, resource.Name, options)
+	case ResourceVM:
+		return ctx.VirtClient.VirtualMachine(resource.Namespace).Get(c, resource.Name, options)
+	case ResourceTemplateInstance:
+		return ctx.TemplateClient.TemplateV1().TemplateInstances(resource.Namespace).Get(c, resource.Name, options)
+	case ResourceService:
+		return ctx.KubeClient.CoreV1().Services(resource.Namespace).Get(c, resource.Name, options)
+	case ResourceRoute:
+		return ctx.RouteClient.RouteV1().Routes(resource.Namespace).Get(c, resource.Name, options)
+	case ResourceIngress:
+		return ctx.KubeClient.NetworkingV1().Ingresses(resource.Namespace).Get(c, resource.Name, options)
+	case ResourceNetworkPolicy:
+		return ctx.KubeClient.NetworkingV1().NetworkPolicies(resource.Namespace).Get(c, resource.Name, options)
+	case ResourceSecret:
+		return ctx.KubeClient.CoreV1().Secrets(resource.Namespace).Get(c, resource.Name, options)
+	case ResourceEgressFirewall:
+		return util.GetEgressFirewall(c, ctx.DynamicClient, resource.Namespace)
+	case ResourceNamespace:
+		return ctx.KubeClient.CoreV1().Namespaces().Get(c, resource.Name, options)
+	case ResourceAdminNetworkPolicy:
+		return util.GetAdminPolicy(c, ctx.DynamicClient, util.AdminNetworkPolicyKind, resource.Name)
+	case ResourceBaselineAdminNetworkPolicy:
+		return util.GetAdminPolicy(c, ctx.DynamicClient, util.BaselineAdminNetworkPolicyKind, resource.Name)
+	default:
+		return nil, fmt.Errorf("unsupported resource type: %s", resource.Kind)
+	}
+}
@@ -0,0 +1,102 @@
+package framework_test
+
+import (
+	"time"
+
+	"myproject/framework"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("Cleanup", func() {
+	var (
+		kubeClient *fake.Clientset
+		ctx        *framework.TestContext
+	)
+
+	BeforeEach(func() {
+		meta := func(name string) metav1.ObjectMeta { return metav1.ObjectMeta{Name: name, Namespace: "ns1"} }
+		kubeClient = fake.NewSimpleClientset(
+			&corev1.Pod{ObjectMeta: meta("server")},
+			&corev1.Service{ObjectMeta: meta("web")},
+			&corev1.Secret{ObjectMeta: meta("tls")},
+		)
+		ctx = framework.NewTestContext(nil, framework.Clients{Kube: kubeClient}, "ns1")
+		ctx.TestConfig.Timeouts.Cleanup = metav1.Duration{Duration: 100 * time.Millisecond}
+	})
+
+	// deletions lists the deleted objects as "<resource>/<name>" in the order of the calls
+	deletions := func() []string {
+		var deleted []string
+		for _, action := range kubeClient.Actions() {
+			if deleteAction, ok := action.(k8stesting.DeleteAction); ok {
+				deleted = append(deleted, deleteAction.GetResource().Resource+"/"+deleteAction.GetName())
+			}
+		}
+		return deleted
+	}
+
+	// keepSecret makes deletions of the secret leave it in place, like a finalizer that never completes
+	// or, with gets > 0, one that completes after that many gets
+	keepSecret := func(gets int) {
+		kubeClient.PrependReactor("delete", "secrets", func(k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, nil
+		})
+		if gets == 0 {
+			return
+		}
+		kubeClient.PrependReactor("get", "secrets", func(k8stesting.Action) (bool, runtime.Object, error) {
+			if gets--; gets == 0 {
+				Expect(kubeClient.Tracker().Delete(corev1.SchemeGroupVersion.WithResource("secrets"), "ns1", "tls")).To(Succeed())
+			}
+			return false, nil, nil
+		})
+	}
+
+	It("should delete tracked resources once, in reverse creation order, ignoring NotFound", func() {
+		ctx.Track(framework.ResourcePod, "server")
+		ctx.Track(framework.ResourceService, "web")
+		ctx.Track(framework.ResourcePod, "already-gone")
+		ctx.Track(framework.ResourcePod, "server")
+		ctx.Track(framework.ResourceSecret, "tls")
+		Expect(ctx.TrackedResources()).To(HaveLen(4))
+
+		ctx.CleanupAll()
+		Expect(deletions()).To(Equal([]string{"secrets/tls", "pods/already-gone", "services/web", "pods/server"}))
+		Expect(ctx.TrackedResources()).To(BeEmpty())
+	})
+
+	It("should wait for finalizers until the object is gone", func() {
+		keepSecret(2)
+		ctx.TestConfig.Timeouts.Cleanup = metav1.Duration{Duration: 10 * time.Second}
+		ctx.Track(framework.ResourceSecret, "tls")
+
+		ctx.CleanupAll()
+		_, err := kubeClient.Tracker().Get(corev1.SchemeGroupVersion.WithResource("secrets"), "ns1", "tls")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should fail when an object is never deleted", func() {
+		keepSecret(0)
+		ctx.Track(framework.ResourceSecret, "tls")
+		ctx.Track(framework.ResourcePod, "server")
+
+		failures := InterceptGomegaFailures(ctx.CleanupAll)
+		Expect(failures).To(ConsistOf(ContainSubstring("secret ns1/tls")))
+		Expect(deletions()).To(Equal([]string{"pods/server", "secrets/tls"}))
+	})
+
+	It("should stop tracking a resource cleaned up by CleanupResource", func() {
+		ctx.Track(framework.ResourcePod, "server")
+		ctx.Track(framework.ResourceService, "web")
+
+		ctx.CleanupResource("server", framework.ResourcePod)
+		Expect(deletions()).To(Equal([]string{"pods/server"}))
+		Expect(ctx.TrackedResources()).To(Equal([]framework.TrackedResource{{Kind: framework.ResourceService, Namespace: "ns1", Name: "web"}}))
+	})
+})
@@ -0,0 +1,91 @@
+package framework
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"myproject/util"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PodEndpoint describes a running pod of the context's namespace as a connectivity matrix endpoint.
+// Probes from it are executed inside the pod, so it should run the client image (see CreateClientPod).
+func (ctx *TestContext) PodEndpoint(podName string) util.Endpoint {
+	pod, err := ctx.KubeClient.CoreV1().Pods(ctx.Namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+	Expect(err).ToNot(HaveOccurred(), "Failed to get pod %s", podName)
+	Expect(pod.Status.PodIP).ToNot(BeEmpty(), "Pod %s has no IP", podName)
+
+	endpoint := util.Endpoint{
+		Name:      fmt.Sprintf("%s/%s", ctx.Namespace, podName),
+		Namespace: ctx.Namespace,
+		IP:        pod.Status.PodIP,
+		Labels:    pod.Labels,
+		Executor:  ctx.PodProber(podName),
+	}
+	for _, container := range pod.Spec.Containers {
+		endpoint.Ports = append(endpoint.Ports, container.Ports...)
+	}
+	return endpoint
+}
+
+// VMEndpoint describes a running VM of the context's namespace as a connectivity matrix endpoint.
+// Probes from it run over SSH; without an SSH client the VM is only used as a destination.
+// Set Ports to what the VM listens on before simulating policies, SimulateReachability needs them.
+func (ctx *TestContext) VMEndpoint(vmName string, sshClient *util.SSHClient) util.Endpoint {
+	vm, err := ctx.VirtClient.VirtualMachine(ctx.Namespace).Get(context.TODO(), vmName, metav1.GetOptions{})
+	Expect(err).ToNot(HaveOccurred(), "Failed to get VM %s", vmName)
+
+	ip, err := util.GetVMPodIP(ctx.VirtClient, ctx.Namespace, vmName)
+	Expect(err).ToNot(HaveOccurred(), "Failed to get the IP of VM %s", vmName)
+
+	endpoint := util.Endpoint{
+		Name:      fmt.Sprintf("%s/%s", ctx.Namespace, vmName),
+		Namespace: ctx.Namespace,
+		IP:        ip,
+	}
+	// The launcher pod carries the labels of the VMI template
+	if vm.Spec.Template != nil {
+		endpoint.Labels = vm.Spec.Template.ObjectMeta.Labels
+	}
+	if sshClient != nil {
+		endpoint.Executor = ctx.SSHProber(sshClient)
+	}
+	return endpoint
+}
+
+// SimulateReachability computes the expected table of the endpoints on the port from the
+// NetworkPolicies currently in their namespaces, without sending any traffic
+func (ctx *TestContext) SimulateReachability(endpoints []util.Endpoint, port util.MatrixPort) *util.Reachability {
+	var namespaces []string
+	for _, endpoint := range endpoints {
+		namespaces = append(namespaces, endpoint.Namespace)
+	}
+	simulator, err := util.NewPolicySimulatorFromCluster(ctx.KubeClient, namespaces...)
+	Expect(err).ToNot(HaveOccurred(), "Failed to load the network policies to simulate")
+
+	expected := simulator.Reachability(endpoints, port)
+	util.LogInfo("Simulated reachability on port %s:\n%s", port, expected.Grid())
+	return expected
+}
+
+// ExpectConnectivity probes every pair of endpoints on the port until the observed reachability matches
+// the expected table, and fails with the expected, observed and comparison grids otherwise
+func (ctx *TestContext) ExpectConnectivity(endpoints []util.Endpoint, port util.MatrixPort, expected *util.Reachability, timeout time.Duration) {
+	var last *util.MatrixResult
+	Eventually(func() ([]string, error) {
+		result, err := util.ProbeMatrix(context.TODO(), util.ExecutorProber{}, endpoints, port, expected, util.DefaultMatrixConcurrency)
+		if err != nil {
+			return nil, err
+		}
+		last = result
+		return result.Mismatches(), nil
+	}, timeout, 10*time.Second).Should(BeEmpty(), func() string {
+		if last == nil {
+			return "The connectivity matrix could not be probed"
+		}
+		return fmt.Sprintf("Unexpected connectivity\n%s\n%s", last.Diff(), strings.Join(last.Mismatches(), "\n"))
+	})
+}
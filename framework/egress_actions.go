@@ -0,0 +1,67 @@
+package framework
+
+import (
+	"context"
+	"time"
+
+	"myproject/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// NewEgressFirewall starts the EgressFirewall of the context's namespace carrying the spec's labels
+func (ctx *TestContext) NewEgressFirewall() *util.EgressFirewallBuilder {
+	return util.NewEgressFirewallBuilder(ctx.Namespace).WithLabels(ctx.Labeler.Labels())
+}
+
+// ApplyEgressFirewall builds the EgressFirewall, creates it, tracks it for cleanup and waits until OVN-Kubernetes has applied it
+func (ctx *TestContext) ApplyEgressFirewall(builder *util.EgressFirewallBuilder, timeout time.Duration) *unstructured.Unstructured {
+	firewall, err := builder.Build()
+	Expect(err).ToNot(HaveOccurred(), "Invalid EgressFirewall")
+	Expect(firewall.GetNamespace()).To(Equal(ctx.Namespace), "The EgressFirewall must be applied from the context of its namespace")
+
+	// Track only what this spec created, the namespace may already have its own firewall
+	created, err := util.CreateEgressFirewall(ctx.DynamicClient, firewall)
+	Expect(err).ToNot(HaveOccurred(), "Failed to create EgressFirewall in %s", ctx.Namespace)
+	ctx.Track(ResourceEgressFirewall, firewall.GetName())
+
+	err = util.WaitForEgressFirewallReady(context.TODO(), ctx.DynamicClient, ctx.Namespace, util.ExponentialBackoff(time.Second, 10*time.Second, timeout))
+	Expect(err).ToNot(HaveOccurred(), "EgressFirewall in %s was not applied", ctx.Namespace)
+	return created
+}
+
+// SkipWithoutStandInHost skips the spec when no stand-in host is configured. Call it before Setup, so
+// skipped specs don't create a namespace for nothing.
+func SkipWithoutStandInHost() {
+	suiteConfig, err := LoadTestConfig()
+	Expect(err).ToNot(HaveOccurred(), "Failed to load the test configuration")
+	if suiteConfig.Egress.StandInHost == "" {
+		Skip("No stand-in host configured for egress specs")
+	}
+}
+
+// StartStandInServer starts the configured stand-in HTTP server for the spec and stops it when the spec ends.
+// The spec is skipped when no stand-in host is configured (egress.standInHost or TEST_EGRESS_STANDIN_HOST).
+func (ctx *TestContext) StartStandInServer() *util.StandInServer {
+	egress := ctx.TestConfig.Egress
+	if egress.StandInHost == "" {
+		Skip("No stand-in host configured for egress specs")
+	}
+
+	server, err := util.StartStandInServer(egress.StandInListen, egress.StandInHost)
+	Expect(err).ToNot(HaveOccurred(), "Failed to start the stand-in server")
+	DeferCleanup(server.Close)
+	return server
+}
+
+// ExpectEgressAllowed repeats a TCP probe from the pod until it connects to the external host and port
+func (ctx *TestContext) ExpectEgressAllowed(podName, host string, port int, timeout time.Duration) util.ProbeResult {
+	return ctx.ExpectReachable(ctx.PodProber(podName), util.TCPProbe{Host: host, Port: port}, timeout)
+}
+
+// ExpectEgressDenied repeats a TCP probe from the pod until the connection to the external host and port
+// times out or is refused. Name resolution failures don't count, they would hide a missing rule.
+func (ctx *TestContext) ExpectEgressDenied(podName, host string, port int, timeout time.Duration) util.ProbeResult {
+	return ctx.ExpectUnreachable(ctx.PodProber(podName), util.TCPProbe{Host: host, Port: port}, timeout, util.ProbeErrorTimeout, util.ProbeErrorRefused)
+}
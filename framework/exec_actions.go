@@ -0,0 +1,86 @@
+package framework
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"myproject/util"
+	. "github.com/onsi/gomega"
+)
+
+// clientPodCommand keeps a client pod running so commands can be executed in it
+var clientPodCommand = []string{"sleep", "infinity"}
+
+// identifiedIndexScript writes the pod name as index page in the document root of the httpd image,
+// which depends on whether it is the upstream or the UBI image
+const identifiedIndexScript = `written=; for dir in /usr/local/apache2/htdocs /var/www/html; do if [ -d "$dir" ]; then echo "$1" > "$dir/index.html" && written=1; fi; done; [ -n "$written" ]`
+
+// slowServerScript answers every GET with the pod name after a delay, on port 8080. It runs with the
+// python3 of the client image, or the platform-python of UBI 8.
+const slowServerScript = `
+import http.server, sys, time
+name, delay = sys.argv[1], float(sys.argv[2])
+class Handler(http.server.BaseHTTPRequestHandler):
+    def do_GET(self):
+        time.sleep(delay)
+        body = (name + "\n").encode()
+        self.send_response(200)
+        self.send_header("Content-Length", str(len(body)))
+        self.end_headers()
+        self.wfile.write(body)
+http.server.HTTPServer(("", 8080), Handler).serve_forever()
+`
+
+// execTimeout bounds a single command run through the helpers
+const execTimeout = 30 * time.Second
+
+// CreateClientPod starts a long-lived pod with the client image that only sleeps.
+// Checks are then executed inside it with ExecInPodHelper or VerifyHTTPFromPod, which
+// avoids creating a new pod (and waiting for it to start) for every assertion.
+func (ctx *TestContext) CreateClientPod(podName string) {
+	containers := []util.ContainerConfig{
+		util.CreateContainerConfig("client", ctx.TestConfig.Images.Client, clientPodCommand, util.GenerateResourceRequirements("100m", "400m", "200Mi", "200Mi")),
+	}
+	ctx.CreateTestPodHelper(podName, containers, 3)
+}
+
+// CreateIdentifiedServerPod starts an httpd pod whose index page is its own name, so responses tell
+// which backend answered (see ExpectRouteTrafficSplit)
+func (ctx *TestContext) CreateIdentifiedServerPod(podName string) {
+	containers := []util.ContainerConfig{
+		util.CreateContainerConfig("httpd-container", ctx.TestConfig.Images.Httpd, nil, util.GenerateResourceRequirements("250m", "1000m", "1Gi", "1Gi")),
+	}
+	ctx.CreateTestPodHelper(podName, containers, 3)
+
+	result := ctx.ExecInPodHelper(podName, util.ExecOptions{Command: []string{"sh", "-c", identifiedIndexScript, "sh", podName}})
+	Expect(result.ExitCode).To(BeZero(), "Failed to write the index page of pod %s: %s", podName, result.Stderr)
+}
+
+// CreateSlowServerPod starts a pod with the client image answering on port 8080 with its name after
+// the delay, e.g. as a backend slower than a route timeout
+func (ctx *TestContext) CreateSlowServerPod(podName string, delay time.Duration) {
+	command := []string{"sh", "-c", `exec "$(command -v python3 || echo /usr/libexec/platform-python)" -c "$0" "$1" "$2"`,
+		slowServerScript, podName, strconv.FormatFloat(delay.Seconds(), 'f', -1, 64)}
+	containers := []util.ContainerConfig{
+		util.CreateContainerConfig("slow-server", ctx.TestConfig.Images.Client, command, util.GenerateResourceRequirements("100m", "400m", "200Mi", "200Mi")),
+	}
+	ctx.CreateTestPodHelper(podName, containers, 3)
+}
+
+// ExecInPodHelper runs a command in a pod of the context's namespace and fails the spec if it could not be run.
+// A non-zero exit code is not a failure, it is returned in the result.
+func (ctx *TestContext) ExecInPodHelper(podName string, options util.ExecOptions) *util.ExecResult {
+	if options.Timeout == 0 {
+		options.Timeout = execTimeout
+	}
+	result, err := util.ExecInPod(context.TODO(), ctx.Config, ctx.KubeClient, ctx.Namespace, podName, options)
+	Expect(err).ToNot(HaveOccurred(), "Failed to run %q in pod %s", strings.Join(options.Command, " "), podName)
+	return result
+}
+
+// VerifyHTTPFromPod requests the URL from inside the pod until it answers with the expected status code
+func (ctx *TestContext) VerifyHTTPFromPod(podName, url string, expectedStatus int, timeout time.Duration) {
+	ctx.ExpectHTTPStatus(ctx.PodProber(podName), url, expectedStatus, timeout)
+}
@@ -0,0 +1,13 @@
+package framework_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestFramework(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Framework Suite")
+}
@@ -0,0 +1,106 @@
+package framework
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"myproject/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	routev1 "github.com/openshift/api/route/v1"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Exposure is how a service is published outside the cluster, so the same spec can run against both
+type Exposure string
+
+const (
+	ExposureRoute   Exposure = "Route"
+	ExposureIngress Exposure = "Ingress" // Turned into Routes by OpenShift
+)
+
+// CreateIngressHelper creates an Ingress carrying the spec's labels and tracks it for cleanup
+func (ctx *TestContext) CreateIngressHelper(ingressName string, options util.IngressOptions) *netv1.Ingress {
+	options.Labels = util.MergeLabels(ctx.Labeler.Labels(), options.Labels)
+	ctx.Track(ResourceIngress, ingressName)
+	ingress, err := util.CreateIngress(ctx.KubeClient, ctx.Namespace, ingressName, options)
+	Expect(err).ToNot(HaveOccurred(), "Failed to create Ingress %s", ingressName)
+	return ingress
+}
+
+// WaitForIngressURL waits until the Route generated for the host of the Ingress is admitted and returns its URL
+func (ctx *TestContext) WaitForIngressURL(ingressName, host string, timeout time.Duration) string {
+	admission, err := util.WaitForIngressRoute(context.TODO(), ctx.RouteClient, ctx.Namespace, ingressName, host, util.ConstantBackoff(5*time.Second, timeout, 0))
+	Expect(err).ToNot(HaveOccurred(), "Expected a Route for host %s of Ingress %s to be admitted", host, ingressName)
+	return admission.URL
+}
+
+// WaitForIngressAddress waits until the Ingress reports its load balancer IP or hostname and returns it
+func (ctx *TestContext) WaitForIngressAddress(ingressName string, timeout time.Duration) string {
+	address, err := util.WaitForIngressLoadBalancer(context.TODO(), ctx.KubeClient, ctx.Namespace, ingressName, util.ConstantBackoff(5*time.Second, timeout, 0))
+	Expect(err).ToNot(HaveOccurred(), "Expected Ingress %s to report its address", ingressName)
+	return address
+}
+
+// AppsHost returns a host for the name in the cluster's apps domain, <name>-<namespace> like the ones
+// assigned to Routes, shortened with a hash when that is longer than a DNS label allows
+func (ctx *TestContext) AppsHost(name string) string {
+	domain, err := util.GetClusterAppsDomain(ctx.DynamicClient)
+	Expect(err).ToNot(HaveOccurred(), "Failed to get the cluster apps domain")
+	return util.HostLabel(name, ctx.Namespace) + "." + domain
+}
+
+// ExposeService publishes a service port through a Route or an Ingress named name, over https with
+// the router's default certificate if tls is set, and returns the URL once a router serves it
+func (ctx *TestContext) ExposeService(exposure Exposure, name, serviceName string, servicePort int, tls bool, timeout time.Duration) string {
+	switch exposure {
+	case ExposureRoute:
+		// Set the host, the generated <name>-<namespace> one can be longer than a DNS label allows
+		options := util.RouteOptions{Hostname: ctx.AppsHost(name)}
+		if tls {
+			options.Termination = routev1.TLSTerminationEdge
+		}
+		ctx.CreateRouteWithOptionsHelper(name, serviceName, ctx.routeTargetPort(serviceName, servicePort), options)
+		return ctx.WaitForRouteURL(name, timeout, 5*time.Second)
+
+	case ExposureIngress:
+		host := ctx.AppsHost(name)
+		options := util.IngressOptions{Rules: []util.IngressRule{{Host: host, ServiceName: serviceName, ServicePort: servicePort}}}
+		if tls {
+			options.TLS = []netv1.IngressTLS{{Hosts: []string{host}}}
+		}
+		ctx.CreateIngressHelper(name, options)
+		return ctx.WaitForIngressURL(name, host, timeout)
+
+	default:
+		Fail(fmt.Sprintf("Unknown exposure %q", exposure))
+		return ""
+	}
+}
+
+// routeTargetPort returns what a Route needs to reach the service port: its name, or the target port
+func (ctx *TestContext) routeTargetPort(serviceName string, servicePort int) interface{} {
+	service, err := ctx.KubeClient.CoreV1().Services(ctx.Namespace).Get(context.TODO(), serviceName, metav1.GetOptions{})
+	Expect(err).ToNot(HaveOccurred(), "Failed to get service %s", serviceName)
+	for _, port := range service.Spec.Ports {
+		if int(port.Port) != servicePort {
+			continue
+		}
+		if port.Name != "" {
+			return port.Name
+		}
+		switch {
+		case port.TargetPort.Type == intstr.String:
+			return port.TargetPort.StrVal
+		case port.TargetPort.IntValue() != 0:
+			return port.TargetPort.IntValue()
+		default:
+			return servicePort // No target port means the same as the port
+		}
+	}
+	Fail(fmt.Sprintf("Service %s has no port %d", serviceName, servicePort))
+	return nil
+}
@@ -0,0 +1,92 @@
+package framework
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"myproject/consts"
+	"myproject/util"
+	. "github.com/onsi/gomega"
+)
+
+// maxNamespaceLength is the limit Kubernetes puts on namespace names (a DNS label)
+const maxNamespaceLength = 63
+
+// NamespaceOptions controls how ephemeral namespaces are created
+type NamespaceOptions struct {
+	UseProjectRequest bool              // Create an OpenShift Project through a ProjectRequest instead of a plain Namespace
+	Labels            map[string]string // Extra labels added to the namespace
+	ServiceAccounts   []string          // Service accounts to wait for, defaults to "default"
+}
+
+// SetupEphemeral is like Setup but runs the spec in a new, uniquely named and labelled namespace
+// that is deleted on teardown. Called from BeforeEach it lives for a single spec, called from
+// BeforeAll or BeforeSuite it is shared by the whole container or suite.
+func SetupEphemeral(baseName string) *TestContext {
+	return SetupEphemeralWithOptions(baseName, NamespaceOptions{})
+}
+
+// SetupEphemeralWithOptions is SetupEphemeral with control over how the namespace is created
+func SetupEphemeralWithOptions(baseName string, options NamespaceOptions) *TestContext {
+	ctx := Setup("")
+	ctx.Namespace = ctx.createEphemeralNamespace(baseName, options)
+	return ctx
+}
+
+// AddNamespace creates one more ephemeral namespace and returns a context for it.
+// The returned context shares clients, random name and cleanup with ctx, so multi-namespace
+// specs can request as many namespaces as they need from a single Setup.
+func (ctx *TestContext) AddNamespace(baseName string) *TestContext {
+	return ctx.AddNamespaceWithOptions(baseName, NamespaceOptions{})
+}
+
+// AddNamespaceWithOptions is AddNamespace with control over how the namespace is created
+func (ctx *TestContext) AddNamespaceWithOptions(baseName string, options NamespaceOptions) *TestContext {
+	derived := *ctx
+	derived.Namespace = ctx.createEphemeralNamespace(baseName, options)
+	return &derived
+}
+
+// createEphemeralNamespace creates and tracks the namespace, then waits for its service accounts
+func (ctx *TestContext) createEphemeralNamespace(baseName string, options NamespaceOptions) string {
+	name := ephemeralNamespaceName(ctx.TestConfig.Namespaces.Prefix, baseName)
+
+	labels := util.MergeLabels(ctx.Labeler.Labels(), options.Labels, map[string]string{consts.EphemeralLabel: "true"})
+
+	// Track first so the namespace is removed after everything created inside it
+	ctx.tracker.add(TrackedResource{Kind: ResourceNamespace, Name: name})
+
+	var err error
+	if options.UseProjectRequest {
+		_, err = util.CreateProject(ctx.ProjectClient, ctx.KubeClient, name, labels)
+	} else {
+		_, err = util.CreateNamespace(ctx.KubeClient, name, labels)
+	}
+	Expect(err).ToNot(HaveOccurred(), "Failed to create ephemeral namespace %s", name)
+
+	serviceAccounts := options.ServiceAccounts
+	if len(serviceAccounts) == 0 {
+		serviceAccounts = []string{"default"}
+	}
+	err = util.WaitForServiceAccounts(context.TODO(), ctx.KubeClient, name, serviceAccounts, util.ExponentialBackoff(time.Second, 5*time.Second, 2*time.Minute))
+	Expect(err).ToNot(HaveOccurred(), "Service accounts were not created in namespace %s", name)
+
+	return name
+}
+
+// ephemeralNamespaceName builds "<prefix>-<base>-<random>", shortening the base name to fit a DNS label
+func ephemeralNamespaceName(prefix, baseName string) string {
+	suffix := util.GenerateRandomName()
+	maxBase := maxNamespaceLength - len(prefix) - len(suffix) - 2
+	if maxBase < 0 {
+		maxBase = 0
+	}
+	if len(baseName) > maxBase {
+		baseName = baseName[:maxBase]
+	}
+	if baseName == "" {
+		return fmt.Sprintf("%s-%s", prefix, suffix)
+	}
+	return fmt.Sprintf("%s-%s-%s", prefix, baseName, suffix)
+}
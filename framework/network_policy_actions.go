@@ -0,0 +1,31 @@
+package framework
+
+import (
+	"myproject/util"
+	. "github.com/onsi/gomega"
+	netv1 "k8s.io/api/networking/v1"
+)
+
+// CreateNetworkPolicyWithNamespaceAllowHelper creates a NetworkPolicy allowing ingress from other namespaces on the given ports
+func (ctx *TestContext) CreateNetworkPolicyWithNamespaceAllowHelper(policyName string, allowPorts []netv1.NetworkPolicyPort) {
+	ctx.Track(ResourceNetworkPolicy, policyName)
+	_, err := util.CreateNetworkPolicyWithNamespaceAllow(ctx.KubeClient, ctx.Namespace, policyName, allowPorts)
+	Expect(err).ToNot(HaveOccurred(), "Failed to create network policy %s with allow rule", policyName)
+}
+
+// NewNetworkPolicy starts a NetworkPolicy in the context's namespace carrying the spec's labels
+func (ctx *TestContext) NewNetworkPolicy(policyName string) *util.NetworkPolicyBuilder {
+	return util.NewNetworkPolicyBuilder(ctx.Namespace, policyName).WithLabels(ctx.Labeler.Labels())
+}
+
+// ApplyNetworkPolicy builds the policy, creates it and tracks it for cleanup
+func (ctx *TestContext) ApplyNetworkPolicy(builder *util.NetworkPolicyBuilder) *netv1.NetworkPolicy {
+	policy, err := builder.Build()
+	Expect(err).ToNot(HaveOccurred(), "Invalid network policy")
+	Expect(policy.Namespace).To(Equal(ctx.Namespace), "Network policy %s must be applied from the context of its namespace", policy.Name)
+
+	ctx.Track(ResourceNetworkPolicy, policy.Name)
+	created, err := util.CreateNetworkPolicy(ctx.KubeClient, policy)
+	Expect(err).ToNot(HaveOccurred(), "Failed to create network policy %s", policy.Name)
+	return created
+}
@@ -1,6 +1,7 @@
 package framework
 
 import (
+	"context"
 	"time"
 	"strings"
 	"myproject/util"
@@ -12,10 +13,11 @@ import (
 // CreateTestPodHelper creates a test pod with a retry mechanism
 func (ctx *TestContext) CreateTestPodHelper(podName string, containers []util.ContainerConfig, retries int) {
 	util.LogInfo("Creating test pod %s with retry mechanism", podName)
-	_, err := util.RetryPodCreationWithWait(ctx.KubeClient, ctx.Config, ctx.Namespace, podName, containers, nil, 15*time.Second, 5*time.Minute, retries)
+	ctx.Track(ResourcePod, podName)
+	_, err := util.RetryPodCreationWithWait(context.TODO(), ctx.KubeClient, ctx.Namespace, podName, containers, ctx.Labeler.For(podName), 15*time.Second, ctx.TestConfig.Timeouts.PodReady.Duration, retries)
 	if err != nil {
 		util.LogError("Failed to create test pod %s: %v", podName, err)
-		Expect(errors.Wrap(err, "failed to create test client pod after retries")).ToNot(HaveOccurred())
+		Expect(errors.Wrap(err, "failed to create test client pod after retries")).ToNot(HaveOccurred(), "%s", util.DescribeWaitFailure(err))
 	}
 	util.LogInfo("Successfully created test pod %s", podName)
 }
@@ -23,8 +25,11 @@ func (ctx *TestContext) CreateTestPodHelper(podName string, containers []util.Co
 // CreateTestPodExpectingFailureHelper creates a test pod and expects it to fail (e.g., due to NetworkPolicy restrictions).
 func (ctx *TestContext) CreateTestPodExpectingFailureHelper(podName string, containers []util.ContainerConfig, retries int) {
 	util.LogInfo("Creating test pod %s, expecting failure", podName)
-	_, err := util.RetryPodCreationWithWait(ctx.KubeClient, ctx.Config, ctx.Namespace, podName, containers, nil, 10*time.Second, 1*time.Minute, retries)
+	ctx.Track(ResourcePod, podName)
+	_, err := util.RetryPodCreationWithWait(context.TODO(), ctx.KubeClient, ctx.Namespace, podName, containers, ctx.Labeler.For(podName), 10*time.Second, 1*time.Minute, retries)
 	if err != nil {
+		// A pod that could never start says nothing about the behaviour under test
+		Expect(errors.Is(err, util.ErrPodUnrecoverable)).To(BeFalse(), "Pod %s failed for an unrelated reason\n%s", podName, util.DescribeWaitFailure(err))
 		util.LogInfo("Pod %s failed as expected", podName)
 	} else {
 		util.LogError("Pod %s did not fail as expected", podName)
@@ -37,7 +42,7 @@ func (ctx *TestContext) WaitForPodAndCheckLogs(podName, logSubstring string, che
 	util.LogInfo("Waiting for pod %s to complete and checking logs for substring: %s", podName, logSubstring)
 
 	// Wait for the pod to complete (successfully or with failure)
-	err := util.WaitForPodState(ctx.KubeClient, ctx.Namespace, podName, checkInterval, timeout, retries, false)
+	err := util.WaitForPodState(context.TODO(), ctx.KubeClient, ctx.Namespace, podName, util.ConstantBackoff(checkInterval, timeout, retries), false)
 	if err != nil {
 		util.LogError("Failed to wait for pod %s completion/failure: %v", podName, err)
 		return err
@@ -64,5 +69,5 @@ func (ctx *TestContext) WaitForPodAndCheckLogs(podName, logSubstring string, che
 // VerifyPodResponse verifies that the pod logs contain the expected response, with retries.
 func (ctx *TestContext) VerifyPodResponse(podName, expectedResponse string, retries int) {
 	err := ctx.WaitForPodAndCheckLogs(podName, expectedResponse, 10*time.Second, 3*time.Minute, retries)
-	Expect(err).ToNot(HaveOccurred(), "Pod %s returned a different response", podName)
+	Expect(err).ToNot(HaveOccurred(), "Pod %s returned a different response\n%s", podName, util.DescribeWaitFailure(err))
 }
\ No newline at end of file
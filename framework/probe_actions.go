@@ -0,0 +1,93 @@
+package framework
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"myproject/util"
+	. "github.com/onsi/gomega"
+)
+
+// probeInterval is how often the expectations repeat a probe until it gives the expected result
+const probeInterval = 5 * time.Second
+
+// PodProber returns an executor running probes inside an existing pod of the context's namespace,
+// such as one started with CreateClientPod
+func (ctx *TestContext) PodProber(podName string) util.ProbeExecutor {
+	return util.PodExecProbeExecutor{Config: ctx.Config, Clientset: ctx.KubeClient, Namespace: ctx.Namespace, Pod: podName, Timeout: execTimeout}
+}
+
+// EphemeralProber returns an executor running every probe in a new client pod of the context's namespace
+func (ctx *TestContext) EphemeralProber() util.ProbeExecutor {
+	return util.EphemeralPodProbeExecutor{
+		Clientset: ctx.KubeClient,
+		Namespace: ctx.Namespace,
+		Image:     ctx.TestConfig.Images.Client,
+		Labels:    ctx.Labeler.Labels(),
+		Timeout:   ctx.TestConfig.Timeouts.PodReady.Duration,
+	}
+}
+
+// SSHProber returns an executor running probes on a VM through the SSH client
+func (ctx *TestContext) SSHProber(client *util.SSHClient) util.ProbeExecutor {
+	return util.SSHProbeExecutor{Client: client}
+}
+
+// RunProbe runs a single probe and fails the spec if it could not be run at all
+func (ctx *TestContext) RunProbe(executor util.ProbeExecutor, probe util.Probe) util.ProbeResult {
+	result, err := util.RunProbe(context.TODO(), executor, probe)
+	Expect(err).ToNot(HaveOccurred(), "Failed to run probe %s", probe)
+	return result
+}
+
+// ExpectReachable repeats the probe until it reaches its target and returns the successful result
+func (ctx *TestContext) ExpectReachable(executor util.ProbeExecutor, probe util.Probe, timeout time.Duration) util.ProbeResult {
+	var last util.ProbeResult
+	Eventually(func() (bool, error) {
+		var err error
+		last, err = util.RunProbe(context.TODO(), executor, probe)
+		return last.Reachable, err
+	}, timeout, probeInterval).Should(BeTrue(), func() string {
+		return fmt.Sprintf("Expected %s to be reachable from %s, last result: %s", probe, executor, last)
+	})
+	return last
+}
+
+// ExpectUnreachable repeats the probe until it fails, with one of the given error classes if any are given
+func (ctx *TestContext) ExpectUnreachable(executor util.ProbeExecutor, probe util.Probe, timeout time.Duration, classes ...util.ProbeErrorClass) util.ProbeResult {
+	var last util.ProbeResult
+	Eventually(func() (bool, error) {
+		var err error
+		last, err = util.RunProbe(context.TODO(), executor, probe)
+		if err != nil || last.Reachable {
+			return false, err
+		}
+		if len(classes) == 0 {
+			return true, nil
+		}
+		for _, class := range classes {
+			if last.ErrorClass == class {
+				return true, nil
+			}
+		}
+		return false, nil
+	}, timeout, probeInterval).Should(BeTrue(), func() string {
+		return fmt.Sprintf("Expected %s to be unreachable (%v) from %s, last result: %s", probe, classes, executor, last)
+	})
+	return last
+}
+
+// ExpectHTTPStatus repeats an HTTP probe until it gets the expected status code
+func (ctx *TestContext) ExpectHTTPStatus(executor util.ProbeExecutor, url string, expectedStatus int, timeout time.Duration) util.ProbeResult {
+	probe := util.HTTPProbe{URL: url}
+	var last util.ProbeResult
+	Eventually(func() (int, error) {
+		var err error
+		last, err = util.RunProbe(context.TODO(), executor, probe)
+		return last.StatusCode, err
+	}, timeout, probeInterval).Should(Equal(expectedStatus), func() string {
+		return fmt.Sprintf("Unexpected response for %s from %s, last result: %s", url, executor, last)
+	})
+	return last
+}
@@ -1,15 +1,29 @@
 package framework
 
 import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"net"
 	"time"
 	"myproject/util"
 	. "github.com/onsi/gomega"
+	routev1 "github.com/openshift/api/route/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // CreateRouteHelper creates a route for the given service with the given port and hostname
 func (ctx *TestContext) CreateRouteHelper(routeName, serviceName string, targetPort interface{}, hostname string) {
-    err := util.CreateRoute(ctx.RouteClient, ctx.Namespace, routeName, serviceName, targetPort, hostname)
-    Expect(err).ToNot(HaveOccurred(), "Failed to create route %s", routeName)
+    ctx.CreateRouteWithOptionsHelper(routeName, serviceName, targetPort, util.RouteOptions{Hostname: hostname})
+}
+
+// CreateRouteWithOptionsHelper creates a route for the given service, with TLS if the options ask for it
+func (ctx *TestContext) CreateRouteWithOptionsHelper(routeName, serviceName string, targetPort interface{}, options util.RouteOptions) *routev1.Route {
+	options.Labels = util.MergeLabels(ctx.Labeler.Labels(), options.Labels)
+	ctx.Track(ResourceRoute, routeName)
+	route, err := util.CreateRouteWithOptions(ctx.RouteClient, ctx.Namespace, routeName, serviceName, targetPort, options)
+	Expect(err).ToNot(HaveOccurred(), "Failed to create route %s", routeName)
+	return route
 }
 
 // GetRouteURLHelper fetches the URL of a route and returns it
@@ -19,14 +33,75 @@ func (ctx *TestContext) GetRouteURLHelper(routeName string) string {
 	return routeURL
 }
 
-// WaitForRouteURL waits for a route to get a valid URL within a specified timeout
+// WaitForRouteURL waits until a router admits the route and returns the URL of the admitted host
 func (ctx *TestContext) WaitForRouteURL(routeName string, timeout, interval time.Duration) string {
-	var routeURL string
+	admission, err := util.WaitForRouteAdmitted(context.TODO(), ctx.RouteClient, ctx.Namespace, routeName, "", util.ConstantBackoff(interval, timeout, 0))
+	Expect(err).ToNot(HaveOccurred(), "Expected route %s to be admitted", routeName)
+	return admission.URL
+}
+
+// ExpectRouteRejected waits until the routers reject the route and checks the reason, e.g. HostAlreadyClaimed
+func (ctx *TestContext) ExpectRouteRejected(routeName, reason string, timeout time.Duration) {
+	_, err := util.WaitForRouteAdmitted(context.TODO(), ctx.RouteClient, ctx.Namespace, routeName, "", util.ConstantBackoff(5*time.Second, timeout, 0))
+	var rejected *util.RouteRejectedError
+	Expect(errors.As(err, &rejected)).To(BeTrue(), "Expected route %s to be rejected, got %v", routeName, err)
+	Expect(rejected.HasReason(reason)).To(BeTrue(), "Expected route %s to be rejected with %s: %v", routeName, reason, rejected)
+}
 
-	Eventually(func() (string, error) {
-		routeURL = ctx.GetRouteURLHelper(routeName)
-		return routeURL, nil
-	}, timeout, interval).ShouldNot(BeEmpty(), "Expected route to get a valid URL")
+// ExpectServedCertificate connects to address with serverName as SNI until the served chain leads to
+// one of the roots and the leaf is valid for serverName. A nil pool uses the system roots.
+func (ctx *TestContext) ExpectServedCertificate(address, serverName string, roots *x509.CertPool, timeout time.Duration) []*x509.Certificate {
+	var certs []*x509.Certificate
+	Eventually(func() error {
+		c, cancel := context.WithTimeout(context.TODO(), 10*time.Second)
+		defer cancel()
+		served, err := util.FetchServedCertificates(c, address, serverName)
+		if err != nil {
+			return err
+		}
+		certs = served
+		return util.VerifyServedCertificates(served, roots, serverName)
+	}, timeout, 5*time.Second).Should(Succeed(), "Expected %s to serve a valid certificate for %s", address, serverName)
 
-	return routeURL
-}
\ No newline at end of file
+	return certs
+}
+
+// ExpectRouteCertificate checks the certificate the router serves for the host of a TLS route.
+// Pass the route's CA, or util.GetDefaultIngressCA for routes without their own certificate.
+func (ctx *TestContext) ExpectRouteCertificate(routeName string, roots *x509.CertPool, timeout time.Duration) []*x509.Certificate {
+	route, err := ctx.RouteClient.RouteV1().Routes(ctx.Namespace).Get(context.TODO(), routeName, metav1.GetOptions{})
+	Expect(err).ToNot(HaveOccurred(), "Failed to get Route %s", routeName)
+	Expect(route.Spec.TLS).ToNot(BeNil(), "Route %s is not a TLS route", routeName)
+	Expect(route.Spec.Host).ToNot(BeEmpty(), "Route %s has no assigned host", routeName)
+
+	return ctx.ExpectServedCertificate(net.JoinHostPort(route.Spec.Host, "443"), route.Spec.Host, roots, timeout)
+}
+
+// ExpectRouteTrafficSplit sends the requests through the route from the executor and checks that every
+// backend answered its weighted share within the tolerance (a fraction of all requests, e.g. 0.1).
+// identifiers maps the services of the route to the first line of their response bodies, such as
+// the name of a pod created with CreateIdentifiedServerPod, one per service. Wait for the route to serve traffic first.
+func (ctx *TestContext) ExpectRouteTrafficSplit(executor util.ProbeExecutor, routeName string, identifiers map[string]string, requests int, tolerance float64) util.TrafficSplit {
+	route, err := ctx.RouteClient.RouteV1().Routes(ctx.Namespace).Get(context.TODO(), routeName, metav1.GetOptions{})
+	Expect(err).ToNot(HaveOccurred(), "Failed to get Route %s", routeName)
+	Expect(route.Spec.Host).ToNot(BeEmpty(), "Route %s has no assigned host", routeName)
+
+	weights := map[string]int32{}
+	var ids []string
+	for service, weight := range util.RouteBackendWeights(route) {
+		identifier, ok := identifiers[service]
+		Expect(ok).To(BeTrue(), "No identifier given for service %s of route %s", service, routeName)
+		_, reused := weights[identifier]
+		Expect(reused).To(BeFalse(), "Identifier %s is given for more than one service of route %s", identifier, routeName)
+		weights[identifier] = weight
+		ids = append(ids, identifier)
+	}
+
+	bodies, err := util.SampleHTTPResponses(context.TODO(), executor, util.RouteURL(route), requests, 10*time.Second)
+	Expect(err).ToNot(HaveOccurred(), "Failed to send requests through route %s", routeName)
+
+	split := util.NewTrafficSplit(bodies, ids)
+	util.LogInfo("Traffic split of route %s: %s", routeName, split)
+	Expect(split.Check(weights, tolerance)).To(Succeed())
+	return split
+}
@@ -0,0 +1,30 @@
+package framework_test
+
+import (
+	"myproject/framework"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	routev1 "github.com/openshift/api/route/v1"
+	routefake "github.com/openshift/client-go/route/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("ExpectRouteTrafficSplit", func() {
+	It("should fail before sending requests when services share an identifier", func() {
+		weight := int32(50)
+		routeClient := routefake.NewSimpleClientset(&routev1.Route{
+			ObjectMeta: metav1.ObjectMeta{Name: "canary", Namespace: "ns1"},
+			Spec: routev1.RouteSpec{
+				Host:              "canary.example.com",
+				To:                routev1.RouteTargetReference{Kind: "Service", Name: "stable", Weight: &weight},
+				AlternateBackends: []routev1.RouteTargetReference{{Kind: "Service", Name: "canary", Weight: &weight}},
+			},
+		})
+		ctx := framework.NewTestContext(nil, framework.Clients{Route: routeClient}, "ns1")
+
+		err := InterceptGomegaFailure(func() {
+			ctx.ExpectRouteTrafficSplit(nil, "canary", map[string]string{"stable": "server", "canary": "server"}, 10, 0.1)
+		})
+		Expect(err).To(MatchError(ContainSubstring("Identifier server is given for more than one service")))
+	})
+})
@@ -0,0 +1,133 @@
+package framework
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"myproject/util"
+	. "github.com/onsi/gomega"
+)
+
+// routerRequestTimeout bounds the single requests sent by the router assertions
+const routerRequestTimeout = 10 * time.Second
+
+// ExpectRouteHeader requests the URL until the response carries the header with the expected value,
+// e.g. the Strict-Transport-Security header of util.RouteHSTS
+func (ctx *TestContext) ExpectRouteHeader(executor util.ProbeExecutor, url, header, expected string, timeout time.Duration) *util.HTTPResponseHead {
+	var head *util.HTTPResponseHead
+	Eventually(func() (string, error) {
+		var err error
+		head, err = util.FetchHTTPHead(context.TODO(), executor, url, routerRequestTimeout)
+		if err != nil {
+			return "", err
+		}
+		if !head.Received() {
+			return "", fmt.Errorf("no response from %s (curl exit code %d)", url, head.ExitCode)
+		}
+		return head.Header.Get(header), nil
+	}, timeout, 5*time.Second).Should(Equal(expected), "Expected header %s from %s", header, url)
+	return head
+}
+
+// ExpectRouteCookie requests the URL until the presence of a Set-Cookie header matches, which tells
+// whether the router keeps clients on the same endpoint (see util.RouterOptions.DisableCookies)
+func (ctx *TestContext) ExpectRouteCookie(executor util.ProbeExecutor, url string, present bool, timeout time.Duration) {
+	Eventually(func() (bool, error) {
+		head, err := util.FetchHTTPHead(context.TODO(), executor, url, routerRequestTimeout)
+		if err != nil {
+			return false, err
+		}
+		if !head.Received() {
+			return false, fmt.Errorf("no response from %s (curl exit code %d)", url, head.ExitCode)
+		}
+		return len(head.Header.Values("Set-Cookie")) > 0, nil
+	}, timeout, 5*time.Second).Should(Equal(present), "Expected a cookie from %s: %t", url, present)
+}
+
+// ExpectRouteTimeout requests a URL whose backend answers slower than the route timeout until the
+// router gives up with a 504 after at least the timeout
+func (ctx *TestContext) ExpectRouteTimeout(executor util.ProbeExecutor, url string, routeTimeout, timeout time.Duration) {
+	Eventually(func() error {
+		head, err := util.FetchHTTPHead(context.TODO(), executor, url, routeTimeout+routerRequestTimeout)
+		if err != nil {
+			return err
+		}
+		if head.StatusCode != 504 {
+			return fmt.Errorf("expected a 504 from %s, got status %d (curl exit code %d) after %s", url, head.StatusCode, head.ExitCode, head.Latency)
+		}
+		if head.Latency < routeTimeout {
+			return fmt.Errorf("the 504 from %s came after %s, before the %s timeout", url, head.Latency, routeTimeout)
+		}
+		return nil
+	}, timeout, 5*time.Second).Should(Succeed())
+}
+
+// ExpectRouteSourceRejected requests the URL until the router refuses the client, either by closing
+// the connection or with a 403, as it does for sources outside util.RouterOptions.IPWhitelist.
+// Timeouts and other failures don't count, they would hide a route that isn't served at all.
+func (ctx *TestContext) ExpectRouteSourceRejected(executor util.ProbeExecutor, url string, timeout time.Duration) {
+	Eventually(func() error {
+		head, err := util.FetchHTTPHead(context.TODO(), executor, url, routerRequestTimeout)
+		if err != nil {
+			return err
+		}
+		if head.StatusCode == 403 || head.ClosedByServer() {
+			return nil
+		}
+		return fmt.Errorf("%s answered with status %d (curl exit code %d)", url, head.StatusCode, head.ExitCode)
+	}, timeout, 5*time.Second).Should(Succeed(), "Expected %s to reject the client", url)
+}
+
+// ExpectRouteRateLimited sends bursts of requests until some are answered and the ones beyond the
+// limit of util.RouterOptions.RateLimit are refused. The router refuses by closing the connection,
+// newer versions may answer 429; any other failure fails the burst.
+func (ctx *TestContext) ExpectRouteRateLimited(executor util.ProbeExecutor, url string, requests int, timeout time.Duration) []util.HTTPResponseHead {
+	var heads []util.HTTPResponseHead
+	Eventually(func() error {
+		var err error
+		heads, err = util.SampleHTTPHeads(context.TODO(), executor, url, requests, routerRequestTimeout)
+		if err != nil {
+			return err
+		}
+		answered, refused := 0, 0
+		var unexpected []string
+		for _, head := range heads {
+			switch {
+			case head.Received() && head.StatusCode >= 200 && head.StatusCode < 400:
+				answered++
+			case head.StatusCode == 429 || head.ClosedByServer():
+				refused++
+			default:
+				unexpected = append(unexpected, fmt.Sprintf("status %d (curl exit code %d)", head.StatusCode, head.ExitCode))
+			}
+		}
+		if len(unexpected) > 0 {
+			return fmt.Errorf("requests failed for another reason than the rate limit: %s", strings.Join(unexpected, ", "))
+		}
+		if answered == 0 || refused == 0 {
+			return fmt.Errorf("expected answered and refused requests, got %d answered and %d refused", answered, refused)
+		}
+		return nil
+	}, timeout, 5*time.Second).Should(Succeed(), "Expected %s to be rate limited", url)
+	return heads
+}
+
+// ExpectAnsweringBackends sends the requests and checks how many distinct backends answered them,
+// e.g. 1 with source balancing and every backend with round robin and cookies disabled
+func (ctx *TestContext) ExpectAnsweringBackends(executor util.ProbeExecutor, url string, identifiers []string, requests, expected int) util.TrafficSplit {
+	bodies, err := util.SampleHTTPResponses(context.TODO(), executor, url, requests, routerRequestTimeout)
+	Expect(err).ToNot(HaveOccurred(), "Failed to send requests to %s", url)
+
+	split := util.NewTrafficSplit(bodies, identifiers)
+	Expect(split.Unmatched).To(BeEmpty(), "Responses from no known backend: %s", split)
+	answering := 0
+	for _, count := range split.Counts {
+		if count > 0 {
+			answering++
+		}
+	}
+	Expect(answering).To(Equal(expected), "Unexpected number of answering backends: %s", split)
+	return split
+}
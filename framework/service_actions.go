@@ -1,6 +1,7 @@
 package framework
 
 import (
+	"context"
 	"time"
 	corev1 "k8s.io/api/core/v1"
 	. "github.com/onsi/gomega"
@@ -8,20 +9,24 @@ import (
 )
 
 // CreateServiceHelper creates a Kubernetes service of a specified type (ClusterIP, LoadBalancer, etc.)
-func (ctx *TestContext) CreateServiceHelper(serviceName string, serviceType string, servicePorts []corev1.ServicePort, labels map[string]string) {
-	_, err := util.CreateService(ctx.KubeClient, ctx.Namespace, serviceName, serviceType, servicePorts, labels)
+// selecting the pods with the given labels. The service itself gets the labels of the spec.
+func (ctx *TestContext) CreateServiceHelper(serviceName string, serviceType string, servicePorts []corev1.ServicePort, selector map[string]string) {
+	ctx.Track(ResourceService, serviceName)
+	_, err := util.CreateService(ctx.KubeClient, ctx.Namespace, serviceName, serviceType, servicePorts, selector, ctx.Labeler.Labels())
 	Expect(err).ToNot(HaveOccurred(), "Failed to create service %s of type %s", serviceName, serviceType)
 }
 
-// WaitForServiceIP waits for a service to get a valid IP within a specified timeout
+// WaitForServiceIP waits for a service to get an address within a specified timeout, see util.GetServiceIP
 func (ctx *TestContext) WaitForServiceIP(serviceName string, timeout, interval time.Duration) string {
-	var serviceIP string
-
-	Eventually(func() (string, error) {
-		var err error
-		serviceIP, err = util.GetServiceIP(ctx.KubeClient, ctx.Namespace, serviceName)
-		return serviceIP, err
-	}, timeout, interval).ShouldNot(BeEmpty(), "Expected service to get a service IP")
+	endpoint, err := util.WaitForServiceEndpoint(context.TODO(), ctx.KubeClient, ctx.Namespace, serviceName, "", util.ConstantBackoff(interval, timeout, 0))
+	Expect(err).ToNot(HaveOccurred(), "Expected service %s to get an address", serviceName)
+	return endpoint.Host
+}
 
-	return serviceIP
-}
\ No newline at end of file
+// WaitForServiceEndpoint waits until the named port of a service can be reached, e.g. the node address
+// and node port of a NodePort service. An empty portName picks the first port.
+func (ctx *TestContext) WaitForServiceEndpoint(serviceName, portName string, timeout time.Duration) util.ServiceEndpoint {
+	endpoint, err := util.WaitForServiceEndpoint(context.TODO(), ctx.KubeClient, ctx.Namespace, serviceName, portName, util.ConstantBackoff(5*time.Second, timeout, 0))
+	Expect(err).ToNot(HaveOccurred(), "Expected service %s to get an address", serviceName)
+	return *endpoint
+}
@@ -0,0 +1,28 @@
+package framework_test
+
+import (
+	"context"
+
+	"myproject/consts"
+	"myproject/framework"
+	"myproject/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("CreateServiceHelper", func() {
+	It("should label the service with the run ID and keep the selector apart", func() {
+		kubeClient := fake.NewSimpleClientset()
+		ctx := framework.NewTestContext(nil, framework.Clients{Kube: kubeClient}, "ns1")
+
+		ctx.CreateServiceHelper("web", "ClusterIP", []corev1.ServicePort{util.GeneratePort("http", 80, 8080, "TCP")}, map[string]string{"app": "server"})
+
+		service, err := kubeClient.CoreV1().Services("ns1").Get(context.TODO(), "web", metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(service.Labels).To(HaveKeyWithValue(consts.RunIDLabel, framework.RunID()))
+		Expect(service.Spec.Selector).To(Equal(map[string]string{"app": "server"}))
+	})
+})
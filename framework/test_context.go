@@ -1,48 +1,154 @@
 package framework
 
 import (
+	"os"
+	"sync"
+
+	"myproject/config"
+	"myproject/consts"
 	"myproject/util"
+	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
-	"github.com/openshift/client-go/route/clientset/versioned"
+	projectclientset "github.com/openshift/client-go/project/clientset/versioned"
+	routeclientset "github.com/openshift/client-go/route/clientset/versioned"
+	templateclientset "github.com/openshift/client-go/template/clientset/versioned"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	kubecli "kubevirt.io/client-go/kubecli"
 )
 
+var (
+	runID     string
+	runIDOnce sync.Once
+
+	testConfig     *config.Config
+	testConfigErr  error
+	testConfigOnce sync.Once
+)
+
+// RunID identifies the current test run. It is taken from the TEST_RUN_ID environment variable,
+// or generated once per process, and is added as a label to the objects the framework creates.
+func RunID() string {
+	runIDOnce.Do(func() {
+		runID = os.Getenv("TEST_RUN_ID")
+		if runID == "" {
+			runID = util.GenerateRandomName()
+		}
+	})
+	return runID
+}
+
+// LoadTestConfig loads the suite configuration once per process from the file named by TEST_CONFIG
+// and the TEST_* environment overrides. An invalid configuration fails every spec that calls Setup.
+func LoadTestConfig() (*config.Config, error) {
+	testConfigOnce.Do(func() {
+		testConfig, testConfigErr = config.LoadFromEnv()
+	})
+	return testConfig, testConfigErr
+}
+
 // TestContext holds reusable values across tests, including a random name for resources
 type TestContext struct {
-	Config      *rest.Config
-	KubeClient   *kubernetes.Clientset
-	VirtClient  kubecli.KubevirtClient
-	RouteClient *versioned.Clientset
-	Namespace   string
-	RandomName  string
+	Config         *rest.Config
+	KubeClient     kubernetes.Interface
+	VirtClient     kubecli.KubevirtClient
+	RouteClient    routeclientset.Interface
+	TemplateClient templateclientset.Interface
+	ProjectClient  projectclientset.Interface
+	DynamicClient  dynamic.Interface // For APIs without typed clients, e.g. AdminNetworkPolicies
+	Namespace      string
+	RandomName     string
+	ArtifactDir    string         // Root directory for failure artifacts, from ARTIFACT_DIR or DefaultArtifactDir
+	TestConfig     *config.Config // Images, template, resources, timeouts and labels used by the helpers
+	Labeler        *util.Labeler  // Labels of the objects created by the helpers, safe to share between goroutines
+	tracker        *resourceTracker
+}
+
+// Clients are the clients a TestContext talks to the cluster with. Clients a test doesn't use can stay nil.
+type Clients struct {
+	Kube     kubernetes.Interface
+	Virt     kubecli.KubevirtClient
+	Route    routeclientset.Interface
+	Template templateclientset.Interface
+	Project  projectclientset.Interface
+	Dynamic  dynamic.Interface
 }
 
-// Setup initializes the environment (e.g., auth, logging) and sets the random name for each test
+// NewTestContext builds a TestContext from already constructed clients and the default configuration.
+// It allows injecting fake clientsets so helpers can be exercised without a live cluster.
+func NewTestContext(restConfig *rest.Config, clients Clients, namespace string) *TestContext {
+	return &TestContext{
+		Config:         restConfig,
+		KubeClient:     clients.Kube,
+		VirtClient:     clients.Virt,
+		RouteClient:    clients.Route,
+		TemplateClient: clients.Template,
+		ProjectClient:  clients.Project,
+		DynamicClient:  clients.Dynamic,
+		Namespace:      namespace,
+		RandomName:     util.GenerateRandomName(),
+		ArtifactDir:    artifactDirFromEnv(),
+		TestConfig:     config.Default(),
+		Labeler:        newLabeler(config.Default(), ""),
+		tracker:        &resourceTracker{},
+	}
+}
+
+// Owner identifies who started the run, from the TEST_OWNER or USER environment variables
+func Owner() string {
+	if owner := os.Getenv("TEST_OWNER"); owner != "" {
+		return owner
+	}
+	return os.Getenv("USER")
+}
+
+// newLabeler builds the labels for the objects of one spec from the defaults and the configured labels
+func newLabeler(cfg *config.Config, specName string) *util.Labeler {
+	return util.NewLabeler(util.MergeLabels(consts.DefaultLabels, cfg.Labels), RunID(), specName, Owner())
+}
+
+// Setup initializes the environment (e.g., auth, logging) and sets the random name for each test.
+// It registers a DeferCleanup that deletes every tracked resource, so it must be called from a setup node such as BeforeEach.
+// If the spec failed, the tracked resources are dumped to the artifact directory before they are deleted.
 func Setup(namespace string) *TestContext {
 	var err error
 
 	err = util.SetLogLevel("debug")
 	Expect(err).ToNot(HaveOccurred(), "Failed to initiate the logger")
 
+	suiteConfig, err := LoadTestConfig()
+	Expect(err).ToNot(HaveOccurred(), "Failed to load the test configuration")
+
 	kubeclient, config, err := util.Authenticate()
 	Expect(err).ToNot(HaveOccurred(), "Failed to authenticate with Kubernetes")
 
 	virtClient, err := kubecli.GetKubevirtClientFromRESTConfig(config)
 	Expect(err).ToNot(HaveOccurred(), "Failed to authenticate with KubeVirt")
 
-	routeClient, err := versioned.NewForConfig(config)
+	routeClient, err := routeclientset.NewForConfig(config)
 	Expect(err).ToNot(HaveOccurred(), "Failed to create Route client")
 
-	randomName := util.GenerateRandomName()
+	templateClient, err := templateclientset.NewForConfig(config)
+	Expect(err).ToNot(HaveOccurred(), "Failed to create Template client")
 
-	return &TestContext{
-		KubeClient:   kubeclient,
-		Config:      config,
-		VirtClient:  virtClient,
-		RouteClient: routeClient,
-		Namespace:   namespace,
-		RandomName:  randomName,
-	}
-}
\ No newline at end of file
+	projectClient, err := projectclientset.NewForConfig(config)
+	Expect(err).ToNot(HaveOccurred(), "Failed to create Project client")
+
+	dynamicClient, err := dynamic.NewForConfig(config)
+	Expect(err).ToNot(HaveOccurred(), "Failed to create dynamic client")
+
+	ctx := NewTestContext(config, Clients{
+		Kube:     kubeclient,
+		Virt:     virtClient,
+		Route:    routeClient,
+		Template: templateClient,
+		Project:  projectClient,
+		Dynamic:  dynamicClient,
+	}, namespace)
+	ctx.TestConfig = suiteConfig
+	ctx.Labeler = newLabeler(suiteConfig, CurrentSpecReport().FullText())
+	DeferCleanup(ctx.CleanupAll)
+	DeferCleanup(ctx.collectArtifactsOnFailure)
+	return ctx
+}
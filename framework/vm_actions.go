@@ -1,15 +1,32 @@
 package framework
 
 import (
+	"context"
+	"time"
+
 	"myproject/util"
 	. "github.com/onsi/gomega"
 )
 
-// CreateTestVM creates a VM with default settings using the random name from the context
+// CreateTestVM creates a VM with the configured template and resources using the random name from the context.
+// An empty templateName uses the template from the test configuration.
 func (ctx *TestContext) CreateTestVM(vmName string, scriptPath string, templateName string) {
-	resourceRequirements := util.ConvertCoreV1ToKubeVirtResourceRequirements(
-		util.GenerateResourceRequirements("4000m", "4000m", "4Gi", "4Gi"))
+	if templateName == "" {
+		templateName = ctx.TestConfig.Template.Name
+	}
+	resourceRequirements := util.ConvertCoreV1ToKubeVirtResourceRequirements(ctx.TestConfig.VMResources())
+
+	// The VM is created through a TemplateInstance, track both so the VM is removed first
+	ctx.Track(ResourceTemplateInstance, vmName)
+	ctx.Track(ResourceVM, vmName)
 
-	_, err := util.CreateVM(ctx.Config, ctx.Namespace, templateName, vmName, &resourceRequirements, nil, true, scriptPath, "")
+	_, err := util.CreateVM(ctx.TemplateClient, ctx.VirtClient, ctx.Namespace, ctx.TestConfig.Template.Namespace, templateName, vmName, &resourceRequirements, ctx.Labeler.For(vmName), false, scriptPath, "")
 	Expect(err).ToNot(HaveOccurred(), "Failed to create VM")
+
+	backoff := util.ExponentialBackoff(time.Second, 5*time.Second, ctx.TestConfig.Timeouts.VMReady.Duration)
+	err = util.WaitForTemplateInstanceReady(context.TODO(), ctx.TemplateClient, ctx.Namespace, vmName, backoff)
+	Expect(err).ToNot(HaveOccurred(), "TemplateInstance %s did not become ready\n%s", vmName, util.DescribeWaitFailure(err))
+
+	err = util.WaitForVMReady(context.TODO(), ctx.VirtClient, ctx.Namespace, vmName, backoff)
+	Expect(err).ToNot(HaveOccurred(), "VM %s did not become ready\n%s", vmName, util.DescribeWaitFailure(err))
 }
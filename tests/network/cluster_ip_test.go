@@ -16,13 +16,11 @@ var _ = Describe("Service type ClusterIP access from same namespace", func() {
 		clientPodName string
 		serviceName   string
 		serviceIP     string
-		imageClient = consts.ClientImage
-		image       = consts.HttpdImage
 	)
 
 	BeforeEach(func() {
-		// Initialize the TestContext and setup environment in the current namespace
-		ctx = framework.Setup("core")
+		// Initialize the TestContext and setup environment in a new ephemeral namespace
+		ctx = framework.SetupEphemeral("clusterip")
 
 		// Generate names for the pod, test pod, and service using the random name from context
 		serverPodName = consts.TestPrefix + "-server-" + ctx.RandomName
@@ -31,7 +29,7 @@ var _ = Describe("Service type ClusterIP access from same namespace", func() {
 
 		// Define the pod to be exposed by the ClusterIP service
 		containers := []util.ContainerConfig{
-			util.CreateContainerConfig("test-container", image, nil, util.GenerateResourceRequirements("250m", "1000m", "1Gi", "1Gi")),
+			util.CreateContainerConfig("test-container", ctx.TestConfig.Images.Httpd, nil, util.GenerateResourceRequirements("250m", "1000m", "1Gi", "1Gi")),
 		}
 
 		// Create the main test pod
@@ -50,22 +48,10 @@ var _ = Describe("Service type ClusterIP access from same namespace", func() {
 		// Log the retrieved ClusterIP
 		util.LogInfo("ClusterIP for service %s: %s", serviceName, serviceIP)
 	
-		// Define the test pod that will access the service in the same namespace
-		testContainers := []util.ContainerConfig{
-			util.CreateContainerConfig("curl-container", imageClient, []string{"curl", "--fail", "--retry", "5", "-w", "HTTP Response Code: %{http_code}\n", "http://" + serviceIP}, util.GenerateResourceRequirements("100m", "400m", "200Mi", "200Mi")),
-		}
-	
-		// Create the test pod in the same namespace
-		ctx.CreateTestPodHelper(clientPodName, testContainers, 3)
-	
-		// Verify access to the service
-		ctx.VerifyPodResponse(clientPodName, "HTTP Response Code: 200", 3)
-	})
+		// Start a long-lived client pod in the same namespace
+		ctx.CreateClientPod(clientPodName)
 
-	AfterEach(func() {
-		// Clean up resources in both namespaces
-		ctx.CleanupResource(serverPodName, "pod")
-		ctx.CleanupResource(clientPodName, "pod")
-		ctx.CleanupResource(serviceName, "service")
+		// Verify access to the service from inside the client pod
+		ctx.VerifyHTTPFromPod(clientPodName, "http://"+serviceIP, 200, 3*time.Minute)
 	})
 })
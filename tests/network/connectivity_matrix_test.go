@@ -0,0 +1,59 @@
+package network_test
+
+import (
+	"time"
+	"myproject/framework"
+	"myproject/util"
+	"myproject/consts"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Connectivity matrix across namespaces", func() {
+	var (
+		ctxX      *framework.TestContext
+		ctxY      *framework.TestContext
+		endpoints []util.Endpoint
+		port      = util.MatrixPort{Port: 80}
+	)
+
+	BeforeEach(func() {
+		// Two ephemeral namespaces, each with an httpd server and a client pod
+		ctxX = framework.SetupEphemeral("matrix-x")
+		ctxY = ctxX.AddNamespace("matrix-y")
+
+		endpoints = nil
+		for _, ctx := range []*framework.TestContext{ctxX, ctxY} {
+			serverPodName := consts.TestPrefix + "-server-" + ctx.RandomName
+			clientPodName := consts.TestPrefix + "-client-" + ctx.RandomName
+
+			// The server declares its port, so the policy simulator knows it listens there
+			server := util.CreateContainerConfig("test-container", ctx.TestConfig.Images.Httpd, nil, util.GenerateResourceRequirements("250m", "1000m", "1Gi", "1Gi"))
+			server.Ports = []corev1.ContainerPort{{Name: "http", ContainerPort: 80, Protocol: corev1.ProtocolTCP}}
+			ctx.CreateTestPodHelper(serverPodName, []util.ContainerConfig{server}, 3)
+			ctx.CreateClientPod(clientPodName)
+
+			// The httpd image has no probe tools, so servers are only used as destinations
+			serverEndpoint := ctx.PodEndpoint(serverPodName)
+			serverEndpoint.Executor = nil
+			endpoints = append(endpoints, serverEndpoint, ctx.PodEndpoint(clientPodName))
+		}
+	})
+
+	It("should match the reachability simulated from the NetworkPolicies before and after allowing another namespace", func() {
+		// Whatever policies the project template put in both namespaces, the simulator reads them from the
+		// cluster. Nothing listens on port 80 of the clients, so they are never reachable.
+		ctxX.ExpectConnectivity(endpoints, port, ctxX.SimulateReachability(endpoints, port), 2*time.Minute)
+
+		// Allow ingress from namespace y to the pods of namespace x on port 80
+		policyName := consts.TestPrefix + "-np-" + ctxX.RandomName
+		ctxX.ApplyNetworkPolicy(ctxX.NewNetworkPolicy(policyName).
+			AllowIngress(util.NewNetworkPolicyRule().Namespace(ctxY.Namespace).Port(80, "TCP")))
+
+		expected := ctxX.SimulateReachability(endpoints, port)
+		serverX, clientY := endpoints[0].Name, endpoints[3].Name
+		Expect(expected.Get(clientY, serverX)).To(BeTrue(), "The policy should let namespace y reach the server of x")
+		ctxX.ExpectConnectivity(endpoints, port, expected, 2*time.Minute)
+	})
+})
@@ -0,0 +1,45 @@
+package network_test
+
+import (
+	"time"
+	"myproject/framework"
+	"myproject/util"
+	"myproject/consts"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EgressFirewall to restrict traffic leaving the namespace", func() {
+	var (
+		ctx           *framework.TestContext
+		clientPodName string
+		standIn       *util.StandInServer
+	)
+
+	BeforeEach(func() {
+		// The stand-in server on the runner plays the external destination, skip before any setup when not configured
+		framework.SkipWithoutStandInHost()
+
+		// Initialize the TestContext and setup environment in a new ephemeral namespace
+		ctx = framework.SetupEphemeral("egressfw")
+		standIn = ctx.StartStandInServer()
+
+		clientPodName = consts.TestPrefix + "-client-" + ctx.RandomName
+		ctx.CreateClientPod(clientPodName)
+	})
+
+	It("should block egress to the stand-in server once its address is denied", func() {
+		// Without an EgressFirewall the stand-in server is reachable
+		ctx.ExpectHTTPStatus(ctx.PodProber(clientPodName), standIn.URL(), 200, 2*time.Minute)
+		Expect(standIn.Requests()).ToNot(BeEmpty())
+
+		cidr, err := standIn.CIDR()
+		Expect(err).ToNot(HaveOccurred(), "The deny rule needs the stand-in host as an IP address")
+
+		// Deny the stand-in server on its port only
+		ctx.ApplyEgressFirewall(ctx.NewEgressFirewall().
+			DenyCIDR(cidr, util.EgressFirewallPort{Protocol: "TCP", Port: int32(standIn.Port)}), 2*time.Minute)
+
+		ctx.ExpectEgressDenied(clientPodName, standIn.Host, standIn.Port, 2*time.Minute)
+	})
+})
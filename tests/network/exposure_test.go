@@ -0,0 +1,58 @@
+package network_test
+
+import (
+	"time"
+	"myproject/framework"
+	"myproject/util"
+	"myproject/consts"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Service exposed through a Route or an Ingress", func() {
+	var (
+		ctx           *framework.TestContext
+		clientPodName string
+		serviceName   string
+		exposedName   string
+	)
+
+	BeforeEach(func() {
+		// Initialize the TestContext and setup environment in a new ephemeral namespace
+		ctx = framework.SetupEphemeral("exposure")
+
+		serverPodName := consts.TestPrefix + "-server-" + ctx.RandomName
+		serviceName = consts.TestPrefix + "-service-" + ctx.RandomName
+		exposedName = consts.TestPrefix + "-exposed-" + ctx.RandomName
+
+		ctx.CreateIdentifiedServerPod(serverPodName)
+		ctx.CreateServiceHelper(serviceName, "ClusterIP", []corev1.ServicePort{util.GeneratePort("http", 80, 80, "TCP")}, map[string]string{"app": serverPodName})
+
+		clientPodName = consts.TestPrefix + "-client-" + ctx.RandomName
+		ctx.CreateClientPod(clientPodName)
+	})
+
+	DescribeTable("should serve the service from within the cluster",
+		func(exposure framework.Exposure, tls bool) {
+			url := ctx.ExposeService(exposure, exposedName, serviceName, 80, tls, 2*time.Minute)
+			if tls {
+				Expect(url).To(HavePrefix("https://"))
+			} else {
+				Expect(url).To(HavePrefix("http://"))
+			}
+
+			ctx.VerifyHTTPFromPod(clientPodName, url, 200, 2*time.Minute)
+		},
+		Entry("through a Route", framework.ExposureRoute, false),
+		Entry("through an edge Route", framework.ExposureRoute, true),
+		Entry("through an Ingress", framework.ExposureIngress, false),
+		Entry("through an Ingress with TLS", framework.ExposureIngress, true),
+	)
+
+	It("should report the router address on the Ingress", func() {
+		ctx.ExposeService(framework.ExposureIngress, exposedName, serviceName, 80, false, 2*time.Minute)
+
+		Expect(ctx.WaitForIngressAddress(exposedName, 2*time.Minute)).ToNot(BeEmpty())
+	})
+})
@@ -1,6 +1,7 @@
 package network_test
 
 import (
+	"time"
 	"myproject/framework"
 	"myproject/util"
 	"myproject/consts"
@@ -16,13 +17,11 @@ var _ = Describe("Service type Headless access from same namespace", func() {
 		clientPodName string
 		serviceName   string
 		headlessDNS   string
-		imageClient   = consts.ClientImage
-		image         = consts.HttpdImage
 	)
 
 	BeforeEach(func() {
-		// Initialize the TestContext and setup environment in the current namespace
-		ctx = framework.Setup("core")
+		// Initialize the TestContext and setup environment in a new ephemeral namespace
+		ctx = framework.SetupEphemeral("headless")
 
 		// Generate names for the server pod, client pod, and service using the random name from context
 		serverPodName = consts.TestPrefix + "-server-" + ctx.RandomName
@@ -31,7 +30,7 @@ var _ = Describe("Service type Headless access from same namespace", func() {
 
 		// Define the pod to be exposed by the Headless service
 		containers := []util.ContainerConfig{
-			util.CreateContainerConfig("test-container", image, nil, util.GenerateResourceRequirements("250m", "1000m", "1Gi", "1Gi")),
+			util.CreateContainerConfig("test-container", ctx.TestConfig.Images.Httpd, nil, util.GenerateResourceRequirements("250m", "1000m", "1Gi", "1Gi")),
 		}
 
 		// Create the server pod
@@ -50,22 +49,10 @@ var _ = Describe("Service type Headless access from same namespace", func() {
 	})
 
 	It("should allow access to the Headless service from the same namespace using DNS", func() {
-		// Define the test pod that will access the headless service via DNS in the same namespace
-		testContainers := []util.ContainerConfig{
-			util.CreateContainerConfig("curl-container", imageClient, []string{"curl", "--fail", "--retry", "5", "-w", "HTTP Response Code: %{http_code}\n", "http://" + headlessDNS}, util.GenerateResourceRequirements("100m", "400m", "200Mi", "200Mi")),
-		}
-
-		// Create the test pod in the same namespace
-		ctx.CreateTestPodHelper(clientPodName, testContainers, 3)
-
-		// Verify DNS access to the headless service
-		ctx.VerifyPodResponse(clientPodName, "HTTP Response Code: 200", 3)
-	})
+		// Start a long-lived client pod in the same namespace
+		ctx.CreateClientPod(clientPodName)
 
-	AfterEach(func() {
-		// Clean up resources in both namespaces
-		ctx.CleanupResource(serverPodName, "pod")
-		ctx.CleanupResource(clientPodName, "pod")
-		ctx.CleanupResource(serviceName, "service")
+		// Verify DNS access to the headless service from inside the client pod
+		ctx.VerifyHTTPFromPod(clientPodName, "http://"+headlessDNS, 200, 3*time.Minute)
 	})
 })
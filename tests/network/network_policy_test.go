@@ -19,14 +19,12 @@ var _ = Describe("NetworkPolicy to restrict access to ports", func() {
 		serviceName   string
 		policyName    string
 		serviceIP     string
-		imageClient   = consts.ClientImage
-		image         = consts.HttpdImage
 	)
 
 	BeforeEach(func() {
-		// Initialize the TestContext and setup environment in the current namespace
-		ctx = framework.Setup("core")
-		ctxHelper = framework.Setup("test-4")
+		// Initialize the TestContext and setup environment in a new ephemeral namespace
+		ctx = framework.SetupEphemeral("netpol")
+		ctxHelper = ctx.AddNamespace("netpol-client")
 
 		// Generate names for the pod, test pod, service, and network policy using the random name from context
 		serverPodName = consts.TestPrefix + "-server-" + ctx.RandomName
@@ -36,7 +34,7 @@ var _ = Describe("NetworkPolicy to restrict access to ports", func() {
 
 		// Define the pod to be exposed by the ClusterIP service
 		containers := []util.ContainerConfig{
-			util.CreateContainerConfig("test-container", image, nil, util.GenerateResourceRequirements("250m", "1000m", "1Gi", "1Gi")),
+			util.CreateContainerConfig("test-container", ctx.TestConfig.Images.Httpd, nil, util.GenerateResourceRequirements("250m", "1000m", "1Gi", "1Gi")),
 		}
 
 		// Create the main test pod
@@ -54,40 +52,20 @@ var _ = Describe("NetworkPolicy to restrict access to ports", func() {
 		// Fetch the service IP
 		serviceIP = ctx.WaitForServiceIP(serviceName, 2*time.Minute, 10*time.Second)
 
-		// Define the test pod that will access the service from another namespace
-		testContainers := []util.ContainerConfig{
-			util.CreateContainerConfig("curl-container", imageClient, []string{"curl", "--max-time", "5", "-w", "HTTP Response Code: %{http_code}\n", "http://" + serviceIP}, util.GenerateResourceRequirements("100m", "400m", "200Mi", "200Mi")),
-		}
-
-		// Create the test pod in a different namespace, expecting it to fail due to lack of NetworkPolicy
-		ctxHelper.CreateTestPodExpectingFailureHelper(clientPodName, testContainers, 3)
+		// Start a long-lived client pod in a different namespace
+		ctxHelper.CreateClientPod(clientPodName)
+		prober := ctxHelper.PodProber(clientPodName)
+		probe := util.HTTPProbe{URL: "http://" + serviceIP}
 
-		// Verify that access is denied (i.e., "HTTP Response Code: 000" or no response)
-		ctxHelper.VerifyPodResponse(clientPodName, "HTTP Response Code: 000", 3)
-
-		// Clean up the failing pod
-		ctxHelper.CleanupResource(clientPodName, "pod")
+		// Verify that access is denied: the packets are dropped, so the request times out
+		ctxHelper.ExpectUnreachable(prober, probe, time.Minute, util.ProbeErrorTimeout)
 
 		// Apply the NetworkPolicy to allow traffic from other namespaces on port 80
-		networkPorts := util.CreateNetworkPolicyPort(80, "TCP")
-		_, err := util.CreateNetworkPolicyWithNamespaceAllow(ctx.KubeClient, ctx.Namespace, policyName, networkPorts)
-		Expect(err).ToNot(HaveOccurred(), "Failed to create network policy with allow rule")
-
-		// Wait a bit for the policy to take effect
-		time.Sleep(10 * time.Second)
-
-		// Create the test pod again, after applying the NetworkPolicy
-		ctxHelper.CreateTestPodHelper(clientPodName, testContainers, 3)
-
-		// Verify that access is allowed after applying NetworkPolicy
-		ctxHelper.VerifyPodResponse(clientPodName, "HTTP Response Code: 200", 3)
-	})
+		networkPorts, err := util.CreateNetworkPolicyPort(80, "TCP")
+		Expect(err).ToNot(HaveOccurred())
+		ctx.CreateNetworkPolicyWithNamespaceAllowHelper(policyName, networkPorts)
 
-	AfterEach(func() {
-		// Clean up resources: Delete pods, services, and network policies
-		ctxHelper.CleanupResource(clientPodName, "pod")
-		ctx.CleanupResource(serverPodName, "pod")
-		ctx.CleanupResource(serviceName, "service")
-		ctx.CleanupResource(policyName, "networkPolicy")
+		// Verify that access is allowed once the NetworkPolicy has taken effect
+		ctxHelper.ExpectHTTPStatus(prober, probe.URL, 200, 2*time.Minute)
 	})
 })
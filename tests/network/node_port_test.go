@@ -0,0 +1,47 @@
+package network_test
+
+import (
+	"time"
+	"myproject/framework"
+	"myproject/util"
+	"myproject/consts"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Service type NodePort on Pod", func() {
+	var (
+		ctx           *framework.TestContext
+		serverPodName string
+		clientPodName string
+		serviceName   string
+	)
+
+	BeforeEach(func() {
+		ctx = framework.SetupEphemeral("nodeport")
+
+		serverPodName = consts.TestPrefix + "-server-" + ctx.RandomName
+		clientPodName = consts.TestPrefix + "-client-" + ctx.RandomName
+		serviceName = consts.TestPrefix + "-np-" + ctx.RandomName
+
+		containers := []util.ContainerConfig{
+			util.CreateContainerConfig("test-container", ctx.TestConfig.Images.Httpd, nil, util.GenerateResourceRequirements("250m", "1000m", "1Gi", "1Gi")),
+		}
+		ctx.CreateTestPodHelper(serverPodName, containers, 3)
+
+		servicePorts := []corev1.ServicePort{
+			util.GeneratePort("http", 80, 80, "TCP"),
+		}
+		ctx.CreateServiceHelper(serviceName, "NodePort", servicePorts, map[string]string{"app": serverPodName})
+	})
+
+	It("should allow access to a pod through a node address and the allocated node port", func() {
+		endpoint := ctx.WaitForServiceEndpoint(serviceName, "http", time.Minute)
+		Expect(endpoint.Type).To(Equal(corev1.ServiceTypeNodePort))
+		Expect(endpoint.Port).To(BeNumerically(">=", 30000))
+
+		ctx.CreateClientPod(clientPodName)
+		ctx.VerifyHTTPFromPod(clientPodName, "http://"+endpoint.Address(), 200, 3*time.Minute)
+	})
+})
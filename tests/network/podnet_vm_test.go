@@ -15,13 +15,12 @@ var _ = Describe("Communicate with running VM using pod IP", func() {
 		vmName        string
 		clientPodName string
 		vmPodIP       string
-		imageClient = consts.ClientImage
 		scriptPath  = "../../scripts/httpd_install.sh"  // Path to the bash script
 	)
 
 	BeforeEach(func() {
-		// Initialize the TestContext and setup environment
-		ctx = framework.Setup("core")
+		// Initialize the TestContext and setup environment in a new ephemeral namespace
+		ctx = framework.SetupEphemeral("podnet-vm")
 
 		// Generate names for the VM and test pod using the random name from context
 		vmName = consts.TestPrefix + ctx.RandomName
@@ -44,7 +43,7 @@ var _ = Describe("Communicate with running VM using pod IP", func() {
 
 		// Define the test pod that will access the VM's Pod IP
 		testContainers := []util.ContainerConfig{
-			util.CreateContainerConfig("curl-container", imageClient, []string{"curl", "--fail", "--retry", "5", "-w", "HTTP Response Code: %{http_code}\n", "http://" + vmPodIP + ":80"}, resources),
+			util.CreateContainerConfig("curl-container", ctx.TestConfig.Images.Client, []string{"curl", "--fail", "--retry", "5", "-w", "HTTP Response Code: %{http_code}\n", "http://" + vmPodIP + ":80"}, resources),
 		}
 
 		// Create the test pod using the helper function
@@ -53,10 +52,4 @@ var _ = Describe("Communicate with running VM using pod IP", func() {
 		// Verify the pod can access the VM using the helper function
 		ctx.VerifyPodResponse(clientPodName, "HTTP Response Code: 200", 3)
 	})
-
-	AfterEach(func() {
-		// Clean up resources: Delete the test pod and the VM
-		ctx.CleanupResource(clientPodName, "pod")
-		ctx.CleanupResource(vmName, "vm")
-	})
 })
@@ -0,0 +1,106 @@
+package network_test
+
+import (
+	"time"
+	"myproject/framework"
+	"myproject/util"
+	"myproject/consts"
+	. "github.com/onsi/ginkgo/v2"
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Route annotations tuning the HAProxy router", func() {
+	var (
+		ctx           *framework.TestContext
+		clientPodName string
+		serverPods    []string
+		serviceNames  []string
+		routeName     string
+	)
+
+	BeforeEach(func() {
+		// Initialize the TestContext and setup environment in a new ephemeral namespace
+		ctx = framework.SetupEphemeral("route-haproxy")
+		routeName = consts.TestPrefix + "-route-" + ctx.RandomName
+
+		// Two backends answering with their pod name, each behind its own service
+		servicePorts := []corev1.ServicePort{util.GeneratePort("http", 80, 80, "TCP")}
+		serverPods, serviceNames = nil, nil
+		for _, backend := range []string{"a", "b"} {
+			podName := consts.TestPrefix + "-server-" + backend + "-" + ctx.RandomName
+			serviceName := consts.TestPrefix + "-service-" + backend + "-" + ctx.RandomName
+			ctx.CreateIdentifiedServerPod(podName)
+			ctx.CreateServiceHelper(serviceName, "ClusterIP", servicePorts, map[string]string{"app": podName})
+			serverPods = append(serverPods, podName)
+			serviceNames = append(serviceNames, serviceName)
+		}
+
+		clientPodName = consts.TestPrefix + "-client-" + ctx.RandomName
+		ctx.CreateClientPod(clientPodName)
+	})
+
+	// createRoute exposes the first backend with the router options and returns the admitted URL
+	createRoute := func(name string, options util.RouteOptions) string {
+		ctx.CreateRouteWithOptionsHelper(name, serviceNames[0], 80, options)
+		return ctx.WaitForRouteURL(name, 2*time.Minute, 10*time.Second)
+	}
+
+	// bothBackends splits the traffic evenly between the two services
+	bothBackends := func(router util.RouterOptions) util.RouteOptions {
+		weight := int32(1)
+		return util.RouteOptions{Weight: &weight, AlternateBackends: []util.RouteBackend{{ServiceName: serviceNames[1], Weight: 1}}, Router: router}
+	}
+
+	It("should add the HSTS header on an edge route", func() {
+		hsts := util.RouteHSTS{MaxAge: 365 * 24 * time.Hour, IncludeSubDomains: true}
+		routeURL := createRoute(routeName, util.RouteOptions{Termination: routev1.TLSTerminationEdge, Router: util.RouterOptions{HSTS: &hsts}})
+
+		ctx.ExpectRouteHeader(ctx.PodProber(clientPodName), routeURL, "Strict-Transport-Security", hsts.String(), 2*time.Minute)
+	})
+
+	It("should set the sticky session cookie unless cookies are disabled", func() {
+		routeURL := createRoute(routeName, util.RouteOptions{})
+		ctx.ExpectRouteCookie(ctx.PodProber(clientPodName), routeURL, true, 2*time.Minute)
+
+		noCookieURL := createRoute(routeName+"-nocookie", util.RouteOptions{Router: util.RouterOptions{DisableCookies: true}})
+		ctx.ExpectRouteCookie(ctx.PodProber(clientPodName), noCookieURL, false, 2*time.Minute)
+	})
+
+	It("should pin a client to one backend with source balancing", func() {
+		sourceURL := createRoute(routeName, bothBackends(util.RouterOptions{Balance: util.RouteBalanceSource, DisableCookies: true}))
+		ctx.VerifyHTTPFromPod(clientPodName, sourceURL, 200, 2*time.Minute)
+		ctx.ExpectAnsweringBackends(ctx.PodProber(clientPodName), sourceURL, serverPods, 20, 1)
+
+		roundRobinURL := createRoute(routeName+"-rr", bothBackends(util.RouterOptions{Balance: util.RouteBalanceRoundRobin, DisableCookies: true}))
+		ctx.VerifyHTTPFromPod(clientPodName, roundRobinURL, 200, 2*time.Minute)
+		ctx.ExpectAnsweringBackends(ctx.PodProber(clientPodName), roundRobinURL, serverPods, 20, 2)
+	})
+
+	It("should reject clients outside the IP whitelist", func() {
+		// Only a documentation address is allowed, so the client pod is not
+		routeURL := createRoute(routeName, util.RouteOptions{Router: util.RouterOptions{IPWhitelist: []string{"192.0.2.1/32"}}})
+
+		ctx.ExpectRouteSourceRejected(ctx.PodProber(clientPodName), routeURL, 2*time.Minute)
+	})
+
+	It("should refuse requests beyond the HTTP rate limit", func() {
+		routeURL := createRoute(routeName, util.RouteOptions{Router: util.RouterOptions{RateLimit: &util.RouteRateLimit{RateHTTP: 5}}})
+		ctx.VerifyHTTPFromPod(clientPodName, routeURL, 200, 2*time.Minute)
+
+		ctx.ExpectRouteRateLimited(ctx.PodProber(clientPodName), routeURL, 20, 2*time.Minute)
+	})
+
+	It("should answer 504 when the backend is slower than the route timeout", func() {
+		slowPodName := consts.TestPrefix + "-slow-" + ctx.RandomName
+		slowServiceName := consts.TestPrefix + "-slow-svc-" + ctx.RandomName
+		ctx.CreateSlowServerPod(slowPodName, 10*time.Second)
+		ctx.CreateServiceHelper(slowServiceName, "ClusterIP", []corev1.ServicePort{util.GeneratePort("http", 80, 8080, "TCP")}, map[string]string{"app": slowPodName})
+
+		routeTimeout := 3 * time.Second
+		ctx.CreateRouteWithOptionsHelper(routeName, slowServiceName, 80, util.RouteOptions{Router: util.RouterOptions{Timeout: routeTimeout}})
+		routeURL := ctx.WaitForRouteURL(routeName, 2*time.Minute, 10*time.Second)
+
+		ctx.ExpectRouteTimeout(ctx.PodProber(clientPodName), routeURL, routeTimeout, 2*time.Minute)
+	})
+})
@@ -1,11 +1,14 @@
 package network_test
 
 import (
+	"strings"
 	"time"
 	"myproject/framework"
 	"myproject/util"
 	"myproject/consts"
 	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	routev1 "github.com/openshift/api/route/v1"
 	corev1 "k8s.io/api/core/v1"
 )
 
@@ -17,13 +20,11 @@ var _ = Describe("Create Route with service and access from the cluster", func()
 		serviceName   string
 		routeName     string
 		routeURL      string
-		image       = consts.HttpdImage
-		clientImage = consts.ClientImage
 	)
 
 	BeforeEach(func() {
-		// Initialize the TestContext and setup environment
-		ctx = framework.Setup("core")
+		// Initialize the TestContext and setup environment in a new ephemeral namespace
+		ctx = framework.SetupEphemeral("route")
 
 		// Generate names for the pod, service, and route using the random name from context
 		serverPodName = consts.TestPrefix + "-server-" + ctx.RandomName
@@ -32,7 +33,7 @@ var _ = Describe("Create Route with service and access from the cluster", func()
 
 		// Define the pod to be exposed by the service (runs an HTTP server)
 		containers := []util.ContainerConfig{
-			util.CreateContainerConfig("httpd-container", image, nil, util.GenerateResourceRequirements("250m", "1000m", "1Gi", "1Gi")),
+			util.CreateContainerConfig("httpd-container", ctx.TestConfig.Images.Httpd, nil, util.GenerateResourceRequirements("250m", "1000m", "1Gi", "1Gi")),
 		}
 
 		// Create the main test pod
@@ -55,7 +56,7 @@ var _ = Describe("Create Route with service and access from the cluster", func()
 
 		// Define the test pod that will access the route using curl
 		testContainers := []util.ContainerConfig{
-			util.CreateContainerConfig("curl-container", clientImage, []string{
+			util.CreateContainerConfig("curl-container", ctx.TestConfig.Images.Client, []string{
 				"curl", "--fail", "--retry", "5", "-w", "HTTP Response Code: %{http_code}\n", routeURL,
 			}, util.GenerateResourceRequirements("100m", "400m", "200Mi", "200Mi")),
 		}
@@ -68,11 +69,29 @@ var _ = Describe("Create Route with service and access from the cluster", func()
 		ctx.VerifyPodResponse(clientPodName, "HTTP Response Code: 200", 3)
 	})
 
-	AfterEach(func() {
-		// Clean up resources: Delete the route, pods, and services
-		ctx.CleanupResource(serverPodName, "pod")
-		ctx.CleanupResource(clientPodName, "pod")
-		ctx.CleanupResource(serviceName, "service")
-		ctx.CleanupResource(routeName, "route")
+	It("should serve the default certificate on an edge route", func() {
+		edgeRouteName := routeName + "-edge"
+		ctx.CreateRouteWithOptionsHelper(edgeRouteName, serviceName, 80, util.RouteOptions{
+			Termination:                   routev1.TLSTerminationEdge,
+			InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyRedirect,
+		})
+
+		routeURL = ctx.WaitForRouteURL(edgeRouteName, 2*time.Minute, 10*time.Second)
+		Expect(routeURL).To(HavePrefix("https://"))
+
+		// Without its own certificate the route gets the router's default one, signed by the ingress CA
+		ingressCA, err := util.GetDefaultIngressCA(ctx.KubeClient)
+		Expect(err).ToNot(HaveOccurred())
+		ctx.ExpectRouteCertificate(edgeRouteName, ingressCA, 2*time.Minute)
+	})
+
+	It("should reject a second route claiming the same host", func() {
+		routeURL = ctx.WaitForRouteURL(routeName, 2*time.Minute, 10*time.Second)
+		host := strings.TrimPrefix(routeURL, "http://")
+
+		// The older route keeps the host, the newer one is rejected by every router
+		duplicateRouteName := routeName + "-dup"
+		ctx.CreateRouteHelper(duplicateRouteName, serviceName, 80, host)
+		ctx.ExpectRouteRejected(duplicateRouteName, "HostAlreadyClaimed", 2*time.Minute)
 	})
 })
@@ -0,0 +1,61 @@
+package network_test
+
+import (
+	"time"
+	"myproject/framework"
+	"myproject/util"
+	"myproject/consts"
+	. "github.com/onsi/ginkgo/v2"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Route with weighted backends", func() {
+	var (
+		ctx           *framework.TestContext
+		clientPodName string
+		stablePodName string
+		canaryPodName string
+		stableService string
+		canaryService string
+		routeName     string
+	)
+
+	BeforeEach(func() {
+		// Initialize the TestContext and setup environment in a new ephemeral namespace
+		ctx = framework.SetupEphemeral("route-split")
+
+		stablePodName = consts.TestPrefix + "-stable-" + ctx.RandomName
+		canaryPodName = consts.TestPrefix + "-canary-" + ctx.RandomName
+		stableService = consts.TestPrefix + "-stable-svc-" + ctx.RandomName
+		canaryService = consts.TestPrefix + "-canary-svc-" + ctx.RandomName
+		routeName = consts.TestPrefix + "-route-" + ctx.RandomName
+
+		// Each backend answers with its pod name
+		servicePorts := []corev1.ServicePort{util.GeneratePort("http", 80, 80, "TCP")}
+		ctx.CreateIdentifiedServerPod(stablePodName)
+		ctx.CreateServiceHelper(stableService, "ClusterIP", servicePorts, map[string]string{"app": stablePodName})
+		ctx.CreateIdentifiedServerPod(canaryPodName)
+		ctx.CreateServiceHelper(canaryService, "ClusterIP", servicePorts, map[string]string{"app": canaryPodName})
+
+		clientPodName = consts.TestPrefix + "-client-" + ctx.RandomName
+		ctx.CreateClientPod(clientPodName)
+	})
+
+	It("should split the traffic between the services by weight", func() {
+		stableWeight := int32(3)
+		ctx.CreateRouteWithOptionsHelper(routeName, stableService, 80, util.RouteOptions{
+			Weight:            &stableWeight,
+			AlternateBackends: []util.RouteBackend{{ServiceName: canaryService, Weight: 1}},
+		})
+
+		// Wait until the router serves the route before counting
+		routeURL := ctx.WaitForRouteURL(routeName, 2*time.Minute, 10*time.Second)
+		ctx.VerifyHTTPFromPod(clientPodName, routeURL, 200, 2*time.Minute)
+
+		// 75% / 25%, within 10 percentage points
+		ctx.ExpectRouteTrafficSplit(ctx.PodProber(clientPodName), routeName, map[string]string{
+			stableService: stablePodName,
+			canaryService: canaryPodName,
+		}, 100, 0.1)
+	})
+})
@@ -15,14 +15,12 @@ var _ = Describe("Service type LoadBalancer on Pod", func() {
 		serverPodName string
 		clientPodName string
 		serviceName   string
-		imageClient = consts.ClientImage
-		image       = consts.HttpdImage
 		serviceIP     string
 	)
 
 	BeforeEach(func() {
-		// Initialize the TestContext and setup environment
-		ctx = framework.Setup("core")
+		// Initialize the TestContext and setup environment in a new ephemeral namespace
+		ctx = framework.SetupEphemeral("loadbalancer")
 
 		// Generate names for the pod, test pod, and service using the random name from context
 		serverPodName = consts.TestPrefix + "-server-" + ctx.RandomName
@@ -31,7 +29,7 @@ var _ = Describe("Service type LoadBalancer on Pod", func() {
 
 		// Define the pod to be exposed by the LoadBalancer
 		containers := []util.ContainerConfig{
-			util.CreateContainerConfig("test-container", image, nil, util.GenerateResourceRequirements("250m", "1000m", "1Gi", "1Gi")),
+			util.CreateContainerConfig("test-container", ctx.TestConfig.Images.Httpd, nil, util.GenerateResourceRequirements("250m", "1000m", "1Gi", "1Gi")),
 		}
 
 		// Create the main test pod
@@ -49,22 +47,10 @@ var _ = Describe("Service type LoadBalancer on Pod", func() {
 		// Wait for the service to get an external IP
 		serviceIP = ctx.WaitForServiceIP(serviceName, 2*time.Minute, 10*time.Second)
 
-		// Define the test pod that will access the service
-		testContainers := []util.ContainerConfig{
-			util.CreateContainerConfig("curl-container", imageClient, []string{"curl", "--fail", "--retry", "5", "-w", "HTTP Response Code: %{http_code}\n", "http://" + serviceIP}, util.GenerateResourceRequirements("100m", "400m", "200Mi", "200Mi")),
-		}
-
-		// Create the test pod using the retry mechanism
-		ctx.CreateTestPodHelper(clientPodName, testContainers, 3)
-
-		// Wait for the test pod to complete and verify its status
-		ctx.VerifyPodResponse(clientPodName, "HTTP Response Code: 200", 3)
-	})
+		// Start a long-lived client pod in the same namespace
+		ctx.CreateClientPod(clientPodName)
 
-	AfterEach(func() {
-		// Clean up resources: Delete the test pods and the service
-		ctx.CleanupResource(serverPodName, "pod")
-		ctx.CleanupResource(clientPodName, "pod")
-		ctx.CleanupResource(serviceName, "service")
+		// Verify access to the service from inside the client pod
+		ctx.VerifyHTTPFromPod(clientPodName, "http://"+serviceIP, 200, 3*time.Minute)
 	})
 })
@@ -0,0 +1,426 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"myproject/consts"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
+)
+
+// Kinds and resources of the cluster scoped admin policies of the policy.networking.k8s.io API
+const (
+	AdminNetworkPolicyKind         = "AdminNetworkPolicy"
+	BaselineAdminNetworkPolicyKind = "BaselineAdminNetworkPolicy"
+
+	// BaselineAdminNetworkPolicyName is the only name the API accepts for the BANP singleton
+	BaselineAdminNetworkPolicyName = "default"
+
+	maxAdminPolicyPriority = 1000
+	maxAdminPolicyRules    = 100
+	maxAdminRuleNameLength = 100
+)
+
+var (
+	AdminNetworkPolicyGVR         = schema.GroupVersionResource{Group: "policy.networking.k8s.io", Version: "v1alpha1", Resource: "adminnetworkpolicies"}
+	BaselineAdminNetworkPolicyGVR = schema.GroupVersionResource{Group: "policy.networking.k8s.io", Version: "v1alpha1", Resource: "baselineadminnetworkpolicies"}
+)
+
+// AdminPolicyAction is what an admin policy rule does with the traffic it matches
+type AdminPolicyAction string
+
+const (
+	AdminPolicyAllow AdminPolicyAction = "Allow" // Allow the traffic, NetworkPolicies can't deny it
+	AdminPolicyDeny  AdminPolicyAction = "Deny"  // Drop the traffic, NetworkPolicies can't allow it
+	AdminPolicyPass  AdminPolicyAction = "Pass"  // Skip lower priority ANPs and let NetworkPolicies and the BANP decide (ANP only)
+)
+
+// The structs below mirror the v1alpha1 API so the builders can produce unstructured objects
+// without depending on the API module.
+type adminPolicySpec struct {
+	Priority *int32             `json:"priority,omitempty"`
+	Subject  adminPolicySubject `json:"subject"`
+	Ingress  []adminPolicyRule  `json:"ingress,omitempty"`
+	Egress   []adminPolicyRule  `json:"egress,omitempty"`
+}
+
+type adminPolicySubject struct {
+	Namespaces *metav1.LabelSelector `json:"namespaces,omitempty"`
+	Pods       *adminPolicyPods      `json:"pods,omitempty"`
+}
+
+type adminPolicyPods struct {
+	NamespaceSelector metav1.LabelSelector `json:"namespaceSelector"`
+	PodSelector       metav1.LabelSelector `json:"podSelector"`
+}
+
+type adminPolicyRule struct {
+	Name   string            `json:"name,omitempty"`
+	Action AdminPolicyAction `json:"action"`
+	From   []adminPolicyPeer `json:"from,omitempty"`
+	To     []adminPolicyPeer `json:"to,omitempty"`
+	Ports  []adminPolicyPort `json:"ports,omitempty"`
+}
+
+type adminPolicyPeer struct {
+	Namespaces *metav1.LabelSelector `json:"namespaces,omitempty"`
+	Pods       *adminPolicyPods      `json:"pods,omitempty"`
+	Nodes      *metav1.LabelSelector `json:"nodes,omitempty"`
+	Networks   []string              `json:"networks,omitempty"`
+}
+
+type adminPolicyPort struct {
+	PortNumber *adminPolicyPortNumber `json:"portNumber,omitempty"`
+	NamedPort  *string                `json:"namedPort,omitempty"`
+	PortRange  *adminPolicyPortRange  `json:"portRange,omitempty"`
+}
+
+type adminPolicyPortNumber struct {
+	Protocol string `json:"protocol"`
+	Port     int32  `json:"port"`
+}
+
+type adminPolicyPortRange struct {
+	Protocol string `json:"protocol"`
+	Start    int32  `json:"start"`
+	End      int32  `json:"end"`
+}
+
+// AdminPolicyRule builds one ingress or egress rule of an ANP or BANP.
+// Unlike NetworkPolicy rules, a rule needs at least one peer and rules are evaluated in order.
+type AdminPolicyRule struct {
+	rule     adminPolicyRule
+	peers    []adminPolicyPeer
+	egress   bool // Set when the rule uses peers only valid for egress
+	problems []string
+}
+
+// NewAdminPolicyRule starts a rule with a name (shown in events and logs) and an action
+func NewAdminPolicyRule(name string, action AdminPolicyAction) *AdminPolicyRule {
+	r := &AdminPolicyRule{rule: adminPolicyRule{Name: name, Action: action}}
+	if len(name) > maxAdminRuleNameLength {
+		r.problems = append(r.problems, fmt.Sprintf("rule name %q is longer than %d characters", name, maxAdminRuleNameLength))
+	}
+	switch action {
+	case AdminPolicyAllow, AdminPolicyDeny, AdminPolicyPass:
+	default:
+		r.problems = append(r.problems, fmt.Sprintf("rule %q: unknown action %q, expected Allow, Deny or Pass", name, action))
+	}
+	return r
+}
+
+// Namespaces matches every pod of the namespaces matching the labels, every namespace if labels is empty
+func (r *AdminPolicyRule) Namespaces(namespaceLabels map[string]string) *AdminPolicyRule {
+	selector := &metav1.LabelSelector{MatchLabels: namespaceLabels}
+	r.validSelector(selector)
+	r.peers = append(r.peers, adminPolicyPeer{Namespaces: selector})
+	return r
+}
+
+// Namespace matches every pod of the named namespace
+func (r *AdminPolicyRule) Namespace(name string) *AdminPolicyRule {
+	return r.Namespaces(map[string]string{namespaceNameLabel: name})
+}
+
+// Pods matches the pods matching podLabels in the namespaces matching namespaceLabels
+func (r *AdminPolicyRule) Pods(podLabels, namespaceLabels map[string]string) *AdminPolicyRule {
+	pods := &adminPolicyPods{
+		NamespaceSelector: metav1.LabelSelector{MatchLabels: namespaceLabels},
+		PodSelector:       metav1.LabelSelector{MatchLabels: podLabels},
+	}
+	r.validSelector(&pods.NamespaceSelector)
+	r.validSelector(&pods.PodSelector)
+	r.peers = append(r.peers, adminPolicyPeer{Pods: pods})
+	return r
+}
+
+// Nodes matches the host network of the nodes matching the labels. Egress rules only.
+func (r *AdminPolicyRule) Nodes(nodeLabels map[string]string) *AdminPolicyRule {
+	selector := &metav1.LabelSelector{MatchLabels: nodeLabels}
+	r.validSelector(selector)
+	r.peers = append(r.peers, adminPolicyPeer{Nodes: selector})
+	r.egress = true
+	return r
+}
+
+// Networks matches addresses in the CIDRs. Egress rules only.
+func (r *AdminPolicyRule) Networks(cidrs ...string) *AdminPolicyRule {
+	if len(cidrs) == 0 {
+		r.problems = append(r.problems, fmt.Sprintf("rule %q: networks needs at least one CIDR", r.rule.Name))
+	}
+	for _, cidr := range cidrs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			r.problems = append(r.problems, fmt.Sprintf("rule %q: invalid network CIDR %q", r.rule.Name, cidr))
+		}
+	}
+	r.peers = append(r.peers, adminPolicyPeer{Networks: cidrs})
+	r.egress = true
+	return r
+}
+
+// Port matches a single port number
+func (r *AdminPolicyRule) Port(port int32, protocol string) *AdminPolicyRule {
+	parsed, ok := r.validPort(port, protocol)
+	if ok {
+		r.rule.Ports = append(r.rule.Ports, adminPolicyPort{PortNumber: &adminPolicyPortNumber{Protocol: parsed, Port: port}})
+	}
+	return r
+}
+
+// PortRange matches the ports from start to end, both included
+func (r *AdminPolicyRule) PortRange(start, end int32, protocol string) *AdminPolicyRule {
+	parsed, ok := r.validPort(start, protocol)
+	if _, endOK := r.validPort(end, protocol); !ok || !endOK {
+		return r
+	}
+	if end < start {
+		r.problems = append(r.problems, fmt.Sprintf("rule %q: port range %d-%d ends before it starts", r.rule.Name, start, end))
+		return r
+	}
+	r.rule.Ports = append(r.rule.Ports, adminPolicyPort{PortRange: &adminPolicyPortRange{Protocol: parsed, Start: start, End: end}})
+	return r
+}
+
+// NamedPort matches the container port with that name on the pods
+func (r *AdminPolicyRule) NamedPort(name string) *AdminPolicyRule {
+	if problems := validation.IsValidPortName(name); len(problems) > 0 {
+		r.problems = append(r.problems, fmt.Sprintf("rule %q: invalid port name %q: %s", r.rule.Name, name, strings.Join(problems, ", ")))
+		return r
+	}
+	r.rule.Ports = append(r.rule.Ports, adminPolicyPort{NamedPort: &name})
+	return r
+}
+
+func (r *AdminPolicyRule) validPort(port int32, protocol string) (string, bool) {
+	parsed, err := ParseProtocol(protocol)
+	if err != nil {
+		r.problems = append(r.problems, fmt.Sprintf("rule %q: %v", r.rule.Name, err))
+		return "", false
+	}
+	if port < 1 || port > 65535 {
+		r.problems = append(r.problems, fmt.Sprintf("rule %q: port %d is outside 1-65535", r.rule.Name, port))
+		return "", false
+	}
+	return string(parsed), true
+}
+
+func (r *AdminPolicyRule) validSelector(selector *metav1.LabelSelector) {
+	if _, err := metav1.LabelSelectorAsSelector(selector); err != nil {
+		r.problems = append(r.problems, fmt.Sprintf("rule %q: invalid selector: %v", r.rule.Name, err))
+	}
+}
+
+// AdminNetworkPolicyBuilder builds an AdminNetworkPolicy or the BaselineAdminNetworkPolicy as an
+// unstructured object for the dynamic client, and reports every invalid input when built.
+type AdminNetworkPolicyBuilder struct {
+	kind     string
+	name     string
+	labels   map[string]string
+	spec     adminPolicySpec
+	problems []string
+}
+
+// NewAdminNetworkPolicyBuilder starts an ANP. Lower priorities are evaluated first, from 0 to 1000.
+// Priorities must be unique among the ANPs of the cluster, so parallel specs should use different ones.
+func NewAdminNetworkPolicyBuilder(name string, priority int32) *AdminNetworkPolicyBuilder {
+	b := &AdminNetworkPolicyBuilder{kind: AdminNetworkPolicyKind, name: name, labels: MergeLabels(consts.DefaultLabels)}
+	b.spec.Priority = &priority
+	if priority < 0 || priority > maxAdminPolicyPriority {
+		b.problems = append(b.problems, fmt.Sprintf("priority %d is outside 0-%d", priority, maxAdminPolicyPriority))
+	}
+	return b
+}
+
+// NewBaselineAdminNetworkPolicyBuilder starts the BANP, which is evaluated after NetworkPolicies.
+// There is a single BANP per cluster, so specs using it can't run in parallel.
+func NewBaselineAdminNetworkPolicyBuilder() *AdminNetworkPolicyBuilder {
+	return &AdminNetworkPolicyBuilder{kind: BaselineAdminNetworkPolicyKind, name: BaselineAdminNetworkPolicyName, labels: MergeLabels(consts.DefaultLabels)}
+}
+
+// WithLabels adds labels to the policy object
+func (b *AdminNetworkPolicyBuilder) WithLabels(labels map[string]string) *AdminNetworkPolicyBuilder {
+	b.labels = MergeLabels(b.labels, labels)
+	return b
+}
+
+// SubjectNamespaces applies the policy to every pod of the namespaces matching the labels
+func (b *AdminNetworkPolicyBuilder) SubjectNamespaces(namespaceLabels map[string]string) *AdminNetworkPolicyBuilder {
+	b.spec.Subject = adminPolicySubject{Namespaces: &metav1.LabelSelector{MatchLabels: namespaceLabels}}
+	return b
+}
+
+// SubjectPods applies the policy to the pods matching podLabels in the namespaces matching namespaceLabels
+func (b *AdminNetworkPolicyBuilder) SubjectPods(podLabels, namespaceLabels map[string]string) *AdminNetworkPolicyBuilder {
+	b.spec.Subject = adminPolicySubject{Pods: &adminPolicyPods{
+		NamespaceSelector: metav1.LabelSelector{MatchLabels: namespaceLabels},
+		PodSelector:       metav1.LabelSelector{MatchLabels: podLabels},
+	}}
+	return b
+}
+
+// Ingress appends a rule for traffic coming into the subject. Rules are evaluated in order.
+func (b *AdminNetworkPolicyBuilder) Ingress(rule *AdminPolicyRule) *AdminNetworkPolicyBuilder {
+	if rule.egress {
+		b.problems = append(b.problems, fmt.Sprintf("ingress rule %q can't match nodes or networks", rule.rule.Name))
+	}
+	built := rule.rule
+	built.From = append([]adminPolicyPeer{}, rule.peers...)
+	b.addRule(rule, built, &b.spec.Ingress)
+	return b
+}
+
+// Egress appends a rule for traffic leaving the subject. Rules are evaluated in order.
+func (b *AdminNetworkPolicyBuilder) Egress(rule *AdminPolicyRule) *AdminNetworkPolicyBuilder {
+	built := rule.rule
+	built.To = append([]adminPolicyPeer{}, rule.peers...)
+	b.addRule(rule, built, &b.spec.Egress)
+	return b
+}
+
+func (b *AdminNetworkPolicyBuilder) addRule(rule *AdminPolicyRule, built adminPolicyRule, rules *[]adminPolicyRule) {
+	b.problems = append(b.problems, rule.problems...)
+	if len(rule.peers) == 0 {
+		b.problems = append(b.problems, fmt.Sprintf("rule %q needs at least one peer", rule.rule.Name))
+	}
+	if b.kind == BaselineAdminNetworkPolicyKind && rule.rule.Action == AdminPolicyPass {
+		b.problems = append(b.problems, fmt.Sprintf("rule %q: the Pass action is not allowed in a BaselineAdminNetworkPolicy", rule.rule.Name))
+	}
+	built.Ports = append([]adminPolicyPort{}, built.Ports...)
+	*rules = append(*rules, built)
+}
+
+// Build validates the policy and returns it as a new unstructured object
+func (b *AdminNetworkPolicyBuilder) Build() (*unstructured.Unstructured, error) {
+	problems := append([]string{}, b.problems...)
+	for _, msg := range validation.IsDNS1123Subdomain(b.name) {
+		problems = append(problems, fmt.Sprintf("name %q: %s", b.name, msg))
+	}
+	if b.kind == BaselineAdminNetworkPolicyKind && b.name != BaselineAdminNetworkPolicyName {
+		problems = append(problems, fmt.Sprintf("a BaselineAdminNetworkPolicy must be named %q", BaselineAdminNetworkPolicyName))
+	}
+
+	subject := b.spec.Subject
+	switch {
+	case subject.Namespaces == nil && subject.Pods == nil:
+		problems = append(problems, "the policy has no subject")
+	case subject.Namespaces != nil:
+		if _, err := metav1.LabelSelectorAsSelector(subject.Namespaces); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid subject: %v", err))
+		}
+	default:
+		for _, selector := range []*metav1.LabelSelector{&subject.Pods.NamespaceSelector, &subject.Pods.PodSelector} {
+			if _, err := metav1.LabelSelectorAsSelector(selector); err != nil {
+				problems = append(problems, fmt.Sprintf("invalid subject: %v", err))
+			}
+		}
+	}
+	if len(b.spec.Ingress) > maxAdminPolicyRules || len(b.spec.Egress) > maxAdminPolicyRules {
+		problems = append(problems, fmt.Sprintf("at most %d ingress and %d egress rules are allowed", maxAdminPolicyRules, maxAdminPolicyRules))
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid %s %s: %s", b.kind, b.name, strings.Join(problems, "; "))
+	}
+
+	spec, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&b.spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %s %s: %v", b.kind, b.name, err)
+	}
+	policy := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	policy.SetAPIVersion(AdminNetworkPolicyGVR.GroupVersion().String())
+	policy.SetKind(b.kind)
+	policy.SetName(b.name)
+	policy.SetLabels(MergeLabels(b.labels))
+	return policy, nil
+}
+
+// adminPolicyResource returns the resource of an admin policy kind
+func adminPolicyResource(kind string) (schema.GroupVersionResource, error) {
+	switch kind {
+	case AdminNetworkPolicyKind:
+		return AdminNetworkPolicyGVR, nil
+	case BaselineAdminNetworkPolicyKind:
+		return BaselineAdminNetworkPolicyGVR, nil
+	default:
+		return schema.GroupVersionResource{}, fmt.Errorf("unsupported admin policy kind: %s", kind)
+	}
+}
+
+// CreateAdminPolicy creates an AdminNetworkPolicy or BaselineAdminNetworkPolicy built with the builders
+func CreateAdminPolicy(client dynamic.Interface, policy *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	resource, err := adminPolicyResource(policy.GetKind())
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := client.Resource(resource).Create(context.TODO(), policy, metav1.CreateOptions{})
+	if err != nil {
+		LogError("Failed to create %s %s: %v", policy.GetKind(), policy.GetName(), err)
+		return nil, fmt.Errorf("failed to create %s %s: %w", policy.GetKind(), policy.GetName(), err)
+	}
+
+	LogInfo("Successfully created %s %s", policy.GetKind(), policy.GetName())
+	return created, nil
+}
+
+// GetAdminPolicy fetches an AdminNetworkPolicy or BaselineAdminNetworkPolicy
+func GetAdminPolicy(ctx context.Context, client dynamic.Interface, kind, name string) (*unstructured.Unstructured, error) {
+	resource, err := adminPolicyResource(kind)
+	if err != nil {
+		return nil, err
+	}
+	return client.Resource(resource).Get(ctx, name, metav1.GetOptions{})
+}
+
+// DeleteAdminPolicy deletes an AdminNetworkPolicy or BaselineAdminNetworkPolicy
+func DeleteAdminPolicy(client dynamic.Interface, kind, name string) error {
+	resource, err := adminPolicyResource(kind)
+	if err != nil {
+		return err
+	}
+	err = client.Resource(resource).Delete(context.TODO(), name, metav1.DeleteOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to delete %s %s: %w", kind, name, err)
+	}
+	return nil
+}
+
+// WaitForAdminPolicyReady waits until the network plugin reports the policy as programmed.
+// OVN-Kubernetes adds one Ready-In-Zone-<zone> condition per zone, so the policy is ready once it
+// has conditions and all of them are True. A policy without conditions keeps the wait going.
+func WaitForAdminPolicyReady(ctx context.Context, client dynamic.Interface, kind, name string, backoff Backoff) error {
+	return WaitForWithContext(ctx, backoff, func(ctx context.Context) (bool, error) {
+		policy, err := GetAdminPolicy(ctx, client, kind, name)
+		if apierrors.IsNotFound(err) {
+			return false, fmt.Errorf("%s %s does not exist", kind, name)
+		}
+		if err != nil {
+			return false, err
+		}
+
+		conditions, _, err := unstructured.NestedSlice(policy.Object, "status", "conditions")
+		if err != nil {
+			return false, fmt.Errorf("%s %s has invalid conditions: %v", kind, name, err)
+		}
+		if len(conditions) == 0 {
+			LogInfo("Waiting for %s %s to report its status...", kind, name)
+			return false, nil
+		}
+		for _, raw := range conditions {
+			condition, _ := raw.(map[string]interface{})
+			if condition["status"] != string(metav1.ConditionTrue) {
+				return false, fmt.Errorf("%s %s is not ready: %v: %v", kind, name, condition["type"], condition["message"])
+			}
+		}
+
+		LogInfo("%s %s is ready.", kind, name)
+		return true, nil
+	})
+}
@@ -0,0 +1,139 @@
+package util_test
+
+import (
+	"context"
+	"time"
+
+	"myproject/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+var _ = Describe("AdminNetworkPolicyBuilder", func() {
+	It("should build an ANP with ordered rules", func() {
+		policy, err := util.NewAdminNetworkPolicyBuilder("tenant-isolation", 10).
+			SubjectNamespaces(map[string]string{"tenant": "blue"}).
+			Ingress(util.NewAdminPolicyRule("allow-monitoring", util.AdminPolicyAllow).Namespace("openshift-monitoring")).
+			Ingress(util.NewAdminPolicyRule("pass-same-tenant", util.AdminPolicyPass).Namespaces(map[string]string{"tenant": "blue"})).
+			Ingress(util.NewAdminPolicyRule("deny-others", util.AdminPolicyDeny).Namespaces(nil)).
+			Egress(util.NewAdminPolicyRule("deny-metadata", util.AdminPolicyDeny).Networks("169.254.169.254/32").Port(80, "TCP")).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(policy.GetAPIVersion()).To(Equal("policy.networking.k8s.io/v1alpha1"))
+		Expect(policy.GetKind()).To(Equal(util.AdminNetworkPolicyKind))
+		Expect(policy.GetLabels()).To(HaveKeyWithValue("managed", "openshift-testing"))
+
+		priority, _, _ := unstructured.NestedInt64(policy.Object, "spec", "priority")
+		Expect(priority).To(BeEquivalentTo(10))
+		tenant, _, _ := unstructured.NestedString(policy.Object, "spec", "subject", "namespaces", "matchLabels", "tenant")
+		Expect(tenant).To(Equal("blue"))
+
+		ingress, _, _ := unstructured.NestedSlice(policy.Object, "spec", "ingress")
+		Expect(ingress).To(HaveLen(3))
+		var actions []interface{}
+		for _, rule := range ingress {
+			actions = append(actions, rule.(map[string]interface{})["action"])
+		}
+		Expect(actions).To(Equal([]interface{}{"Allow", "Pass", "Deny"}))
+
+		egress, _, _ := unstructured.NestedSlice(policy.Object, "spec", "egress")
+		egressRule := egress[0].(map[string]interface{})
+		Expect(egressRule["to"]).To(Equal([]interface{}{map[string]interface{}{"networks": []interface{}{"169.254.169.254/32"}}}))
+		Expect(egressRule["ports"]).To(Equal([]interface{}{map[string]interface{}{"portNumber": map[string]interface{}{"protocol": "TCP", "port": int64(80)}}}))
+	})
+
+	It("should build the BANP singleton without a priority", func() {
+		policy, err := util.NewBaselineAdminNetworkPolicyBuilder().
+			SubjectPods(map[string]string{"app": "web"}, nil).
+			Ingress(util.NewAdminPolicyRule("default-deny", util.AdminPolicyDeny).Namespaces(nil)).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(policy.GetKind()).To(Equal(util.BaselineAdminNetworkPolicyKind))
+		Expect(policy.GetName()).To(Equal("default"))
+
+		_, found, _ := unstructured.NestedFieldNoCopy(policy.Object, "spec", "priority")
+		Expect(found).To(BeFalse())
+	})
+
+	DescribeTable("reports invalid input",
+		func(builder *util.AdminNetworkPolicyBuilder, problem string) {
+			_, err := builder.Build()
+			Expect(err).To(MatchError(ContainSubstring(problem)))
+		},
+		Entry("priority out of range", util.NewAdminNetworkPolicyBuilder("anp", 1001).SubjectNamespaces(nil), "priority 1001 is outside 0-1000"),
+		Entry("no subject", util.NewAdminNetworkPolicyBuilder("anp", 1), "the policy has no subject"),
+		Entry("unknown action", util.NewAdminNetworkPolicyBuilder("anp", 1).SubjectNamespaces(nil).
+			Ingress(util.NewAdminPolicyRule("r", "Drop").Namespaces(nil)), `unknown action "Drop"`),
+		Entry("rule without peers", util.NewAdminNetworkPolicyBuilder("anp", 1).SubjectNamespaces(nil).
+			Ingress(util.NewAdminPolicyRule("r", util.AdminPolicyDeny)), `rule "r" needs at least one peer`),
+		Entry("networks in ingress", util.NewAdminNetworkPolicyBuilder("anp", 1).SubjectNamespaces(nil).
+			Ingress(util.NewAdminPolicyRule("r", util.AdminPolicyDeny).Networks("10.0.0.0/8")), "can't match nodes or networks"),
+		Entry("invalid network", util.NewAdminNetworkPolicyBuilder("anp", 1).SubjectNamespaces(nil).
+			Egress(util.NewAdminPolicyRule("r", util.AdminPolicyDeny).Networks("10.0.0.0")), `invalid network CIDR "10.0.0.0"`),
+		Entry("invalid protocol", util.NewAdminNetworkPolicyBuilder("anp", 1).SubjectNamespaces(nil).
+			Egress(util.NewAdminPolicyRule("r", util.AdminPolicyDeny).Namespaces(nil).Port(80, "ICMP")), `unsupported protocol "ICMP"`),
+		Entry("reversed port range", util.NewAdminNetworkPolicyBuilder("anp", 1).SubjectNamespaces(nil).
+			Egress(util.NewAdminPolicyRule("r", util.AdminPolicyDeny).Namespaces(nil).PortRange(9000, 8000, "TCP")), "ends before it starts"),
+		Entry("Pass in the BANP", util.NewBaselineAdminNetworkPolicyBuilder().SubjectNamespaces(nil).
+			Ingress(util.NewAdminPolicyRule("r", util.AdminPolicyPass).Namespaces(nil)), "Pass action is not allowed"),
+	)
+})
+
+var _ = Describe("Admin policy helpers", func() {
+	var client *dynamicfake.FakeDynamicClient
+
+	BeforeEach(func() {
+		client = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	})
+
+	newANP := func() *unstructured.Unstructured {
+		policy, err := util.NewAdminNetworkPolicyBuilder("anp", 5).SubjectNamespaces(nil).
+			Ingress(util.NewAdminPolicyRule("deny", util.AdminPolicyDeny).Namespaces(nil)).Build()
+		Expect(err).ToNot(HaveOccurred())
+		return policy
+	}
+
+	setConditions := func(statuses ...string) {
+		policy, err := util.GetAdminPolicy(context.Background(), client, util.AdminNetworkPolicyKind, "anp")
+		Expect(err).ToNot(HaveOccurred())
+		var conditions []interface{}
+		for i, status := range statuses {
+			conditions = append(conditions, map[string]interface{}{"type": "Ready-In-Zone-" + string(rune('a'+i)), "status": status, "message": "programmed"})
+		}
+		Expect(unstructured.SetNestedSlice(policy.Object, conditions, "status", "conditions")).To(Succeed())
+		_, err = client.Resource(util.AdminNetworkPolicyGVR).Update(context.Background(), policy, metav1.UpdateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	It("should create, wait for and delete an ANP", func() {
+		_, err := util.CreateAdminPolicy(client, newANP())
+		Expect(err).ToNot(HaveOccurred())
+
+		setConditions("True", "True")
+		err = util.WaitForAdminPolicyReady(context.Background(), client, util.AdminNetworkPolicyKind, "anp", util.ConstantBackoff(10*time.Millisecond, time.Second, 0))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(util.DeleteAdminPolicy(client, util.AdminNetworkPolicyKind, "anp")).To(Succeed())
+		_, err = util.GetAdminPolicy(context.Background(), client, util.AdminNetworkPolicyKind, "anp")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should keep waiting while a zone is not ready", func() {
+		_, err := util.CreateAdminPolicy(client, newANP())
+		Expect(err).ToNot(HaveOccurred())
+
+		setConditions("True", "False")
+		err = util.WaitForAdminPolicyReady(context.Background(), client, util.AdminNetworkPolicyKind, "anp", util.ConstantBackoff(10*time.Millisecond, 100*time.Millisecond, 0))
+		Expect(err).To(MatchError(util.ErrWaitTimeout))
+		Expect(err).To(MatchError(ContainSubstring("Ready-In-Zone-b")))
+	})
+
+	It("should reject unknown kinds", func() {
+		Expect(util.DeleteAdminPolicy(client, "NetworkPolicy", "anp")).To(MatchError(ContainSubstring("unsupported admin policy kind")))
+	})
+})
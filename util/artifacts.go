@@ -0,0 +1,65 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+// maxArtifactNameLength keeps artifact file and directory names well below filesystem limits
+const maxArtifactNameLength = 120
+
+var unsafeFileNameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// SanitizeFileName turns an arbitrary string (e.g. a spec name) into a safe file or directory name.
+func SanitizeFileName(name string) string {
+	sanitized := unsafeFileNameChars.ReplaceAllString(name, "_")
+	if len(sanitized) > maxArtifactNameLength {
+		sanitized = sanitized[:maxArtifactNameLength]
+	}
+	if sanitized == "" {
+		sanitized = "unnamed"
+	}
+	return sanitized
+}
+
+// WriteYAML serializes obj as YAML into the given file, creating parent directories as needed.
+func WriteYAML(path string, obj interface{}) error {
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %v", path, err)
+	}
+	return writeArtifact(path, data)
+}
+
+// WriteText writes plain text (e.g. logs) into the given file, creating parent directories as needed.
+func WriteText(path, content string) error {
+	return writeArtifact(path, []byte(content))
+}
+
+func writeArtifact(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create artifact directory for %s: %v", path, err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write artifact %s: %v", path, err)
+	}
+	LogDebug("Wrote artifact %s", path)
+	return nil
+}
+
+// GetContainerLogs fetches the logs of a single container of a pod
+func GetContainerLogs(clientset kubernetes.Interface, namespace, podName, containerName string) (string, error) {
+	podLogOpts := corev1.PodLogOptions{Container: containerName}
+	data, err := clientset.CoreV1().Pods(namespace).GetLogs(podName, &podLogOpts).DoRaw(context.TODO())
+	if err != nil {
+		return "", fmt.Errorf("failed to get logs of container %s in pod %s: %v", containerName, podName, err)
+	}
+	return string(data), nil
+}
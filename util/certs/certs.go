@@ -0,0 +1,222 @@
+// Package certs generates throwaway certificate authorities and certificates for TLS tests.
+// Everything is created in memory with ECDSA P-256 keys and needs no network access.
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"time"
+
+	"myproject/util"
+)
+
+const (
+	// DefaultValidity is how long generated certificates are valid
+	DefaultValidity = 24 * time.Hour
+
+	// clockSkew backdates certificates so nodes with a slightly late clock accept them
+	clockSkew = time.Hour
+)
+
+// CA is a certificate authority that signs server, client and intermediate CA certificates
+type CA struct {
+	Certificate *x509.Certificate
+	CertPEM     []byte
+	KeyPEM      []byte
+	key         *ecdsa.PrivateKey
+	parent      *CA // Issuer of an intermediate CA, nil for a root
+}
+
+// KeyPair is a certificate with its private key, both PEM encoded
+type KeyPair struct {
+	Certificate *x509.Certificate
+	CertPEM     []byte // The leaf certificate only, see ChainPEM
+	KeyPEM      []byte
+	CA          *CA // Issuer of the certificate
+}
+
+// NewCA creates a self-signed root CA
+func NewCA(commonName string) (*CA, error) {
+	return newCA(commonName, nil)
+}
+
+// NewIntermediateCA creates a CA signed by ca, so that servers present a chain longer than the leaf
+func (ca *CA) NewIntermediateCA(commonName string) (*CA, error) {
+	return newCA(commonName, ca)
+}
+
+func newCA(commonName string, parent *CA) (*CA, error) {
+	template, err := newTemplate(commonName)
+	if err != nil {
+		return nil, err
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+
+	cert, certPEM, key, keyPEM, err := sign(template, parent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CA %s: %v", commonName, err)
+	}
+	return &CA{Certificate: cert, CertPEM: certPEM, KeyPEM: keyPEM, key: key, parent: parent}, nil
+}
+
+// NewServerCert issues a server certificate valid for the hosts, which can be DNS names (wildcards
+// such as "*.apps.example.com" included) or IP addresses. The first host is used as common name
+// when commonName is empty.
+func (ca *CA) NewServerCert(commonName string, hosts ...string) (*KeyPair, error) {
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("a server certificate needs at least one host")
+	}
+	if commonName == "" {
+		commonName = hosts[0]
+	}
+	template, err := newTemplate(commonName)
+	if err != nil {
+		return nil, err
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	return ca.issue(template)
+}
+
+// NewClientCert issues a client certificate for mutual TLS, the organizations usually map to groups
+func (ca *CA) NewClientCert(commonName string, organizations ...string) (*KeyPair, error) {
+	template, err := newTemplate(commonName)
+	if err != nil {
+		return nil, err
+	}
+	template.Subject.Organization = organizations
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	return ca.issue(template)
+}
+
+func (ca *CA) issue(template *x509.Certificate) (*KeyPair, error) {
+	cert, certPEM, _, keyPEM, err := sign(template, ca)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue certificate %s: %v", template.Subject.CommonName, err)
+	}
+	util.LogDebug("Issued certificate %s (DNS %v, IP %v) signed by %s", template.Subject.CommonName, template.DNSNames, template.IPAddresses, ca.Certificate.Subject.CommonName)
+	return &KeyPair{Certificate: cert, CertPEM: certPEM, KeyPEM: keyPEM, CA: ca}, nil
+}
+
+// Root returns the self-signed CA at the top of the chain
+func (ca *CA) Root() *CA {
+	for ca.parent != nil {
+		ca = ca.parent
+	}
+	return ca
+}
+
+// Pool returns a pool trusting the root of the CA, for verifying chains or configuring clients
+func (ca *CA) Pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Root().Certificate)
+	return pool
+}
+
+// VerifyServer checks that a presented chain (leaf first, as returned by util.FetchServedCertificates)
+// leads to the root of the CA and that the leaf is valid for serverName
+func (ca *CA) VerifyServer(chain []*x509.Certificate, serverName string) error {
+	return util.VerifyServedCertificates(chain, ca.Pool(), serverName)
+}
+
+// VerifyClient checks that a client certificate chain leads to the root of the CA and allows client authentication
+func (ca *CA) VerifyClient(chain []*x509.Certificate) error {
+	if len(chain) == 0 {
+		return fmt.Errorf("no certificate to verify")
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		Roots:         ca.Pool(),
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		return fmt.Errorf("client certificate %s is not valid: %v", chain[0].Subject, err)
+	}
+	return nil
+}
+
+// IntermediatesPEM returns the intermediate CAs between the CA and its root, the CA first.
+// It is empty for a root CA.
+func (ca *CA) IntermediatesPEM() []byte {
+	var chain []byte
+	for current := ca; current.parent != nil; current = current.parent {
+		chain = append(chain, current.CertPEM...)
+	}
+	return chain
+}
+
+// ChainPEM returns the leaf followed by the intermediate CAs, which is what a server presents
+func (kp *KeyPair) ChainPEM() []byte {
+	return append(append([]byte{}, kp.CertPEM...), kp.CA.IntermediatesPEM()...)
+}
+
+// TLSCertificate returns the key pair with its chain for a crypto/tls server or client
+func (kp *KeyPair) TLSCertificate() (tls.Certificate, error) {
+	return tls.X509KeyPair(kp.ChainPEM(), kp.KeyPEM)
+}
+
+// newTemplate returns the fields shared by every generated certificate
+func newTemplate(commonName string) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate a serial number: %v", err)
+	}
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    now.Add(-clockSkew),
+		NotAfter:     now.Add(DefaultValidity),
+	}, nil
+}
+
+// sign generates a key for the template and signs it with the issuer, or self-signs it when issuer is nil
+func sign(template *x509.Certificate, issuer *CA) (*x509.Certificate, []byte, *ecdsa.PrivateKey, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to generate key: %v", err)
+	}
+
+	parent, signer := template, key
+	if issuer != nil {
+		parent, signer = issuer.Certificate, issuer.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return cert, certPEM, key, keyPEM, nil
+}
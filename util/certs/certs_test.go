@@ -0,0 +1,143 @@
+package certs_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+
+	"myproject/util"
+	"myproject/util/certs"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("Certificate authority", func() {
+	var ca *certs.CA
+
+	BeforeEach(func() {
+		var err error
+		ca, err = certs.NewCA("test-root")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should issue server certificates for DNS names, wildcards and IPs", func() {
+		kp, err := ca.NewServerCert("", "*.apps.example.com", "web.example.com", "127.0.0.1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(kp.Certificate.Subject.CommonName).To(Equal("*.apps.example.com"))
+		Expect(kp.Certificate.DNSNames).To(ConsistOf("*.apps.example.com", "web.example.com"))
+		Expect(kp.Certificate.IPAddresses).To(HaveLen(1))
+
+		chain := []*x509.Certificate{kp.Certificate}
+		Expect(ca.VerifyServer(chain, "shop.apps.example.com")).To(Succeed())
+		Expect(ca.VerifyServer(chain, "127.0.0.1")).To(Succeed())
+		Expect(ca.VerifyServer(chain, "other.example.org")).To(MatchError(ContainSubstring("not valid for other.example.org")))
+		Expect(ca.VerifyClient(chain)).To(HaveOccurred(), "a server certificate can't authenticate clients")
+	})
+
+	It("should reject a chain from another CA", func() {
+		other, err := certs.NewCA("other-root")
+		Expect(err).ToNot(HaveOccurred())
+		kp, err := other.NewServerCert("", "web.example.com")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ca.VerifyServer([]*x509.Certificate{kp.Certificate}, "web.example.com")).To(MatchError(ContainSubstring("unknown authority")))
+	})
+
+	It("should issue client certificates", func() {
+		kp, err := ca.NewClientCert("alice", "developers")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(kp.Certificate.Subject.Organization).To(Equal([]string{"developers"}))
+		Expect(ca.VerifyClient([]*x509.Certificate{kp.Certificate})).To(Succeed())
+	})
+
+	It("should serve the chain through an intermediate CA over TLS", func() {
+		intermediate, err := ca.NewIntermediateCA("test-intermediate")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(intermediate.Root()).To(Equal(ca))
+
+		kp, err := intermediate.NewServerCert("", "web.example.com")
+		Expect(err).ToNot(HaveOccurred())
+		tlsCert, err := kp.TLSCertificate()
+		Expect(err).ToNot(HaveOccurred())
+
+		server := httptest.NewUnstartedServer(http.NotFoundHandler())
+		server.TLS = &tls.Config{Certificates: []tls.Certificate{tlsCert}}
+		server.StartTLS()
+		DeferCleanup(server.Close)
+
+		chain, err := util.FetchServedCertificates(context.Background(), server.Listener.Addr().String(), "web.example.com")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(chain).To(HaveLen(2))
+		Expect(ca.VerifyServer(chain, "web.example.com")).To(Succeed())
+		Expect(ca.VerifyServer(chain[:1], "web.example.com")).To(HaveOccurred(), "the leaf alone does not lead to the root")
+	})
+
+	It("should require a host for server certificates", func() {
+		_, err := ca.NewServerCert("web")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Certificate storage", func() {
+	var (
+		ca        *certs.CA
+		clientset *fake.Clientset
+	)
+
+	BeforeEach(func() {
+		var err error
+		ca, err = certs.NewCA("test-root")
+		Expect(err).ToNot(HaveOccurred())
+		clientset = fake.NewSimpleClientset(&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "ns"}})
+	})
+
+	It("should issue a certificate for the service DNS names", func() {
+		names, err := certs.ServiceDNSNames(clientset, "ns", "web")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(names).To(Equal([]string{"web.ns.svc.cluster.local", "web.ns.svc", "web.ns", "web"}))
+
+		kp, err := ca.NewServerCert("", names...)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ca.VerifyServer([]*x509.Certificate{kp.Certificate}, "web.ns.svc")).To(Succeed())
+	})
+
+	It("should store a key pair as a kubernetes.io/tls Secret", func() {
+		intermediate, err := ca.NewIntermediateCA("test-intermediate")
+		Expect(err).ToNot(HaveOccurred())
+		kp, err := intermediate.NewServerCert("", "web.example.com")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = certs.CreateTLSSecret(clientset, "ns", "web-tls", kp, map[string]string{"team": "networking"})
+		Expect(err).ToNot(HaveOccurred())
+
+		secret, err := clientset.CoreV1().Secrets("ns").Get(context.Background(), "web-tls", metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(secret.Type).To(Equal(corev1.SecretTypeTLS))
+		Expect(secret.Labels).To(HaveKeyWithValue("managed", "openshift-testing"))
+		Expect(secret.Labels).To(HaveKeyWithValue("team", "networking"))
+		Expect(secret.Data[corev1.TLSCertKey]).To(Equal(append(append([]byte{}, kp.CertPEM...), intermediate.CertPEM...)))
+		Expect(secret.Data[certs.CASecretKey]).To(Equal(ca.CertPEM))
+
+		_, err = tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should fill valid route options", func() {
+		kp, err := ca.NewServerCert("", "web.apps.example.com")
+		Expect(err).ToNot(HaveOccurred())
+
+		options := kp.RouteOptions(routev1.TLSTerminationEdge)
+		Expect(options.Validate()).To(Succeed())
+		Expect(options.CACertificate).To(Equal(string(ca.CertPEM)))
+
+		pool, err := util.CertPoolFromPEM(options.CACertificate)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(util.VerifyServedCertificates([]*x509.Certificate{kp.Certificate}, pool, "web.apps.example.com")).To(Succeed())
+	})
+})
+
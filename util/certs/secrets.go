@@ -0,0 +1,71 @@
+package certs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"myproject/consts"
+	"myproject/util"
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CASecretKey is the key of the root CA in the Secrets built by NewTLSSecret, as used by cert-manager
+const CASecretKey = "ca.crt"
+
+// NewTLSSecret returns a kubernetes.io/tls Secret with the chain in tls.crt, the key in tls.key and
+// the root CA in ca.crt, labelled with the default labels and the given ones
+func NewTLSSecret(namespace, name string, kp *KeyPair, labels map[string]string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    util.MergeLabels(consts.DefaultLabels, labels),
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       kp.ChainPEM(),
+			corev1.TLSPrivateKeyKey: kp.KeyPEM,
+			CASecretKey:             kp.CA.Root().CertPEM,
+		},
+	}
+}
+
+// CreateTLSSecret stores the key pair in a kubernetes.io/tls Secret, see NewTLSSecret
+func CreateTLSSecret(clientset kubernetes.Interface, namespace, name string, kp *KeyPair, labels map[string]string) (*corev1.Secret, error) {
+	secret, err := clientset.CoreV1().Secrets(namespace).Create(context.TODO(), NewTLSSecret(namespace, name, kp, labels), metav1.CreateOptions{})
+	if err != nil {
+		util.LogError("Failed to create TLS secret %s/%s: %v", namespace, name, err)
+		return nil, fmt.Errorf("failed to create TLS secret %s/%s: %v", namespace, name, err)
+	}
+
+	util.LogInfo("Successfully created TLS secret %s/%s for %s", namespace, name, kp.Certificate.Subject.CommonName)
+	return secret, nil
+}
+
+// ServiceDNSNames returns the names a service is reached at from inside the cluster, from the fully
+// qualified name returned by util.GetServiceDNSName down to the bare service name
+func ServiceDNSNames(clientset kubernetes.Interface, namespace, serviceName string) ([]string, error) {
+	fqdn, err := util.GetServiceDNSName(clientset, namespace, serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceAndNamespace := strings.TrimSuffix(fqdn, ".svc.cluster.local")
+	names := []string{fqdn, serviceAndNamespace + ".svc", serviceAndNamespace, serviceName}
+	return names, nil
+}
+
+// RouteOptions returns route options serving the key pair, with the intermediates and root as CA.
+// Use it for edge and reencrypt routes, passthrough routes take the certificate from the pod.
+func (kp *KeyPair) RouteOptions(termination routev1.TLSTerminationType) util.RouteOptions {
+	return util.RouteOptions{
+		Termination:   termination,
+		Certificate:   string(kp.CertPEM),
+		Key:           string(kp.KeyPEM),
+		CACertificate: string(append(kp.CA.IntermediatesPEM(), kp.CA.Root().CertPEM...)),
+	}
+}
@@ -0,0 +1,299 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DefaultMatrixConcurrency bounds how many probes of a connectivity matrix run at the same time
+const DefaultMatrixConcurrency = 10
+
+// Endpoint is a pod or VM taking part in a connectivity matrix
+type Endpoint struct {
+	Name      string                 // Unique name shown in the grid, e.g. "x/server"
+	Namespace string                 // Namespace of the pod or VM
+	IP        string                 // Address probed when the endpoint is a destination
+	Labels    map[string]string      // Labels of the pod (or of the VM's launcher pod)
+	Ports     []corev1.ContainerPort // Ports the endpoint listens on, used to resolve named ports when simulating policies
+	Executor  ProbeExecutor          // Runs probes when the endpoint is a source, nil if it can't be one
+}
+
+// Listens reports whether one of the endpoint's ports is the given port, which the policy simulator
+// requires of every reachable destination
+func (e Endpoint) Listens(port MatrixPort) bool {
+	protocol := port.Protocol
+	if protocol == "" {
+		protocol = corev1.ProtocolTCP
+	}
+	for _, p := range e.Ports {
+		portProtocol := p.Protocol
+		if portProtocol == "" {
+			portProtocol = corev1.ProtocolTCP
+		}
+		if int(p.ContainerPort) == port.Port && portProtocol == protocol {
+			return true
+		}
+	}
+	return false
+}
+
+// MatrixPort is a port probed on every destination
+type MatrixPort struct {
+	Port     int
+	Protocol corev1.Protocol // TCP or UDP (the destination must run an echo server), defaults to TCP
+}
+
+func (p MatrixPort) String() string {
+	protocol := p.Protocol
+	if protocol == "" {
+		protocol = corev1.ProtocolTCP
+	}
+	return fmt.Sprintf("%d/%s", p.Port, protocol)
+}
+
+// Prober runs a single probe from one endpoint to another. It is an interface so the matrix
+// can be tested without a cluster.
+type Prober interface {
+	Probe(ctx context.Context, from, to Endpoint, port MatrixPort) (ProbeResult, error)
+}
+
+// ExecutorProber runs TCP or UDP probes through the executor of the source endpoint
+type ExecutorProber struct{}
+
+func (ExecutorProber) Probe(ctx context.Context, from, to Endpoint, port MatrixPort) (ProbeResult, error) {
+	if from.Executor == nil {
+		return ProbeResult{}, fmt.Errorf("endpoint %s has no executor to probe from", from.Name)
+	}
+
+	var probe Probe
+	switch port.Protocol {
+	case "", corev1.ProtocolTCP:
+		probe = TCPProbe{Host: to.IP, Port: port.Port}
+	case corev1.ProtocolUDP:
+		probe = UDPProbe{Host: to.IP, Port: port.Port}
+	default:
+		return ProbeResult{}, fmt.Errorf("unsupported protocol for probes: %s", port.Protocol)
+	}
+	return RunProbe(ctx, from.Executor, probe)
+}
+
+// Reachability is a truth table of which source reaches which destination
+type Reachability struct {
+	names   []string
+	table   map[string]map[string]bool
+	unknown []string // Names passed to Expect that are not in the table, reported by Err
+}
+
+// NewReachability creates a table over the endpoint names with every pair set to the default
+func NewReachability(names []string, defaultValue bool) *Reachability {
+	r := &Reachability{names: append([]string{}, names...), table: map[string]map[string]bool{}}
+	for _, from := range names {
+		r.table[from] = map[string]bool{}
+		for _, to := range names {
+			r.table[from][to] = defaultValue
+		}
+	}
+	return r
+}
+
+// Names returns the endpoint names in the order of the grid rows and columns
+func (r *Reachability) Names() []string {
+	return append([]string{}, r.names...)
+}
+
+// Expect sets whether from reaches to. Names that are not in the table are ignored and
+// reported by Err, so ProbeMatrix fails instead of checking a table with a typo.
+func (r *Reachability) Expect(from, to string, reachable bool) {
+	if !r.has(from) || !r.has(to) {
+		return
+	}
+	r.table[from][to] = reachable
+}
+
+// ExpectAllIngress sets whether every source reaches the destination, see Expect for unknown names
+func (r *Reachability) ExpectAllIngress(to string, reachable bool) {
+	for _, from := range r.names {
+		r.Expect(from, to, reachable)
+	}
+}
+
+// ExpectAllEgress sets whether the source reaches every destination, see Expect for unknown names
+func (r *Reachability) ExpectAllEgress(from string, reachable bool) {
+	for _, to := range r.names {
+		r.Expect(from, to, reachable)
+	}
+}
+
+// Get returns whether from reaches to
+func (r *Reachability) Get(from, to string) bool {
+	return r.table[from][to]
+}
+
+// Grid renders the table with "." for reachable and "X" for blocked
+func (r *Reachability) Grid() string {
+	return renderGrid(r.names, func(from, to string) string {
+		if r.Get(from, to) {
+			return "."
+		}
+		return "X"
+	})
+}
+
+// Err reports the names passed to Expect that are not in the table
+func (r *Reachability) Err() error {
+	if len(r.unknown) == 0 {
+		return nil
+	}
+	return fmt.Errorf("unknown endpoints in reachability table: %s", strings.Join(r.unknown, ", "))
+}
+
+// has reports whether the name is in the table and records it as unknown otherwise
+func (r *Reachability) has(name string) bool {
+	if _, ok := r.table[name]; ok {
+		return true
+	}
+	if !containsString(r.unknown, name) {
+		r.unknown = append(r.unknown, name)
+	}
+	return false
+}
+
+// MatrixResult holds what a connectivity matrix observed for one port
+type MatrixResult struct {
+	Port     MatrixPort
+	Expected *Reachability
+	Observed *Reachability
+	Results  map[string]map[string]ProbeResult // Probe results by source and destination
+	Errors   map[string]map[string]error       // Probes that could not run, by source and destination
+}
+
+// Mismatches lists the pairs whose observed reachability differs from the expectation or whose probe failed
+func (m *MatrixResult) Mismatches() []string {
+	var mismatches []string
+	for _, from := range m.Expected.names {
+		for _, to := range m.Expected.names {
+			if err := m.Errors[from][to]; err != nil {
+				mismatches = append(mismatches, fmt.Sprintf("%s -> %s: probe failed: %v", from, to, err))
+				continue
+			}
+			expected, observed := m.Expected.Get(from, to), m.Observed.Get(from, to)
+			if expected != observed {
+				mismatches = append(mismatches, fmt.Sprintf("%s -> %s: expected reachable=%t, got %s", from, to, expected, m.Results[from][to]))
+			}
+		}
+	}
+	return mismatches
+}
+
+// Diff renders the expected and observed grids and a comparison grid where
+// "." is a match, "X" a mismatch and "E" a probe that could not run
+func (m *MatrixResult) Diff() string {
+	comparison := renderGrid(m.Expected.names, func(from, to string) string {
+		switch {
+		case m.Errors[from][to] != nil:
+			return "E"
+		case m.Expected.Get(from, to) != m.Observed.Get(from, to):
+			return "X"
+		default:
+			return "."
+		}
+	})
+	return fmt.Sprintf("Port %s\nExpected:\n%s\nObserved:\n%s\nComparison:\n%s", m.Port, m.Expected.Grid(), m.Observed.Grid(), comparison)
+}
+
+// ProbeMatrix probes every source to destination pair on the port concurrently and compares the
+// outcome with the expected table. Endpoints without an executor are only used as destinations.
+func ProbeMatrix(ctx context.Context, prober Prober, endpoints []Endpoint, port MatrixPort, expected *Reachability, concurrency int) (*MatrixResult, error) {
+	if err := expected.Err(); err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for _, endpoint := range endpoints {
+		if _, ok := expected.table[endpoint.Name]; !ok {
+			return nil, fmt.Errorf("endpoint %s is missing from the expected reachability", endpoint.Name)
+		}
+		seen[endpoint.Name] = true
+	}
+	if len(seen) != len(endpoints) || len(seen) != len(expected.names) {
+		return nil, fmt.Errorf("got %d endpoints, expected %d uniquely named endpoints matching the reachability table", len(endpoints), len(expected.names))
+	}
+	if concurrency <= 0 {
+		concurrency = DefaultMatrixConcurrency
+	}
+
+	result := &MatrixResult{
+		Port:     port,
+		Expected: expected,
+		Observed: NewReachability(expected.names, false),
+		Results:  map[string]map[string]ProbeResult{},
+		Errors:   map[string]map[string]error{},
+	}
+	for _, name := range expected.names {
+		result.Results[name] = map[string]ProbeResult{}
+		result.Errors[name] = map[string]error{}
+	}
+
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		semaphore = make(chan struct{}, concurrency)
+	)
+	for _, from := range endpoints {
+		if from.Executor == nil {
+			// Not a source, so the row keeps the expectation instead of reporting a mismatch
+			for _, to := range endpoints {
+				result.Observed.Expect(from.Name, to.Name, expected.Get(from.Name, to.Name))
+			}
+			continue
+		}
+		for _, to := range endpoints {
+			wg.Add(1)
+			go func(from, to Endpoint) {
+				defer wg.Done()
+				semaphore <- struct{}{}
+				defer func() { <-semaphore }()
+
+				probeResult, err := prober.Probe(ctx, from, to, port)
+
+				mu.Lock()
+				defer mu.Unlock()
+				result.Results[from.Name][to.Name] = probeResult
+				if err != nil {
+					result.Errors[from.Name][to.Name] = err
+					return
+				}
+				result.Observed.Expect(from.Name, to.Name, probeResult.Reachable)
+			}(from, to)
+		}
+	}
+	wg.Wait()
+
+	LogInfo("Connectivity matrix on port %s: %d mismatches", port, len(result.Mismatches()))
+	return result, nil
+}
+
+// renderGrid lays out a table with sources as rows and destinations as columns
+func renderGrid(names []string, cell func(from, to string) string) string {
+	var buffer bytes.Buffer
+	writer := tabwriter.NewWriter(&buffer, 0, 4, 1, ' ', 0)
+	fmt.Fprint(writer, "-")
+	for _, to := range names {
+		fmt.Fprintf(writer, "\t%s", to)
+	}
+	fmt.Fprintln(writer)
+	for _, from := range names {
+		fmt.Fprint(writer, from)
+		for _, to := range names {
+			fmt.Fprintf(writer, "\t%s", cell(from, to))
+		}
+		fmt.Fprintln(writer)
+	}
+	writer.Flush()
+	return buffer.String()
+}
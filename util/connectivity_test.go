@@ -0,0 +1,129 @@
+package util_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"myproject/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeProber answers from a table and records how many probes ran at the same time
+type fakeProber struct {
+	mu          sync.Mutex
+	reachable   map[string]bool // "from->to"
+	failing     map[string]bool
+	inFlight    int
+	maxInFlight int
+}
+
+func (p *fakeProber) Probe(ctx context.Context, from, to util.Endpoint, port util.MatrixPort) (util.ProbeResult, error) {
+	key := from.Name + "->" + to.Name
+	p.mu.Lock()
+	p.inFlight++
+	if p.inFlight > p.maxInFlight {
+		p.maxInFlight = p.inFlight
+	}
+	p.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.inFlight--
+	if p.failing[key] {
+		return util.ProbeResult{}, errors.New("exec failed")
+	}
+	if p.reachable[key] {
+		return util.ProbeResult{Probe: key, Reachable: true, ResolvedIP: to.IP}, nil
+	}
+	return util.ProbeResult{Probe: key, ErrorClass: util.ProbeErrorTimeout}, nil
+}
+
+var _ = Describe("ProbeMatrix", func() {
+	var (
+		names     = []string{"x/a", "x/b", "y/a"}
+		endpoints []util.Endpoint
+		port      = util.MatrixPort{Port: 80}
+	)
+
+	BeforeEach(func() {
+		endpoints = nil
+		for _, name := range names {
+			endpoints = append(endpoints, util.Endpoint{Name: name, IP: "10.0.0." + name[len(name)-1:], Executor: localProbeExecutor{}})
+		}
+	})
+
+	It("should probe every pair concurrently and match the expected table", func() {
+		prober := &fakeProber{reachable: map[string]bool{}}
+		expected := util.NewReachability(names, true)
+		expected.ExpectAllIngress("y/a", false)
+		for _, from := range names {
+			for _, to := range names {
+				prober.reachable[from+"->"+to] = to != "y/a"
+			}
+		}
+
+		result, err := util.ProbeMatrix(context.Background(), prober, endpoints, port, expected, 3)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Mismatches()).To(BeEmpty(), result.Diff())
+		Expect(prober.maxInFlight).To(BeNumerically(">", 1))
+		Expect(prober.maxInFlight).To(BeNumerically("<=", 3))
+	})
+
+	It("should report mismatches and failed probes in the grid diff", func() {
+		prober := &fakeProber{
+			reachable: map[string]bool{"x/a->x/b": true, "x/b->x/a": true},
+			failing:   map[string]bool{"y/a->x/a": true},
+		}
+		expected := util.NewReachability(names, false)
+		expected.Expect("x/a", "x/b", true)
+		expected.Expect("x/b", "x/a", false)
+
+		result, err := util.ProbeMatrix(context.Background(), prober, endpoints, port, expected, 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Mismatches()).To(ConsistOf(
+			ContainSubstring("x/b -> x/a: expected reachable=false"),
+			ContainSubstring("y/a -> x/a: probe failed: exec failed"),
+		))
+		Expect(result.Diff()).To(ContainSubstring("Comparison:\n" +
+			"-   x/a x/b y/a\n" +
+			"x/a .   .   .\n" +
+			"x/b X   .   .\n" +
+			"y/a E   .   .\n"))
+	})
+
+	It("should only use endpoints with an executor as sources", func() {
+		endpoints[2].Executor = nil
+		prober := &fakeProber{reachable: map[string]bool{}}
+		expected := util.NewReachability(names, false)
+		expected.Expect("y/a", "x/a", true)
+
+		result, err := util.ProbeMatrix(context.Background(), prober, endpoints, port, expected, 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Mismatches()).To(BeEmpty())
+		Expect(result.Results["y/a"]).To(BeEmpty())
+	})
+
+	It("should report expectations for names missing from the table instead of panicking", func() {
+		expected := util.NewReachability(names, true)
+		expected.Expect("x/a", "z/a", false)
+		expected.ExpectAllEgress("z/a", false)
+		Expect(expected.Err()).To(MatchError("unknown endpoints in reachability table: z/a"))
+
+		_, err := util.ProbeMatrix(context.Background(), &fakeProber{}, endpoints, port, expected, 0)
+		Expect(err).To(MatchError(ContainSubstring("z/a")))
+	})
+
+	It("should reject endpoints that don't match the table", func() {
+		_, err := util.ProbeMatrix(context.Background(), &fakeProber{}, endpoints[:2], port, util.NewReachability(names, true), 0)
+		Expect(err).To(HaveOccurred())
+
+		endpoints[1].Name = "z/a"
+		_, err = util.ProbeMatrix(context.Background(), &fakeProber{}, endpoints, port, util.NewReachability(names, true), 0)
+		Expect(err).To(MatchError(ContainSubstring("endpoint z/a is missing")))
+	})
+})
@@ -0,0 +1,232 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+// maxDiagnosticEvents limits how many of the most recent Events are attached to a wait failure.
+const maxDiagnosticEvents = 10
+
+// WaitDiagnostics describes the last observed state of an object a wait gave up on.
+type WaitDiagnostics struct {
+	Kind       string
+	Namespace  string
+	Name       string
+	Phase      string
+	Conditions []string // e.g. "Ready=False (ContainersNotReady: containers with unready status)"
+	Containers []string // e.g. "httpd: waiting ImagePullBackOff (Back-off pulling image)"
+	Events     []string // e.g. "Warning Failed: Failed to pull image"
+}
+
+// Summary returns a multi-line, human readable description of the diagnostics.
+func (d WaitDiagnostics) Summary() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Last observed state of %s %s/%s:\n", d.Kind, d.Namespace, d.Name)
+	if d.Phase == "" {
+		b.WriteString("  Phase: <object was never observed>\n")
+	} else {
+		fmt.Fprintf(&b, "  Phase: %s\n", d.Phase)
+	}
+	writeList := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "  %s:\n", title)
+		for _, item := range items {
+			fmt.Fprintf(&b, "    - %s\n", item)
+		}
+	}
+	writeList("Conditions", d.Conditions)
+	writeList("Containers", d.Containers)
+	writeList("Recent events", d.Events)
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// WaitError is returned by the wait helpers when they fail, wrapping the underlying error
+// (e.g. ErrWaitTimeout) together with the diagnostics of the waited object.
+type WaitError struct {
+	Err         error
+	Diagnostics WaitDiagnostics
+}
+
+func (e *WaitError) Error() string {
+	d := e.Diagnostics
+	msg := fmt.Sprintf("%v (last observed %s %s/%s", e.Err, d.Kind, d.Namespace, d.Name)
+	if d.Phase != "" {
+		msg += " phase=" + d.Phase
+	}
+	if len(d.Containers) > 0 {
+		msg += " containers=[" + strings.Join(d.Containers, "; ") + "]"
+	}
+	return msg + ")"
+}
+
+func (e *WaitError) Unwrap() error {
+	return e.Err
+}
+
+// DescribeWaitFailure returns the diagnostics summary carried by err, or the plain error text
+// when the error did not come from a wait helper.
+func DescribeWaitFailure(err error) string {
+	if err == nil {
+		return ""
+	}
+	var waitErr *WaitError
+	if errors.As(err, &waitErr) {
+		return waitErr.Diagnostics.Summary()
+	}
+	return err.Error()
+}
+
+// PodDiagnostics extracts the phase, conditions and container states of a pod.
+func PodDiagnostics(pod *corev1.Pod) WaitDiagnostics {
+	diag := WaitDiagnostics{
+		Kind:      "Pod",
+		Namespace: pod.Namespace,
+		Name:      pod.Name,
+		Phase:     string(pod.Status.Phase),
+	}
+
+	for _, condition := range pod.Status.Conditions {
+		diag.Conditions = append(diag.Conditions, formatCondition(string(condition.Type), string(condition.Status), condition.Reason, condition.Message))
+	}
+
+	statuses := append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...)
+	statuses = append(statuses, pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		switch {
+		case status.State.Waiting != nil:
+			diag.Containers = append(diag.Containers, formatContainerState(status.Name, "waiting", status.State.Waiting.Reason, status.State.Waiting.Message))
+		case status.State.Terminated != nil:
+			terminated := status.State.Terminated
+			diag.Containers = append(diag.Containers, formatContainerState(status.Name, fmt.Sprintf("terminated (exit code %d)", terminated.ExitCode), terminated.Reason, terminated.Message))
+		case status.State.Running != nil:
+			diag.Containers = append(diag.Containers, fmt.Sprintf("%s: running (restarts: %d)", status.Name, status.RestartCount))
+		}
+	}
+
+	return diag
+}
+
+// VMDiagnostics extracts the printable status and conditions of a KubeVirt VM.
+func VMDiagnostics(vm *kubevirtv1.VirtualMachine) WaitDiagnostics {
+	diag := WaitDiagnostics{
+		Kind:      "VirtualMachine",
+		Namespace: vm.Namespace,
+		Name:      vm.Name,
+		Phase:     string(vm.Status.PrintableStatus),
+	}
+
+	for _, condition := range vm.Status.Conditions {
+		diag.Conditions = append(diag.Conditions, formatCondition(string(condition.Type), string(condition.Status), condition.Reason, condition.Message))
+	}
+
+	return diag
+}
+
+// CollectEvents returns the most recent Events recorded for the named object, newest last.
+// Kinds restricts the involved object kinds, an empty list matches any kind.
+func CollectEvents(ctx context.Context, clientset kubernetes.Interface, namespace, name string, kinds ...string) ([]string, error) {
+	eventList, err := clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: "involvedObject.name=" + name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events for %s: %v", name, err)
+	}
+
+	// Field selectors are not honoured everywhere (e.g. fake clientsets), so filter again here
+	var events []corev1.Event
+	for _, event := range eventList.Items {
+		if event.InvolvedObject.Name != name {
+			continue
+		}
+		if len(kinds) > 0 && !containsString(kinds, event.InvolvedObject.Kind) {
+			continue
+		}
+		events = append(events, event)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return eventTime(events[i]).Before(eventTime(events[j]))
+	})
+	if len(events) > maxDiagnosticEvents {
+		events = events[len(events)-maxDiagnosticEvents:]
+	}
+
+	var result []string
+	for _, event := range events {
+		line := fmt.Sprintf("%s %s: %s", event.Type, event.Reason, strings.TrimSpace(event.Message))
+		if event.Count > 1 {
+			line += fmt.Sprintf(" (x%d)", event.Count)
+		}
+		result = append(result, line)
+	}
+	return result, nil
+}
+
+// newWaitError wraps a failed wait with diagnostics and the recent Events of the object.
+// Events are fetched with a fresh context because the wait context is usually already expired.
+func newWaitError(err error, diag WaitDiagnostics, clientset kubernetes.Interface, kinds ...string) error {
+	if err == nil {
+		return nil
+	}
+
+	if clientset != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		events, eventsErr := CollectEvents(ctx, clientset, diag.Namespace, diag.Name, kinds...)
+		if eventsErr != nil {
+			LogWarn("Could not collect events for %s %s: %v", diag.Kind, diag.Name, eventsErr)
+		}
+		diag.Events = events
+	}
+
+	waitErr := &WaitError{Err: err, Diagnostics: diag}
+	LogError("%s", waitErr.Diagnostics.Summary())
+	return waitErr
+}
+
+func formatCondition(conditionType, status, reason, message string) string {
+	line := fmt.Sprintf("%s=%s", conditionType, status)
+	if reason != "" || message != "" {
+		line += fmt.Sprintf(" (%s: %s)", reason, message)
+	}
+	return line
+}
+
+func formatContainerState(name, state, reason, message string) string {
+	line := fmt.Sprintf("%s: %s %s", name, state, reason)
+	if message != "" {
+		line += fmt.Sprintf(" (%s)", message)
+	}
+	return strings.TrimSpace(line)
+}
+
+func eventTime(event corev1.Event) time.Time {
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp.Time
+	}
+	if !event.EventTime.IsZero() {
+		return event.EventTime.Time
+	}
+	return event.CreationTimestamp.Time
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
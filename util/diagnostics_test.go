@@ -0,0 +1,42 @@
+package util_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"myproject/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("Wait diagnostics", func() {
+	It("should describe the last observed pod and its events on timeout", func() {
+		pod := newTestPod("server", corev1.PodPending)
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+			Name:  "httpd",
+			State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating", Message: "pulling image"}},
+		}}
+		event := &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "server.1", Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "server", Namespace: "default"},
+			Type:           corev1.EventTypeWarning,
+			Reason:         "Failed",
+			Message:        "Failed to pull image \"httpd\"",
+		}
+		clientset := fake.NewSimpleClientset(pod, event)
+
+		err := util.WaitForPodState(context.Background(), clientset, "default", "server", util.ConstantBackoff(10*time.Millisecond, 100*time.Millisecond, 0), true)
+		Expect(errors.Is(err, util.ErrWaitTimeout)).To(BeTrue())
+
+		var waitErr *util.WaitError
+		Expect(errors.As(err, &waitErr)).To(BeTrue())
+		Expect(waitErr.Diagnostics.Phase).To(Equal("Pending"))
+		Expect(waitErr.Diagnostics.Containers).To(ConsistOf(ContainSubstring("ContainerCreating")))
+		Expect(waitErr.Diagnostics.Events).To(ConsistOf(ContainSubstring("Failed to pull image")))
+		Expect(util.DescribeWaitFailure(err)).To(ContainSubstring("Recent events"))
+	})
+})
@@ -0,0 +1,210 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"myproject/consts"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	EgressFirewallKind = "EgressFirewall"
+
+	// EgressFirewallName is the only name OVN-Kubernetes accepts, there is one EgressFirewall per namespace
+	EgressFirewallName = "default"
+
+	// egressFirewallApplied is the status OVN-Kubernetes reports once the rules are programmed
+	egressFirewallApplied = "EgressFirewall Rules applied"
+)
+
+var EgressFirewallGVR = schema.GroupVersionResource{Group: "k8s.ovn.org", Version: "v1", Resource: "egressfirewalls"}
+
+// EgressFirewallAction is what a rule does with the traffic leaving the namespace
+type EgressFirewallAction string
+
+const (
+	EgressFirewallAllow EgressFirewallAction = "Allow"
+	EgressFirewallDeny  EgressFirewallAction = "Deny"
+)
+
+// EgressFirewallPort restricts a rule to a port, every port of the protocol if Port is 0
+type EgressFirewallPort struct {
+	Protocol string `json:"protocol"`
+	Port     int32  `json:"port,omitempty"`
+}
+
+// The structs below mirror the k8s.ovn.org/v1 API for the unstructured conversion
+type egressFirewallSpec struct {
+	Egress []egressFirewallRule `json:"egress"`
+}
+
+type egressFirewallRule struct {
+	Type  EgressFirewallAction      `json:"type"`
+	To    egressFirewallDestination `json:"to"`
+	Ports []EgressFirewallPort      `json:"ports,omitempty"`
+}
+
+type egressFirewallDestination struct {
+	CIDRSelector string `json:"cidrSelector,omitempty"`
+	DNSName      string `json:"dnsName,omitempty"`
+}
+
+// EgressFirewallBuilder builds the EgressFirewall of a namespace as an unstructured object.
+// Rules are evaluated in order and traffic matching no rule is allowed.
+type EgressFirewallBuilder struct {
+	namespace string
+	labels    map[string]string
+	spec      egressFirewallSpec
+	problems  []string
+}
+
+// NewEgressFirewallBuilder starts the EgressFirewall of the namespace with the default labels
+func NewEgressFirewallBuilder(namespace string) *EgressFirewallBuilder {
+	return &EgressFirewallBuilder{namespace: namespace, labels: MergeLabels(consts.DefaultLabels)}
+}
+
+// WithLabels adds labels to the EgressFirewall object
+func (b *EgressFirewallBuilder) WithLabels(labels map[string]string) *EgressFirewallBuilder {
+	b.labels = MergeLabels(b.labels, labels)
+	return b
+}
+
+// AllowCIDR allows traffic to the CIDR, optionally only on the ports
+func (b *EgressFirewallBuilder) AllowCIDR(cidr string, ports ...EgressFirewallPort) *EgressFirewallBuilder {
+	return b.cidrRule(EgressFirewallAllow, cidr, ports)
+}
+
+// DenyCIDR denies traffic to the CIDR, optionally only on the ports. "0.0.0.0/0" denies everything not allowed before.
+func (b *EgressFirewallBuilder) DenyCIDR(cidr string, ports ...EgressFirewallPort) *EgressFirewallBuilder {
+	return b.cidrRule(EgressFirewallDeny, cidr, ports)
+}
+
+// AllowDNSName allows traffic to the addresses the name resolves to. Wildcards such as "*.example.com" are accepted.
+func (b *EgressFirewallBuilder) AllowDNSName(name string, ports ...EgressFirewallPort) *EgressFirewallBuilder {
+	return b.dnsRule(EgressFirewallAllow, name, ports)
+}
+
+// DenyDNSName denies traffic to the addresses the name resolves to
+func (b *EgressFirewallBuilder) DenyDNSName(name string, ports ...EgressFirewallPort) *EgressFirewallBuilder {
+	return b.dnsRule(EgressFirewallDeny, name, ports)
+}
+
+func (b *EgressFirewallBuilder) cidrRule(action EgressFirewallAction, cidr string, ports []EgressFirewallPort) *EgressFirewallBuilder {
+	if _, _, err := net.ParseCIDR(cidr); err != nil {
+		b.problems = append(b.problems, fmt.Sprintf("invalid cidrSelector %q", cidr))
+	}
+	return b.addRule(action, egressFirewallDestination{CIDRSelector: cidr}, ports)
+}
+
+func (b *EgressFirewallBuilder) dnsRule(action EgressFirewallAction, name string, ports []EgressFirewallPort) *EgressFirewallBuilder {
+	for _, msg := range validation.IsDNS1123Subdomain(strings.TrimPrefix(name, "*.")) {
+		b.problems = append(b.problems, fmt.Sprintf("invalid dnsName %q: %s", name, msg))
+	}
+	return b.addRule(action, egressFirewallDestination{DNSName: name}, ports)
+}
+
+func (b *EgressFirewallBuilder) addRule(action EgressFirewallAction, to egressFirewallDestination, ports []EgressFirewallPort) *EgressFirewallBuilder {
+	normalized := make([]EgressFirewallPort, 0, len(ports))
+	for _, port := range ports {
+		protocol, err := ParseProtocol(port.Protocol)
+		if err != nil {
+			b.problems = append(b.problems, err.Error())
+			continue
+		}
+		if port.Port < 0 || port.Port > 65535 {
+			b.problems = append(b.problems, fmt.Sprintf("port %d is outside 1-65535", port.Port))
+			continue
+		}
+		normalized = append(normalized, EgressFirewallPort{Protocol: string(protocol), Port: port.Port})
+	}
+	b.spec.Egress = append(b.spec.Egress, egressFirewallRule{Type: action, To: to, Ports: normalized})
+	return b
+}
+
+// Build validates the EgressFirewall and returns it as a new unstructured object
+func (b *EgressFirewallBuilder) Build() (*unstructured.Unstructured, error) {
+	problems := append([]string{}, b.problems...)
+	if b.namespace == "" {
+		problems = append(problems, "namespace must not be empty")
+	}
+	if len(b.spec.Egress) == 0 {
+		problems = append(problems, "the EgressFirewall has no rules")
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid EgressFirewall in %s: %s", b.namespace, strings.Join(problems, "; "))
+	}
+
+	spec, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&b.spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert EgressFirewall in %s: %v", b.namespace, err)
+	}
+	firewall := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	firewall.SetAPIVersion(EgressFirewallGVR.GroupVersion().String())
+	firewall.SetKind(EgressFirewallKind)
+	firewall.SetNamespace(b.namespace)
+	firewall.SetName(EgressFirewallName)
+	firewall.SetLabels(MergeLabels(b.labels))
+	return firewall, nil
+}
+
+// CreateEgressFirewall creates an EgressFirewall built with NewEgressFirewallBuilder
+func CreateEgressFirewall(client dynamic.Interface, firewall *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	created, err := client.Resource(EgressFirewallGVR).Namespace(firewall.GetNamespace()).Create(context.TODO(), firewall, metav1.CreateOptions{})
+	if err != nil {
+		LogError("Failed to create EgressFirewall in %s: %v", firewall.GetNamespace(), err)
+		return nil, fmt.Errorf("failed to create EgressFirewall in %s: %w", firewall.GetNamespace(), err)
+	}
+
+	LogInfo("Successfully created EgressFirewall in namespace %s", firewall.GetNamespace())
+	return created, nil
+}
+
+// GetEgressFirewall fetches the EgressFirewall of a namespace
+func GetEgressFirewall(ctx context.Context, client dynamic.Interface, namespace string) (*unstructured.Unstructured, error) {
+	return client.Resource(EgressFirewallGVR).Namespace(namespace).Get(ctx, EgressFirewallName, metav1.GetOptions{})
+}
+
+// DeleteEgressFirewall deletes the EgressFirewall of a namespace
+func DeleteEgressFirewall(client dynamic.Interface, namespace string) error {
+	err := client.Resource(EgressFirewallGVR).Namespace(namespace).Delete(context.TODO(), EgressFirewallName, metav1.DeleteOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to delete EgressFirewall in %s: %w", namespace, err)
+	}
+	return nil
+}
+
+// WaitForEgressFirewallReady waits until OVN-Kubernetes reports the rules of the namespace as applied.
+// A failure status (e.g. an unsupported rule) is reported with its messages while the wait goes on.
+func WaitForEgressFirewallReady(ctx context.Context, client dynamic.Interface, namespace string, backoff Backoff) error {
+	return WaitForWithContext(ctx, backoff, func(ctx context.Context) (bool, error) {
+		firewall, err := GetEgressFirewall(ctx, client, namespace)
+		if apierrors.IsNotFound(err) {
+			return false, fmt.Errorf("EgressFirewall in %s does not exist", namespace)
+		}
+		if err != nil {
+			return false, err
+		}
+
+		status, _, _ := unstructured.NestedString(firewall.Object, "status", "status")
+		switch {
+		case status == egressFirewallApplied:
+			LogInfo("EgressFirewall in %s is applied.", namespace)
+			return true, nil
+		case status == "":
+			LogInfo("Waiting for EgressFirewall in %s to report its status...", namespace)
+			return false, nil
+		default:
+			messages, _, _ := unstructured.NestedStringSlice(firewall.Object, "status", "messages")
+			return false, fmt.Errorf("EgressFirewall in %s is not applied: %s %v", namespace, status, messages)
+		}
+	})
+}
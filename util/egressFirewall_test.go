@@ -0,0 +1,93 @@
+package util_test
+
+import (
+	"context"
+	"time"
+
+	"myproject/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+var _ = Describe("EgressFirewallBuilder", func() {
+	It("should build ordered rules", func() {
+		firewall, err := util.NewEgressFirewallBuilder("ns1").
+			AllowDNSName("*.example.com", util.EgressFirewallPort{Protocol: "tcp", Port: 443}).
+			AllowCIDR("192.0.2.0/24").
+			DenyCIDR("0.0.0.0/0").
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(firewall.GetAPIVersion()).To(Equal("k8s.ovn.org/v1"))
+		Expect(firewall.GetKind()).To(Equal("EgressFirewall"))
+		Expect(firewall.GetName()).To(Equal("default"))
+		Expect(firewall.GetNamespace()).To(Equal("ns1"))
+
+		rules, _, _ := unstructured.NestedSlice(firewall.Object, "spec", "egress")
+		Expect(rules).To(Equal([]interface{}{
+			map[string]interface{}{
+				"type":  "Allow",
+				"to":    map[string]interface{}{"dnsName": "*.example.com"},
+				"ports": []interface{}{map[string]interface{}{"protocol": "TCP", "port": int64(443)}},
+			},
+			map[string]interface{}{"type": "Allow", "to": map[string]interface{}{"cidrSelector": "192.0.2.0/24"}},
+			map[string]interface{}{"type": "Deny", "to": map[string]interface{}{"cidrSelector": "0.0.0.0/0"}},
+		}))
+	})
+
+	DescribeTable("reports invalid input",
+		func(builder *util.EgressFirewallBuilder, problem string) {
+			_, err := builder.Build()
+			Expect(err).To(MatchError(ContainSubstring(problem)))
+		},
+		Entry("no rules", util.NewEgressFirewallBuilder("ns1"), "has no rules"),
+		Entry("invalid CIDR", util.NewEgressFirewallBuilder("ns1").DenyCIDR("192.0.2.1"), `invalid cidrSelector "192.0.2.1"`),
+		Entry("invalid DNS name", util.NewEgressFirewallBuilder("ns1").AllowDNSName("not a name"), `invalid dnsName "not a name"`),
+		Entry("invalid protocol", util.NewEgressFirewallBuilder("ns1").AllowCIDR("192.0.2.0/24", util.EgressFirewallPort{Protocol: "ICMP"}), `unsupported protocol "ICMP"`),
+		Entry("missing namespace", util.NewEgressFirewallBuilder("").DenyCIDR("0.0.0.0/0"), "namespace must not be empty"),
+	)
+})
+
+var _ = Describe("WaitForEgressFirewallReady", func() {
+	var client *dynamicfake.FakeDynamicClient
+
+	setStatus := func(status string, messages ...interface{}) {
+		firewall, err := util.GetEgressFirewall(context.Background(), client, "ns1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(unstructured.SetNestedField(firewall.Object, status, "status", "status")).To(Succeed())
+		Expect(unstructured.SetNestedSlice(firewall.Object, messages, "status", "messages")).To(Succeed())
+		_, err = client.Resource(util.EgressFirewallGVR).Namespace("ns1").Update(context.Background(), firewall, metav1.UpdateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		client = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+		firewall, err := util.NewEgressFirewallBuilder("ns1").DenyCIDR("0.0.0.0/0").Build()
+		Expect(err).ToNot(HaveOccurred())
+		_, err = util.CreateEgressFirewall(client, firewall)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should succeed once the rules are applied", func() {
+		setStatus("EgressFirewall Rules applied")
+		err := util.WaitForEgressFirewallReady(context.Background(), client, "ns1", util.ConstantBackoff(10*time.Millisecond, time.Second, 0))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should report the messages of a failed EgressFirewall", func() {
+		setStatus("EgressFirewall Rules not correctly applied", "node1: unsupported dnsName")
+		err := util.WaitForEgressFirewallReady(context.Background(), client, "ns1", util.ConstantBackoff(10*time.Millisecond, 100*time.Millisecond, 0))
+		Expect(err).To(MatchError(util.ErrWaitTimeout))
+		Expect(err).To(MatchError(ContainSubstring("unsupported dnsName")))
+	})
+
+	It("should delete the EgressFirewall of the namespace", func() {
+		Expect(util.DeleteEgressFirewall(client, "ns1")).To(Succeed())
+		_, err := util.GetEgressFirewall(context.Background(), client, "ns1")
+		Expect(err).To(HaveOccurred())
+	})
+})
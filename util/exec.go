@@ -0,0 +1,142 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/util/exec"
+)
+
+// ExecOptions describes a command to run in a pod
+type ExecOptions struct {
+	Container string        // Defaults to the first container of the pod
+	Command   []string      // The command and its arguments, not run through a shell
+	Stdin     io.Reader     // Optional input for the command
+	Timeout   time.Duration // 0 means no timeout besides the context
+}
+
+// ExecResult holds the output of a command run in a pod
+type ExecResult struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// Succeeded reports whether the command exited with code 0
+func (r *ExecResult) Succeeded() bool {
+	return r.ExitCode == 0
+}
+
+// PodExecutorFactory returns the executor that streams an exec request to a pod
+type PodExecutorFactory func(namespace, podName string, options *corev1.PodExecOptions) (remotecommand.Executor, error)
+
+// NewPodExecutorFactory streams exec requests through the API server of the config.
+// WebSockets are used when the API server supports them, with a fallback to SPDY.
+func NewPodExecutorFactory(config *rest.Config, clientset kubernetes.Interface) PodExecutorFactory {
+	return func(namespace, podName string, options *corev1.PodExecOptions) (remotecommand.Executor, error) {
+		request := clientset.CoreV1().RESTClient().Post().
+			Resource("pods").
+			Namespace(namespace).
+			Name(podName).
+			SubResource("exec").
+			VersionedParams(options, scheme.ParameterCodec)
+		return newExecutor(config, request.URL())
+	}
+}
+
+// ExecInPod runs a command in a running pod and returns its stdout, stderr and exit code.
+// A command that ran but exited with a non-zero code is not an error, the code is in the result.
+// Errors are returned when the pod can't be used or the stream fails (including timeouts).
+func ExecInPod(ctx context.Context, config *rest.Config, clientset kubernetes.Interface, namespace, podName string, options ExecOptions) (*ExecResult, error) {
+	return ExecInPodWithFactory(ctx, NewPodExecutorFactory(config, clientset), clientset, namespace, podName, options)
+}
+
+// ExecInPodWithFactory is ExecInPod streaming through executors of the factory, e.g. a fake in unit tests
+func ExecInPodWithFactory(ctx context.Context, executorFactory PodExecutorFactory, clientset kubernetes.Interface, namespace, podName string, options ExecOptions) (*ExecResult, error) {
+	if len(options.Command) == 0 {
+		return nil, fmt.Errorf("no command given to run in pod %s/%s", namespace, podName)
+	}
+
+	pod, err := clientset.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		LogError("Failed to get pod %s for exec: %v", podName, err)
+		return nil, fmt.Errorf("failed to get pod %s/%s: %v", namespace, podName, err)
+	}
+	if pod.Status.Phase != corev1.PodRunning {
+		return nil, fmt.Errorf("cannot exec in pod %s/%s in phase %s", namespace, podName, pod.Status.Phase)
+	}
+
+	container := options.Container
+	if container == "" {
+		if len(pod.Spec.Containers) == 0 {
+			return nil, fmt.Errorf("pod %s/%s has no containers", namespace, podName)
+		}
+		container = pod.Spec.Containers[0].Name
+	}
+
+	executor, err := executorFactory(namespace, podName, &corev1.PodExecOptions{
+		Container: container,
+		Command:   options.Command,
+		Stdin:     options.Stdin != nil,
+		Stdout:    true,
+		Stderr:    true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create executor for pod %s/%s: %v", namespace, podName, err)
+	}
+
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	LogDebug("Running %q in %s/%s container %s", strings.Join(options.Command, " "), namespace, podName, container)
+	var stdout, stderr bytes.Buffer
+	err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:  options.Stdin,
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+
+	result := &ExecResult{Stdout: stdout.String(), Stderr: stderr.String()}
+	var exitErr exec.CodeExitError
+	switch {
+	case err == nil:
+		return result, nil
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitStatus()
+		return result, nil
+	case ctx.Err() != nil:
+		return result, fmt.Errorf("command %q in pod %s/%s did not finish: %w", strings.Join(options.Command, " "), namespace, podName, ctx.Err())
+	default:
+		LogError("Failed to exec in pod %s: %v", podName, err)
+		return result, fmt.Errorf("failed to exec in pod %s/%s: %v", namespace, podName, err)
+	}
+}
+
+// newExecutor prefers the WebSocket protocol and falls back to SPDY for API servers that don't upgrade
+func newExecutor(config *rest.Config, execURL *url.URL) (remotecommand.Executor, error) {
+	spdyExecutor, err := remotecommand.NewSPDYExecutor(config, "POST", execURL)
+	if err != nil {
+		return nil, err
+	}
+	websocketExecutor, err := remotecommand.NewWebSocketExecutor(config, "GET", execURL.String())
+	if err != nil {
+		return nil, err
+	}
+	return remotecommand.NewFallbackExecutor(websocketExecutor, spdyExecutor, httpstream.IsUpgradeFailure)
+}
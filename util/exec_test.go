@@ -0,0 +1,134 @@
+package util_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"myproject/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/util/exec"
+)
+
+// fakeExecutor plays a command run in a pod: it echoes stdin and writes the given output
+type fakeExecutor struct {
+	stdout, stderr string
+	err            error
+	block          bool // Wait for the context to end instead
+}
+
+func (e *fakeExecutor) Stream(options remotecommand.StreamOptions) error {
+	return e.StreamWithContext(context.Background(), options)
+}
+
+func (e *fakeExecutor) StreamWithContext(ctx context.Context, options remotecommand.StreamOptions) error {
+	if e.block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	if options.Stdin != nil {
+		if _, err := io.Copy(options.Stdout, options.Stdin); err != nil {
+			return err
+		}
+	}
+	fmt.Fprint(options.Stdout, e.stdout)
+	fmt.Fprint(options.Stderr, e.stderr)
+	return e.err
+}
+
+var _ = Describe("ExecInPod", func() {
+	run := func(clientset *fake.Clientset, command ...string) error {
+		_, err := util.ExecInPod(context.Background(), &rest.Config{}, clientset, "default", "client", util.ExecOptions{Command: command})
+		return err
+	}
+
+	It("should require a command", func() {
+		err := run(fake.NewSimpleClientset(newTestPod("client", corev1.PodRunning)))
+		Expect(err).To(MatchError(ContainSubstring("no command")))
+	})
+
+	It("should fail for a missing pod", func() {
+		err := run(fake.NewSimpleClientset(), "true")
+		Expect(err).To(MatchError(ContainSubstring("failed to get pod default/client")))
+	})
+
+	It("should refuse pods that are not running", func() {
+		err := run(fake.NewSimpleClientset(newTestPod("client", corev1.PodPending)), "true")
+		Expect(err).To(MatchError(ContainSubstring("in phase Pending")))
+	})
+
+	It("should require a container to run in", func() {
+		err := run(fake.NewSimpleClientset(newTestPod("client", corev1.PodRunning)), "true")
+		Expect(err).To(MatchError(ContainSubstring("has no containers")))
+	})
+
+	Context("with a running container", func() {
+		var (
+			clientset *fake.Clientset
+			requested *corev1.PodExecOptions
+		)
+
+		BeforeEach(func() {
+			pod := newTestPod("client", corev1.PodRunning)
+			pod.Spec.Containers = []corev1.Container{{Name: "client"}, {Name: "sidecar"}}
+			clientset = fake.NewSimpleClientset(pod)
+			requested = nil
+		})
+
+		execWith := func(executor *fakeExecutor, options util.ExecOptions) (*util.ExecResult, error) {
+			factory := func(namespace, podName string, execOptions *corev1.PodExecOptions) (remotecommand.Executor, error) {
+				Expect(namespace).To(Equal("default"))
+				Expect(podName).To(Equal("client"))
+				requested = execOptions
+				return executor, nil
+			}
+			return util.ExecInPodWithFactory(context.Background(), factory, clientset, "default", "client", options)
+		}
+
+		It("should return the output of the first container by default", func() {
+			result, err := execWith(&fakeExecutor{stdout: "hello\n", stderr: "warning\n"}, util.ExecOptions{Command: []string{"echo", "hello"}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Succeeded()).To(BeTrue())
+			Expect(result.Stdout).To(Equal("hello\n"))
+			Expect(result.Stderr).To(Equal("warning\n"))
+			Expect(requested.Container).To(Equal("client"))
+			Expect(requested.Command).To(Equal([]string{"echo", "hello"}))
+			Expect(requested.Stdin).To(BeFalse())
+		})
+
+		It("should stream stdin to the chosen container", func() {
+			result, err := execWith(&fakeExecutor{}, util.ExecOptions{Container: "sidecar", Command: []string{"cat"}, Stdin: strings.NewReader("input")})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Stdout).To(Equal("input"))
+			Expect(requested.Container).To(Equal("sidecar"))
+			Expect(requested.Stdin).To(BeTrue())
+		})
+
+		It("should return the exit code of a failed command", func() {
+			result, err := execWith(&fakeExecutor{stderr: "not found\n", err: exec.CodeExitError{Err: errors.New("command terminated with exit code 7"), Code: 7}}, util.ExecOptions{Command: []string{"curl", "http://nowhere"}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Succeeded()).To(BeFalse())
+			Expect(result.ExitCode).To(Equal(7))
+			Expect(result.Stderr).To(Equal("not found\n"))
+		})
+
+		It("should fail when the command outlives its timeout", func() {
+			_, err := execWith(&fakeExecutor{block: true}, util.ExecOptions{Command: []string{"sleep", "60"}, Timeout: 20 * time.Millisecond})
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+			Expect(err).To(MatchError(ContainSubstring("did not finish")))
+		})
+
+		It("should fail when the stream breaks", func() {
+			_, err := execWith(&fakeExecutor{err: errors.New("connection reset")}, util.ExecOptions{Command: []string{"true"}})
+			Expect(err).To(MatchError(ContainSubstring("failed to exec in pod default/client: connection reset")))
+		})
+	})
+})
@@ -0,0 +1,8 @@
+package util
+
+import "time"
+
+// BackoffDelay exposes Backoff.delay to the tests of the util_test package
+func BackoffDelay(b Backoff, attempt int) time.Duration {
+	return b.delay(attempt)
+}
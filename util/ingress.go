@@ -0,0 +1,262 @@
+package util
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"myproject/consts"
+	routeclientset "github.com/openshift/client-go/route/clientset/versioned"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ClusterIngressConfigGVR is the cluster wide ingress configuration holding the default apps domain
+var ClusterIngressConfigGVR = schema.GroupVersionResource{Group: "config.openshift.io", Version: "v1", Resource: "ingresses"}
+
+// IngressRule sends the requests for a host and path to a service
+type IngressRule struct {
+	Host        string         // Required on OpenShift, which only turns rules with a host into Routes
+	Path        string         // Defaults to "/"
+	PathType    netv1.PathType // Defaults to Prefix
+	ServiceName string
+	ServicePort interface{} // Port number (int) or name (string) of the service
+}
+
+// IngressOptions describes a networking.k8s.io/v1 Ingress
+type IngressOptions struct {
+	ClassName string // Empty uses the default IngressClass
+	Rules     []IngressRule
+	// TLS hosts are served over https. On OpenShift an empty SecretName gives an edge Route with the
+	// router's default certificate.
+	TLS         []netv1.IngressTLS
+	Annotations map[string]string // e.g. route.openshift.io/termination
+	Labels      map[string]string // Added to the default labels
+}
+
+// Validate checks the rules of the options
+func (o IngressOptions) Validate() error {
+	var problems []string
+	if len(o.Rules) == 0 {
+		problems = append(problems, "the ingress has no rules")
+	}
+	for i, rule := range o.Rules {
+		if rule.Host == "" {
+			problems = append(problems, fmt.Sprintf("rule %d has no host", i))
+		} else {
+			for _, msg := range hostProblems(rule.Host) {
+				problems = append(problems, fmt.Sprintf("invalid host %q: %s", rule.Host, msg))
+			}
+		}
+		if rule.Path != "" && !strings.HasPrefix(rule.Path, "/") {
+			problems = append(problems, fmt.Sprintf("path %q of rule %d must start with /", rule.Path, i))
+		}
+		if rule.ServiceName == "" {
+			problems = append(problems, fmt.Sprintf("rule %d has no service", i))
+		}
+		switch rule.ServicePort.(type) {
+		case int, string:
+		default:
+			problems = append(problems, fmt.Sprintf("unsupported type for the port of rule %d: %T", i, rule.ServicePort))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid ingress options: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// hostProblems checks the length of the host and every label of it, a leading "*" label is a wildcard
+func hostProblems(host string) []string {
+	var problems []string
+	if len(host) > validation.DNS1123SubdomainMaxLength {
+		problems = append(problems, validation.MaxLenError(validation.DNS1123SubdomainMaxLength))
+	}
+	for i, label := range strings.Split(host, ".") {
+		if i == 0 && label == "*" {
+			continue
+		}
+		for _, msg := range validation.IsDNS1123Label(label) {
+			problems = append(problems, fmt.Sprintf("label %q: %s", label, msg))
+		}
+	}
+	return problems
+}
+
+// HostLabel joins the parts with "-" into a DNS label. Labels longer than 63 characters are cut and
+// end with a hash of the full value, so they stay unique.
+func HostLabel(parts ...string) string {
+	label := strings.Join(parts, "-")
+	if len(label) <= validation.DNS1123LabelMaxLength {
+		return label
+	}
+	sum := sha256.Sum256([]byte(label))
+	suffix := hex.EncodeToString(sum[:])[:8]
+	return strings.TrimRight(label[:validation.DNS1123LabelMaxLength-len(suffix)-1], "-.") + "-" + suffix
+}
+
+// ingressRules groups the rules by host as the Ingress API expects
+func (o IngressOptions) ingressRules() []netv1.IngressRule {
+	var rules []netv1.IngressRule
+	byHost := map[string]int{}
+	for _, rule := range o.Rules {
+		path, pathType := rule.Path, rule.PathType
+		if path == "" {
+			path = "/"
+		}
+		if pathType == "" {
+			pathType = netv1.PathTypePrefix
+		}
+		backend := netv1.IngressServiceBackend{Name: rule.ServiceName}
+		switch port := rule.ServicePort.(type) {
+		case int:
+			backend.Port.Number = int32(port)
+		case string:
+			backend.Port.Name = port
+		}
+
+		i, found := byHost[rule.Host]
+		if !found {
+			i = len(rules)
+			byHost[rule.Host] = i
+			rules = append(rules, netv1.IngressRule{Host: rule.Host, IngressRuleValue: netv1.IngressRuleValue{HTTP: &netv1.HTTPIngressRuleValue{}}})
+		}
+		rules[i].HTTP.Paths = append(rules[i].HTTP.Paths, netv1.HTTPIngressPath{
+			Path:     path,
+			PathType: &pathType,
+			Backend:  netv1.IngressBackend{Service: &backend},
+		})
+	}
+	return rules
+}
+
+// CreateIngress creates an Ingress with host and path rules
+func CreateIngress(clientset kubernetes.Interface, namespace, ingressName string, options IngressOptions) (*netv1.Ingress, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	ingress := &netv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        ingressName,
+			Namespace:   namespace,
+			Labels:      MergeLabels(consts.DefaultLabels, options.Labels),
+			Annotations: options.Annotations,
+		},
+		Spec: netv1.IngressSpec{
+			Rules: options.ingressRules(),
+			TLS:   options.TLS,
+		},
+	}
+	if options.ClassName != "" {
+		ingress.Spec.IngressClassName = &options.ClassName
+	}
+
+	created, err := clientset.NetworkingV1().Ingresses(namespace).Create(context.TODO(), ingress, metav1.CreateOptions{})
+	if err != nil {
+		LogError("Failed to create Ingress %s: %v", ingressName, err)
+		return nil, fmt.Errorf("failed to create Ingress %s: %v", ingressName, err)
+	}
+
+	LogInfo("Successfully created Ingress %s", ingressName)
+	return created, nil
+}
+
+// WaitForIngressRoute waits until OpenShift has generated the Route serving the host of an Ingress and
+// a router admitted it. The admission's URL uses https when the Ingress lists the host under TLS.
+// Rejections end the wait with a *RouteRejectedError like WaitForRouteAdmitted.
+func WaitForIngressRoute(ctx context.Context, routeClient routeclientset.Interface, namespace, ingressName, host string, backoff Backoff) (*RouteAdmission, error) {
+	var admitted *RouteAdmission
+	err := WaitForWithContext(ctx, backoff, func(ctx context.Context) (bool, error) {
+		routes, err := routeClient.RouteV1().Routes(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return false, err
+		}
+
+		for i := range routes.Items {
+			route := &routes.Items[i]
+			if route.Spec.Host != host || !ownedByIngress(route.OwnerReferences, ingressName) {
+				continue
+			}
+			var done bool
+			admitted, done, err = routeAdmission(route, "")
+			if !done {
+				LogInfo("Waiting for route %s of Ingress %s to be admitted...", route.Name, ingressName)
+			}
+			return done, err
+		}
+		return false, fmt.Errorf("no Route generated for host %s of Ingress %s yet", host, ingressName)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return admitted, nil
+}
+
+// ownedByIngress reports whether the owner references point to the named Ingress
+func ownedByIngress(owners []metav1.OwnerReference, ingressName string) bool {
+	for _, owner := range owners {
+		if owner.Kind == "Ingress" && owner.Name == ingressName {
+			return true
+		}
+	}
+	return false
+}
+
+// WaitForIngressLoadBalancer waits until the Ingress reports the address it is reachable at, an IP or
+// a hostname such as the router's canonical hostname, and returns it
+func WaitForIngressLoadBalancer(ctx context.Context, clientset kubernetes.Interface, namespace, ingressName string, backoff Backoff) (string, error) {
+	var address string
+	err := WaitForWithContext(ctx, backoff, func(ctx context.Context) (bool, error) {
+		ingress, err := clientset.NetworkingV1().Ingresses(namespace).Get(ctx, ingressName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		for _, lb := range ingress.Status.LoadBalancer.Ingress {
+			if lb.IP != "" {
+				address = lb.IP
+			} else {
+				address = lb.Hostname
+			}
+			if address != "" {
+				LogInfo("Ingress %s is reachable at %s", ingressName, address)
+				return true, nil
+			}
+		}
+		LogInfo("Waiting for Ingress %s to report its load balancer address...", ingressName)
+		return false, nil
+	})
+	return address, err
+}
+
+// DeleteIngress deletes an Ingress, the Routes generated for it go with it
+func DeleteIngress(clientset kubernetes.Interface, namespace, ingressName string) error {
+	err := clientset.NetworkingV1().Ingresses(namespace).Delete(context.TODO(), ingressName, metav1.DeleteOptions{})
+	if err != nil {
+		LogError("Failed to delete Ingress %s: %v", ingressName, err)
+		return err
+	}
+
+	LogInfo("Successfully deleted Ingress %s", ingressName)
+	return nil
+}
+
+// GetClusterAppsDomain returns the default domain of routes and ingresses, e.g. apps.cluster.example.com
+func GetClusterAppsDomain(client dynamic.Interface) (string, error) {
+	config, err := client.Resource(ClusterIngressConfigGVR).Get(context.TODO(), "cluster", metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get the cluster ingress configuration: %v", err)
+	}
+	domain, _, _ := unstructured.NestedString(config.Object, "spec", "domain")
+	if domain == "" {
+		return "", fmt.Errorf("the cluster ingress configuration has no domain")
+	}
+	return domain, nil
+}
@@ -0,0 +1,156 @@
+package util_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"myproject/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	routev1 "github.com/openshift/api/route/v1"
+	routefake "github.com/openshift/client-go/route/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("Ingresses", func() {
+	var clientset *fake.Clientset
+
+	BeforeEach(func() {
+		clientset = fake.NewSimpleClientset()
+	})
+
+	It("should group the rules by host", func() {
+		ingress, err := util.CreateIngress(clientset, "ns", "shop", util.IngressOptions{
+			ClassName: "openshift-default",
+			Rules: []util.IngressRule{
+				{Host: "shop.apps.example.com", ServiceName: "web", ServicePort: 80},
+				{Host: "shop.apps.example.com", Path: "/api", PathType: netv1.PathTypeExact, ServiceName: "api", ServicePort: "http"},
+				{Host: "admin.apps.example.com", ServiceName: "admin", ServicePort: 8080},
+			},
+			TLS:    []netv1.IngressTLS{{Hosts: []string{"shop.apps.example.com"}}},
+			Labels: map[string]string{"team": "networking"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ingress.Labels).To(HaveKeyWithValue("managed", "openshift-testing"))
+		Expect(*ingress.Spec.IngressClassName).To(Equal("openshift-default"))
+		Expect(ingress.Spec.TLS).To(HaveLen(1))
+
+		Expect(ingress.Spec.Rules).To(HaveLen(2))
+		shop := ingress.Spec.Rules[0]
+		Expect(shop.Host).To(Equal("shop.apps.example.com"))
+		Expect(shop.HTTP.Paths).To(HaveLen(2))
+		Expect(shop.HTTP.Paths[0].Path).To(Equal("/"))
+		Expect(*shop.HTTP.Paths[0].PathType).To(Equal(netv1.PathTypePrefix))
+		Expect(shop.HTTP.Paths[0].Backend.Service.Port.Number).To(BeEquivalentTo(80))
+		Expect(*shop.HTTP.Paths[1].PathType).To(Equal(netv1.PathTypeExact))
+		Expect(shop.HTTP.Paths[1].Backend.Service.Port.Name).To(Equal("http"))
+		Expect(ingress.Spec.Rules[1].HTTP.Paths[0].Backend.Service.Name).To(Equal("admin"))
+	})
+
+	DescribeTable("rejects invalid options",
+		func(options util.IngressOptions, problem string) {
+			_, err := util.CreateIngress(clientset, "ns", "bad", options)
+			Expect(err).To(MatchError(ContainSubstring(problem)))
+		},
+		Entry("no rules", util.IngressOptions{}, "the ingress has no rules"),
+		Entry("no host", util.IngressOptions{Rules: []util.IngressRule{{ServiceName: "web", ServicePort: 80}}}, "rule 0 has no host"),
+		Entry("invalid host", util.IngressOptions{Rules: []util.IngressRule{{Host: "Shop_1", ServiceName: "web", ServicePort: 80}}}, `invalid host "Shop_1"`),
+		Entry("label too long", util.IngressOptions{Rules: []util.IngressRule{{Host: strings.Repeat("a", 64) + ".apps.example.com", ServiceName: "web", ServicePort: 80}}}, "must be no more than 63 characters"),
+		Entry("relative path", util.IngressOptions{Rules: []util.IngressRule{{Host: "a.example.com", Path: "api", ServiceName: "web", ServicePort: 80}}}, "must start with /"),
+		Entry("no service", util.IngressOptions{Rules: []util.IngressRule{{Host: "a.example.com", ServicePort: 80}}}, "rule 0 has no service"),
+		Entry("port type", util.IngressOptions{Rules: []util.IngressRule{{Host: "a.example.com", ServiceName: "web", ServicePort: int32(80)}}}, "unsupported type for the port of rule 0: int32"),
+	)
+
+	It("should keep host labels within 63 characters", func() {
+		Expect(util.HostLabel("web", "ns")).To(Equal("web-ns"))
+
+		name, namespace := "functional-test-exposed-abcd1234", "functional-test-exposure-abcd1234"
+		label := util.HostLabel(name, namespace)
+		Expect(len(label)).To(BeNumerically("<=", 63))
+		Expect(label).To(HavePrefix("functional-test-exposed-"))
+		Expect(label).ToNot(Equal(util.HostLabel(name, "functional-test-exposure-efgh5678")))
+
+		_, err := util.CreateIngress(clientset, "ns", "long", util.IngressOptions{Rules: []util.IngressRule{{Host: label + ".apps.example.com", ServiceName: "web", ServicePort: 80}}})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should wait for the load balancer hostname", func() {
+		_, err := util.CreateIngress(clientset, "ns", "shop", util.IngressOptions{Rules: []util.IngressRule{{Host: "shop.apps.example.com", ServiceName: "web", ServicePort: 80}}})
+		Expect(err).ToNot(HaveOccurred())
+
+		go func() {
+			defer GinkgoRecover()
+			time.Sleep(50 * time.Millisecond)
+			ingress, err := clientset.NetworkingV1().Ingresses("ns").Get(context.Background(), "shop", metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			ingress.Status.LoadBalancer.Ingress = []netv1.IngressLoadBalancerIngress{{Hostname: "router-default.apps.example.com"}}
+			_, err = clientset.NetworkingV1().Ingresses("ns").UpdateStatus(context.Background(), ingress, metav1.UpdateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+		}()
+
+		address, err := util.WaitForIngressLoadBalancer(context.Background(), clientset, "ns", "shop", util.ConstantBackoff(10*time.Millisecond, time.Second, 0))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(address).To(Equal("router-default.apps.example.com"))
+	})
+
+	It("should wait for the generated route of the host", func() {
+		routeClient := routefake.NewSimpleClientset()
+		generated := func(name, owner, host string, conditionStatus corev1.ConditionStatus, reason string) *routev1.Route {
+			return &routev1.Route{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", OwnerReferences: []metav1.OwnerReference{{Kind: "Ingress", Name: owner}}},
+				Spec:       routev1.RouteSpec{Host: host, TLS: &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge}},
+				Status: routev1.RouteStatus{Ingress: []routev1.RouteIngress{{
+					RouterName: "default",
+					Host:       host,
+					Conditions: []routev1.RouteIngressCondition{{Type: routev1.RouteAdmitted, Status: conditionStatus, Reason: reason}},
+				}}},
+			}
+		}
+		wait := func(host string) (*util.RouteAdmission, error) {
+			return util.WaitForIngressRoute(context.Background(), routeClient, "ns", "shop", host, util.ConstantBackoff(10*time.Millisecond, 100*time.Millisecond, 0))
+		}
+
+		_, err := wait("shop.apps.example.com")
+		Expect(err).To(MatchError(ContainSubstring("no Route generated for host shop.apps.example.com")))
+
+		for _, route := range []*routev1.Route{
+			generated("other-abcde", "other", "shop.apps.example.com", corev1.ConditionTrue, ""),
+			generated("shop-fghij", "shop", "shop.apps.example.com", corev1.ConditionTrue, ""),
+			generated("shop-klmno", "shop", "taken.apps.example.com", corev1.ConditionFalse, "HostAlreadyClaimed"),
+		} {
+			_, err := routeClient.RouteV1().Routes("ns").Create(context.Background(), route, metav1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		admission, err := wait("shop.apps.example.com")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(admission.URL).To(Equal("https://shop.apps.example.com"))
+
+		_, err = wait("taken.apps.example.com")
+		var rejected *util.RouteRejectedError
+		Expect(errors.As(err, &rejected)).To(BeTrue())
+		Expect(rejected.HasReason("HostAlreadyClaimed")).To(BeTrue())
+	})
+
+	It("should read the cluster apps domain", func() {
+		config := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "config.openshift.io/v1",
+			"kind":       "Ingress",
+			"metadata":   map[string]interface{}{"name": "cluster"},
+			"spec":       map[string]interface{}{"domain": "apps.cluster.example.com"},
+		}}
+		client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), config)
+
+		domain, err := util.GetClusterAppsDomain(client)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(domain).To(Equal("apps.cluster.example.com"))
+	})
+})
@@ -0,0 +1,221 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"myproject/consts"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// JanitorResource is a resource type the janitor looks for
+type JanitorResource struct {
+	GVR        schema.GroupVersionResource
+	Namespaced bool
+	// IgnoreName skips the name prefix filter for resources whose names the tests don't choose,
+	// such as the BaselineAdminNetworkPolicy that must be called "default". They are matched by
+	// their labels, age and run ID only.
+	IgnoreName bool
+}
+
+// namespacesGVR is the resource the Namespaces option is compared against by name
+var namespacesGVR = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+
+// DefaultJanitorResources lists what the framework creates, in the order orphans are deleted.
+// TemplateInstances go before the VMs they created, and namespaces go last.
+var DefaultJanitorResources = []JanitorResource{
+	{GVR: schema.GroupVersionResource{Group: "template.openshift.io", Version: "v1", Resource: "templateinstances"}, Namespaced: true},
+	{GVR: schema.GroupVersionResource{Group: "kubevirt.io", Version: "v1", Resource: "virtualmachines"}, Namespaced: true},
+	{GVR: schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}, Namespaced: true},
+	{GVR: schema.GroupVersionResource{Group: "route.openshift.io", Version: "v1", Resource: "routes"}, Namespaced: true},
+	{GVR: schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"}, Namespaced: true},
+	{GVR: EgressFirewallGVR, Namespaced: true},
+	{GVR: schema.GroupVersionResource{Version: "v1", Resource: "services"}, Namespaced: true},
+	{GVR: schema.GroupVersionResource{Version: "v1", Resource: "secrets"}, Namespaced: true},
+	{GVR: schema.GroupVersionResource{Version: "v1", Resource: "pods"}, Namespaced: true},
+	{GVR: AdminNetworkPolicyGVR, Namespaced: false, IgnoreName: true},
+	{GVR: BaselineAdminNetworkPolicyGVR, Namespaced: false, IgnoreName: true},
+	{GVR: namespacesGVR, Namespaced: false},
+}
+
+// JanitorOptions selects which leaked resources are considered orphans
+type JanitorOptions struct {
+	Resources     []JanitorResource // Defaults to DefaultJanitorResources
+	Namespaces    []string          // Namespaces to search, empty means all namespaces. Cluster-scoped policies are always searched.
+	LabelSelector string            // Defaults to the framework's default labels (managed=openshift-testing)
+	NamePrefix    string            // Only resources whose name starts with the prefix, empty means any name. Not applied to IgnoreName resources.
+	TTL           time.Duration     // Only resources older than the TTL, 0 means any age
+	RunID         string            // Only resources labelled with this run ID, empty means any run
+	DryRun        bool              // Only report what would be deleted
+	Now           func() time.Time  // Clock used for the age, defaults to time.Now
+}
+
+// Orphan is a leaked resource found by the janitor
+type Orphan struct {
+	Resource  schema.GroupVersionResource
+	Namespace string
+	Name      string
+	Age       time.Duration
+	RunID     string
+}
+
+func (o Orphan) String() string {
+	name := o.Name
+	if o.Namespace != "" {
+		name = o.Namespace + "/" + o.Name
+	}
+	runID := o.RunID
+	if runID == "" {
+		runID = "<none>"
+	}
+	return fmt.Sprintf("%s %s (age %s, run %s)", o.Resource.Resource, name, o.Age.Round(time.Second), runID)
+}
+
+// FindOrphans lists the resources carrying the framework's labels that match the options.
+// Resource types the cluster doesn't serve (e.g. no KubeVirt installed) are skipped.
+func FindOrphans(ctx context.Context, client dynamic.Interface, options JanitorOptions) ([]Orphan, error) {
+	options = options.withDefaults()
+	selector, err := options.selector()
+	if err != nil {
+		return nil, err
+	}
+
+	var orphans []Orphan
+	for _, resource := range options.Resources {
+		items, err := listJanitorResource(ctx, client, resource, options.Namespaces, selector)
+		if apierrors.IsNotFound(err) {
+			LogWarn("Resource %s is not served by the cluster, skipping", resource.GVR.String())
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var found []Orphan
+		for _, item := range items {
+			if resource.GVR == namespacesGVR && len(options.Namespaces) > 0 && !containsString(options.Namespaces, item.GetName()) {
+				continue
+			}
+			if !resource.IgnoreName && options.NamePrefix != "" && !strings.HasPrefix(item.GetName(), options.NamePrefix) {
+				continue
+			}
+			age := options.Now().Sub(item.GetCreationTimestamp().Time)
+			if options.TTL > 0 && age < options.TTL {
+				continue
+			}
+			found = append(found, Orphan{
+				Resource:  resource.GVR,
+				Namespace: item.GetNamespace(),
+				Name:      item.GetName(),
+				Age:       age,
+				RunID:     item.GetLabels()[consts.RunIDLabel],
+			})
+		}
+
+		sort.Slice(found, func(i, j int) bool {
+			return found[i].Namespace+"/"+found[i].Name < found[j].Namespace+"/"+found[j].Name
+		})
+		orphans = append(orphans, found...)
+	}
+
+	return orphans, nil
+}
+
+// CleanupOrphans finds orphans and, unless DryRun is set, deletes them in the order of the resource list.
+// It returns the orphans it found (or deleted) and keeps going when a single deletion fails.
+func CleanupOrphans(ctx context.Context, client dynamic.Interface, options JanitorOptions) ([]Orphan, error) {
+	orphans, err := FindOrphans(ctx, client, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.DryRun {
+		for _, orphan := range orphans {
+			LogInfo("Would delete %s", orphan)
+		}
+		return orphans, nil
+	}
+
+	var failures []string
+	propagation := metav1.DeletePropagationBackground
+	for _, orphan := range orphans {
+		resourceClient := client.Resource(orphan.Resource)
+		var deleteErr error
+		if orphan.Namespace != "" {
+			deleteErr = resourceClient.Namespace(orphan.Namespace).Delete(ctx, orphan.Name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+		} else {
+			deleteErr = resourceClient.Delete(ctx, orphan.Name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+		}
+		if deleteErr != nil && !apierrors.IsNotFound(deleteErr) {
+			LogError("Failed to delete %s: %v", orphan, deleteErr)
+			failures = append(failures, fmt.Sprintf("%s: %v", orphan, deleteErr))
+			continue
+		}
+		LogInfo("Deleted %s", orphan)
+	}
+
+	if len(failures) > 0 {
+		return orphans, fmt.Errorf("failed to delete %d orphans: %s", len(failures), strings.Join(failures, "; "))
+	}
+	return orphans, nil
+}
+
+// listJanitorResource lists the labelled objects of one resource type, either cluster wide or per namespace
+func listJanitorResource(ctx context.Context, client dynamic.Interface, resource JanitorResource, namespaces []string, selector string) ([]unstructured.Unstructured, error) {
+	listOptions := metav1.ListOptions{LabelSelector: selector}
+	if !resource.Namespaced || len(namespaces) == 0 {
+		list, err := client.Resource(resource.GVR).List(ctx, listOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", resource.GVR.Resource, err)
+		}
+		return list.Items, nil
+	}
+
+	var items []unstructured.Unstructured
+	for _, namespace := range namespaces {
+		list, err := client.Resource(resource.GVR).Namespace(namespace).List(ctx, listOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s in %s: %w", resource.GVR.Resource, namespace, err)
+		}
+		items = append(items, list.Items...)
+	}
+	return items, nil
+}
+
+func (o JanitorOptions) withDefaults() JanitorOptions {
+	if len(o.Resources) == 0 {
+		o.Resources = DefaultJanitorResources
+	}
+	if o.LabelSelector == "" {
+		o.LabelSelector = labels.SelectorFromSet(consts.DefaultLabels).String()
+	}
+	if o.Now == nil {
+		o.Now = time.Now
+	}
+	return o
+}
+
+// selector combines the label selector with the run ID filter
+func (o JanitorOptions) selector() (string, error) {
+	selector, err := labels.Parse(o.LabelSelector)
+	if err != nil {
+		return "", fmt.Errorf("invalid label selector %q: %v", o.LabelSelector, err)
+	}
+	if o.RunID != "" {
+		runIDSelector, err := labels.Parse(fmt.Sprintf("%s=%s", consts.RunIDLabel, o.RunID))
+		if err != nil {
+			return "", fmt.Errorf("invalid run ID %q: %v", o.RunID, err)
+		}
+		requirements, _ := runIDSelector.Requirements()
+		selector = selector.Add(requirements...)
+	}
+	return selector.String(), nil
+}
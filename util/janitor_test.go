@@ -0,0 +1,124 @@
+package util_test
+
+import (
+	"context"
+	"time"
+
+	"myproject/consts"
+	"myproject/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+var _ = Describe("Janitor", func() {
+	var (
+		now       = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		podsGVR   = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+		tiGVR     = schema.GroupVersionResource{Group: "template.openshift.io", Version: "v1", Resource: "templateinstances"}
+		resources = []util.JanitorResource{{GVR: tiGVR, Namespaced: true}, {GVR: podsGVR, Namespaced: true}}
+	)
+
+	newObject := func(apiVersion, kind, name string, age time.Duration, labels map[string]string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(apiVersion)
+		obj.SetKind(kind)
+		obj.SetNamespace("core")
+		obj.SetName(name)
+		obj.SetLabels(labels)
+		obj.SetCreationTimestamp(metav1.NewTime(now.Add(-age)))
+		return obj
+	}
+
+	newClient := func() *dynamicfake.FakeDynamicClient {
+		managed := util.MergeLabels(consts.DefaultLabels, map[string]string{consts.RunIDLabel: "run1"})
+		return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+			map[schema.GroupVersionResource]string{podsGVR: "PodList", tiGVR: "TemplateInstanceList"},
+			newObject("v1", "Pod", consts.TestPrefix+"-old", 10*time.Hour, managed),
+			newObject("v1", "Pod", consts.TestPrefix+"-new", time.Minute, managed),
+			newObject("v1", "Pod", consts.TestPrefix+"-other-run", 10*time.Hour, consts.DefaultLabels),
+			newObject("v1", "Pod", "unmanaged", 10*time.Hour, nil),
+			newObject("template.openshift.io/v1", "TemplateInstance", consts.TestPrefix+"vm", 10*time.Hour, managed),
+		)
+	}
+
+	It("should find labelled resources older than the TTL for a run", func() {
+		orphans, err := util.FindOrphans(context.Background(), newClient(), util.JanitorOptions{
+			Resources:  resources,
+			NamePrefix: consts.TestPrefix,
+			TTL:        time.Hour,
+			RunID:      "run1",
+			Now:        func() time.Time { return now },
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		var names []string
+		for _, orphan := range orphans {
+			names = append(names, orphan.Name)
+		}
+		Expect(names).To(Equal([]string{consts.TestPrefix + "vm", consts.TestPrefix + "-old"}))
+	})
+
+	It("should only report orphans in dry-run and delete them otherwise", func() {
+		client := newClient()
+		options := util.JanitorOptions{Resources: resources, TTL: time.Hour, DryRun: true, Now: func() time.Time { return now }}
+
+		orphans, err := util.CleanupOrphans(context.Background(), client, options)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(orphans).To(HaveLen(3))
+		Expect(client.Actions()).ToNot(ContainElement(WithTransform(func(action interface{ GetVerb() string }) string {
+			return action.GetVerb()
+		}, Equal("delete"))))
+
+		options.DryRun = false
+		_, err = util.CleanupOrphans(context.Background(), client, options)
+		Expect(err).ToNot(HaveOccurred())
+
+		remaining, err := util.FindOrphans(context.Background(), client, util.JanitorOptions{Resources: resources, Now: func() time.Time { return now }})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(remaining).To(HaveLen(1))
+		Expect(remaining[0].Name).To(Equal(consts.TestPrefix + "-new"))
+	})
+
+	It("should find a labelled BaselineAdminNetworkPolicy named default despite the prefix and namespaces", func() {
+		banpResources := []util.JanitorResource{
+			{GVR: util.BaselineAdminNetworkPolicyGVR, IgnoreName: true},
+			{GVR: schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}},
+		}
+		managed := util.MergeLabels(consts.DefaultLabels, map[string]string{consts.RunIDLabel: "run1"})
+		newClusterObject := func(apiVersion, kind, name string) *unstructured.Unstructured {
+			obj := newObject(apiVersion, kind, name, 10*time.Hour, managed)
+			obj.SetNamespace("")
+			return obj
+		}
+		client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+			map[schema.GroupVersionResource]string{
+				util.BaselineAdminNetworkPolicyGVR:     "BaselineAdminNetworkPolicyList",
+				{Version: "v1", Resource: "namespaces"}: "NamespaceList",
+			},
+			newClusterObject("policy.networking.k8s.io/v1alpha1", "BaselineAdminNetworkPolicy", "default"),
+			newClusterObject("v1", "Namespace", consts.TestPrefix+"-kept"),
+			newClusterObject("v1", "Namespace", consts.TestPrefix+"-other"),
+		)
+
+		orphans, err := util.FindOrphans(context.Background(), client, util.JanitorOptions{
+			Resources:  banpResources,
+			Namespaces: []string{consts.TestPrefix + "-kept"},
+			NamePrefix: consts.TestPrefix,
+			TTL:        time.Hour,
+			RunID:      "run1",
+			Now:        func() time.Time { return now },
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		var names []string
+		for _, orphan := range orphans {
+			names = append(names, orphan.Name)
+		}
+		Expect(names).To(Equal([]string{"default", consts.TestPrefix + "-kept"}))
+	})
+})
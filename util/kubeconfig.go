@@ -85,7 +85,7 @@ func AuthenticateFile(kubeconfigPath string) (*kubernetes.Clientset, *rest.Confi
 
 // VerifyConnection checks if the Kubernetes client can successfully communicate with the cluster.
 // It attempts to list namespaces to verify the connection.
-func VerifyConnection(clientset *kubernetes.Clientset) error {
+func VerifyConnection(clientset kubernetes.Interface) error {
 	// Attempt to list namespaces to verify connection
 	_, err := clientset.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
@@ -0,0 +1,81 @@
+package util
+
+import (
+	"regexp"
+	"strings"
+
+	"myproject/consts"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// MergeLabels returns a new map holding the labels of all given maps, later maps winning.
+// The inputs are never modified, so shared defaults such as consts.DefaultLabels stay intact.
+func MergeLabels(labelSets ...map[string]string) map[string]string {
+	merged := map[string]string{}
+	for _, labels := range labelSets {
+		for key, value := range labels {
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
+// DefaultLabelsFor returns a fresh copy of the default labels with the "app" label set to name
+func DefaultLabelsFor(name string) map[string]string {
+	return MergeLabels(consts.DefaultLabels, map[string]string{consts.AppLabel: name})
+}
+
+// Labeler builds the labels of the objects created for one spec: the defaults plus the run ID,
+// spec name and owner labels. It is never modified after construction, and every call returns
+// a new map, so one Labeler can be shared by goroutines and parallel specs.
+type Labeler struct {
+	base map[string]string
+}
+
+// NewLabeler copies the defaults and adds the run ID, spec and owner labels that are not empty.
+// The spec name and owner are sanitized into valid label values.
+func NewLabeler(defaults map[string]string, runID, specName, owner string) *Labeler {
+	base := MergeLabels(defaults)
+	optional := map[string]string{
+		consts.RunIDLabel: runID,
+		consts.SpecLabel:  SanitizeLabelValue(specName),
+		consts.OwnerLabel: SanitizeLabelValue(owner),
+	}
+	for key, value := range optional {
+		if value != "" {
+			base[key] = value
+		}
+	}
+	return &Labeler{base: base}
+}
+
+// With returns a new Labeler that also adds the given labels
+func (l *Labeler) With(labels map[string]string) *Labeler {
+	return &Labeler{base: MergeLabels(l.base, labels)}
+}
+
+// Labels returns a copy of the labels shared by every object of the spec
+func (l *Labeler) Labels() map[string]string {
+	return MergeLabels(l.base)
+}
+
+// For returns the labels of the object called name: the shared labels, "app=<name>" and any extra labels
+func (l *Labeler) For(name string, extra ...map[string]string) map[string]string {
+	labelSets := append([]map[string]string{l.base, {consts.AppLabel: name}}, extra...)
+	return MergeLabels(labelSets...)
+}
+
+var invalidLabelChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// SanitizeLabelValue turns free text such as a spec name into a valid label value by replacing
+// invalid characters with "-" and shortening it to 63 characters
+func SanitizeLabelValue(value string) string {
+	value = invalidLabelChars.ReplaceAllString(value, "-")
+	if len(value) > validation.LabelValueMaxLength {
+		value = value[:validation.LabelValueMaxLength]
+	}
+	// Values must start and end with an alphanumeric character
+	return strings.TrimFunc(value, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+}
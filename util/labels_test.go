@@ -0,0 +1,116 @@
+package util_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"myproject/consts"
+	"myproject/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	templatev1 "github.com/openshift/api/template/v1"
+	templatefake "github.com/openshift/client-go/template/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+// newVMTemplate returns a template holding a single labelled VirtualMachine
+func newVMTemplate(namespace, name string) *templatev1.Template {
+	vm := kubevirtv1.VirtualMachine{
+		TypeMeta:   metav1.TypeMeta{APIVersion: kubevirtv1.GroupVersion.String(), Kind: "VirtualMachine"},
+		ObjectMeta: metav1.ObjectMeta{Name: "template-vm", Labels: map[string]string{"os": "rhel8"}},
+		Spec: kubevirtv1.VirtualMachineSpec{
+			Template: &kubevirtv1.VirtualMachineInstanceTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"kubevirt.io/domain": "template-vm"}},
+			},
+		},
+	}
+	raw, err := json.Marshal(vm)
+	Expect(err).ToNot(HaveOccurred())
+
+	return &templatev1.Template{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Objects:    []runtime.RawExtension{{Raw: raw}},
+	}
+}
+
+var _ = Describe("Labeler", func() {
+	It("should add the run, spec and owner labels to a copy of the defaults", func() {
+		labeler := util.NewLabeler(consts.DefaultLabels, "run1", "NetworkPolicy should deny traffic", "jane.doe@example.com")
+
+		labels := labeler.For("server", map[string]string{"tier": "backend"})
+		Expect(labels).To(Equal(map[string]string{
+			"managed":         "openshift-testing",
+			consts.RunIDLabel: "run1",
+			consts.SpecLabel:  "NetworkPolicy-should-deny-traffic",
+			consts.OwnerLabel: "jane.doe-example.com",
+			consts.AppLabel:   "server",
+			"tier":            "backend",
+		}))
+		Expect(labeler.Labels()).ToNot(HaveKey(consts.AppLabel))
+		Expect(consts.DefaultLabels).To(Equal(map[string]string{"managed": "openshift-testing"}))
+	})
+
+	It("should shorten long spec names into valid label values", func() {
+		value := util.SanitizeLabelValue("[sig-network] " + string(make([]byte, 100)) + " trailing -")
+		Expect(len(value)).To(BeNumerically("<=", 63))
+		Expect(value).To(MatchRegexp(`^[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`))
+	})
+
+	It("should keep pod and VM labels separate when they are created in parallel", func() {
+		const workers = 10
+		clientset := fake.NewSimpleClientset()
+		templateClient := templatefake.NewSimpleClientset(newVMTemplate(consts.DefaultTemplateNamespace, consts.DefaultTemplateName))
+		labeler := util.NewLabeler(consts.DefaultLabels, "run1", "parallel", "")
+
+		vms := make([]*kubevirtv1.VirtualMachine, workers)
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(2)
+			go func(i int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				// Every other pod uses the package defaults, the rest the shared Labeler
+				var labels map[string]string
+				if i%2 == 0 {
+					labels = labeler.For(fmt.Sprintf("pod-%d", i))
+				}
+				_, err := util.CreatePod(clientset, "default", fmt.Sprintf("pod-%d", i), nil, labels, false)
+				Expect(err).ToNot(HaveOccurred())
+			}(i)
+			go func(i int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				var err error
+				vms[i], err = util.CreateVM(templateClient, nil, "default", "", "", fmt.Sprintf("vm-%d", i), nil, nil, false, "", "")
+				Expect(err).ToNot(HaveOccurred())
+			}(i)
+		}
+		wg.Wait()
+
+		for i := 0; i < workers; i++ {
+			pod, err := clientset.CoreV1().Pods("default").Get(context.TODO(), fmt.Sprintf("pod-%d", i), metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pod.Labels).To(HaveKeyWithValue(consts.AppLabel, pod.Name))
+			if i%2 == 0 {
+				Expect(pod.Labels).To(HaveKeyWithValue(consts.RunIDLabel, "run1"))
+			} else {
+				Expect(pod.Labels).ToNot(HaveKey(consts.RunIDLabel))
+			}
+
+			name := fmt.Sprintf("vm-%d", i)
+			instance, err := templateClient.TemplateV1().TemplateInstances("default").Get(context.TODO(), name, metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.Labels).To(HaveKeyWithValue(consts.AppLabel, name))
+			Expect(vms[i].Labels).To(HaveKeyWithValue(consts.AppLabel, name))
+			Expect(vms[i].Labels).To(HaveKeyWithValue("os", "rhel8"))
+			Expect(vms[i].Spec.Template.ObjectMeta.Labels).To(HaveKeyWithValue(consts.AppLabel, name))
+			Expect(vms[i].Spec.Template.ObjectMeta.Labels).ToNot(HaveKey("os"))
+		}
+		Expect(consts.DefaultLabels).To(Equal(map[string]string{"managed": "openshift-testing"}))
+	})
+})
@@ -0,0 +1,93 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	projectv1 "github.com/openshift/api/project/v1"
+	projectclientset "github.com/openshift/client-go/project/clientset/versioned"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CreateNamespace creates a namespace with the given labels
+func CreateNamespace(clientset kubernetes.Interface, namespaceName string, labels map[string]string) (*corev1.Namespace, error) {
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   namespaceName,
+			Labels: labels,
+		},
+	}
+
+	createdNamespace, err := clientset.CoreV1().Namespaces().Create(context.TODO(), namespace, metav1.CreateOptions{})
+	if err != nil {
+		LogError("Failed to create namespace %s: %v", namespaceName, err)
+		return nil, err
+	}
+
+	LogInfo("Namespace %s created", namespaceName)
+	return createdNamespace, nil
+}
+
+// CreateProject creates an OpenShift project through a ProjectRequest and then labels its namespace,
+// since ProjectRequests cannot carry labels themselves.
+func CreateProject(projectClient projectclientset.Interface, clientset kubernetes.Interface, projectName string, labels map[string]string) (*corev1.Namespace, error) {
+	projectRequest := &projectv1.ProjectRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: projectName,
+		},
+	}
+
+	_, err := projectClient.ProjectV1().ProjectRequests().Create(context.TODO(), projectRequest, metav1.CreateOptions{})
+	if err != nil {
+		LogError("Failed to create project %s: %v", projectName, err)
+		return nil, err
+	}
+	LogInfo("Project %s created", projectName)
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": labels},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	namespace, err := clientset.CoreV1().Namespaces().Patch(context.TODO(), projectName, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		LogError("Failed to label namespace of project %s: %v", projectName, err)
+		return nil, fmt.Errorf("failed to label namespace of project %s: %v", projectName, err)
+	}
+
+	return namespace, nil
+}
+
+// WaitForServiceAccounts waits until the given service accounts (e.g. "default") exist in the namespace.
+func WaitForServiceAccounts(ctx context.Context, clientset kubernetes.Interface, namespace string, serviceAccounts []string, backoff Backoff) error {
+	return WaitForWithContext(ctx, backoff, func(ctx context.Context) (bool, error) {
+		for _, serviceAccount := range serviceAccounts {
+			_, err := clientset.CoreV1().ServiceAccounts(namespace).Get(ctx, serviceAccount, metav1.GetOptions{})
+			if err != nil {
+				LogInfo("Waiting for service account %s in namespace %s...", serviceAccount, namespace)
+				return false, nil
+			}
+		}
+
+		LogInfo("Service accounts %v are ready in namespace %s", serviceAccounts, namespace)
+		return true, nil
+	})
+}
+
+// DeleteNamespace deletes a namespace and everything in it
+func DeleteNamespace(clientset kubernetes.Interface, namespaceName string) error {
+	err := clientset.CoreV1().Namespaces().Delete(context.TODO(), namespaceName, metav1.DeleteOptions{})
+	if err != nil {
+		LogError("Failed to delete namespace %s: %v", namespaceName, err)
+		return err
+	}
+
+	LogInfo("Successfully deleted namespace %s", namespaceName)
+	return nil
+}
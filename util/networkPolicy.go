@@ -6,17 +6,18 @@ import (
 
 	netv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"myproject/consts"
 )
 
 // CreateNetworkPolicyWithNamespaceAllow creates a NetworkPolicy that allows ingress traffic from other namespaces on a specified port.
-func CreateNetworkPolicyWithNamespaceAllow(clientset *kubernetes.Clientset, namespace, policyName string, allowPorts []netv1.NetworkPolicyPort) (*netv1.NetworkPolicy, error) {
+func CreateNetworkPolicyWithNamespaceAllow(clientset kubernetes.Interface, namespace, policyName string, allowPorts []netv1.NetworkPolicyPort) (*netv1.NetworkPolicy, error) {
     policy := &netv1.NetworkPolicy{
         ObjectMeta: metav1.ObjectMeta{
             Name:      policyName,
             Namespace: namespace,
+            Labels:    MergeLabels(consts.DefaultLabels),
         },
         Spec: netv1.NetworkPolicySpec{
             PodSelector: metav1.LabelSelector{
@@ -58,8 +59,20 @@ func CreateNetworkPolicyWithNamespaceAllow(clientset *kubernetes.Clientset, name
     return networkPolicy, nil
 }
 
+// CreateNetworkPolicy creates a NetworkPolicy, typically one built with NewNetworkPolicyBuilder
+func CreateNetworkPolicy(clientset kubernetes.Interface, policy *netv1.NetworkPolicy) (*netv1.NetworkPolicy, error) {
+	networkPolicy, err := clientset.NetworkingV1().NetworkPolicies(policy.Namespace).Create(context.TODO(), policy, metav1.CreateOptions{})
+	if err != nil {
+		LogError("Failed to create network policy %s: %v", policy.Name, err)
+		return nil, fmt.Errorf("failed to create network policy %s: %v", policy.Name, err)
+	}
+
+	LogInfo("Successfully created NetworkPolicy %s in namespace %s", policy.Name, policy.Namespace)
+	return networkPolicy, nil
+}
+
 // DeleteNetworkPolicy deletes a NetworkPolicy
-func DeleteNetworkPolicy(clientset *kubernetes.Clientset, namespace, policyName string) error {
+func DeleteNetworkPolicy(clientset kubernetes.Interface, namespace, policyName string) error {
 	err := clientset.NetworkingV1().NetworkPolicies(namespace).Delete(context.TODO(), policyName, metav1.DeleteOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to delete network policy: %v", err)
@@ -67,24 +80,18 @@ func DeleteNetworkPolicy(clientset *kubernetes.Clientset, namespace, policyName
 	return nil
 }
 
-// CreateNetworkPolicyPort defines a NetworkPolicyPort to restrict access to a specific port and protocol as a string
-func CreateNetworkPolicyPort(port int32, protocolStr string) []netv1.NetworkPolicyPort {
-	var protocol corev1.Protocol
-
-	// Convert string protocol to corev1.Protocol type
-	switch protocolStr {
-	case "TCP":
-		protocol = corev1.ProtocolTCP
-	case "UDP":
-		protocol = corev1.ProtocolUDP
-	default:
-		protocol = corev1.ProtocolTCP // Default to TCP if no match
+// CreateNetworkPolicyPort defines a NetworkPolicyPort to restrict access to a specific port and protocol (TCP, UDP or SCTP) as a string
+func CreateNetworkPolicyPort(port int32, protocolStr string) ([]netv1.NetworkPolicyPort, error) {
+	protocol, err := ParseProtocol(protocolStr)
+	if err != nil {
+		return nil, err
 	}
 
+	portValue := intstr.FromInt32(port)
 	return []netv1.NetworkPolicyPort{
 		{
-			Port:     &intstr.IntOrString{IntVal: port},
+			Port:     &portValue,
 			Protocol: &protocol,
 		},
-	}
-}
\ No newline at end of file
+	}, nil
+}
@@ -0,0 +1,244 @@
+package util
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"myproject/consts"
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// ParseProtocol converts a protocol name (case-insensitive) to a corev1.Protocol. An empty name means TCP.
+func ParseProtocol(protocol string) (corev1.Protocol, error) {
+	switch strings.ToUpper(protocol) {
+	case "", "TCP":
+		return corev1.ProtocolTCP, nil
+	case "UDP":
+		return corev1.ProtocolUDP, nil
+	case "SCTP":
+		return corev1.ProtocolSCTP, nil
+	default:
+		return "", fmt.Errorf("unsupported protocol %q, expected TCP, UDP or SCTP", protocol)
+	}
+}
+
+// NetworkPolicyRule builds the peers and ports of one ingress or egress rule.
+// A rule without peers matches every peer and a rule without ports matches every port.
+type NetworkPolicyRule struct {
+	peers    []netv1.NetworkPolicyPeer
+	ports    []netv1.NetworkPolicyPort
+	problems []string
+}
+
+// NewNetworkPolicyRule starts an empty rule
+func NewNetworkPolicyRule() *NetworkPolicyRule {
+	return &NetworkPolicyRule{}
+}
+
+// Peer adds a peer from raw selectors. A nil selector is left unset: pods only match pods of the
+// policy's namespace, namespaces only match every pod of the selected namespaces.
+func (r *NetworkPolicyRule) Peer(podSelector, namespaceSelector *metav1.LabelSelector) *NetworkPolicyRule {
+	if podSelector == nil && namespaceSelector == nil {
+		r.problems = append(r.problems, "a peer needs a pod or namespace selector")
+		return r
+	}
+	for _, selector := range []*metav1.LabelSelector{podSelector, namespaceSelector} {
+		if selector == nil {
+			continue
+		}
+		if _, err := metav1.LabelSelectorAsSelector(selector); err != nil {
+			r.problems = append(r.problems, fmt.Sprintf("invalid selector: %v", err))
+		}
+	}
+	r.peers = append(r.peers, netv1.NetworkPolicyPeer{PodSelector: podSelector, NamespaceSelector: namespaceSelector})
+	return r
+}
+
+// Pods adds the pods of the policy's namespace matching the labels, every pod if labels is empty
+func (r *NetworkPolicyRule) Pods(podLabels map[string]string) *NetworkPolicyRule {
+	return r.Peer(&metav1.LabelSelector{MatchLabels: podLabels}, nil)
+}
+
+// Namespaces adds every pod of the namespaces matching the labels, every namespace if labels is empty
+func (r *NetworkPolicyRule) Namespaces(namespaceLabels map[string]string) *NetworkPolicyRule {
+	return r.Peer(nil, &metav1.LabelSelector{MatchLabels: namespaceLabels})
+}
+
+// Namespace adds every pod of the named namespace
+func (r *NetworkPolicyRule) Namespace(name string) *NetworkPolicyRule {
+	return r.Namespaces(map[string]string{namespaceNameLabel: name})
+}
+
+// PodsInNamespaces adds the pods matching podLabels in the namespaces matching namespaceLabels
+func (r *NetworkPolicyRule) PodsInNamespaces(podLabels, namespaceLabels map[string]string) *NetworkPolicyRule {
+	return r.Peer(&metav1.LabelSelector{MatchLabels: podLabels}, &metav1.LabelSelector{MatchLabels: namespaceLabels})
+}
+
+// IPBlock adds the addresses of the CIDR, except those of the except CIDRs which must be strictly smaller
+// blocks inside it, as the API server requires
+func (r *NetworkPolicyRule) IPBlock(cidr string, except ...string) *NetworkPolicyRule {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		r.problems = append(r.problems, fmt.Sprintf("invalid ipBlock CIDR %q", cidr))
+		return r
+	}
+	cidrOnes, _ := network.Mask.Size()
+	for _, exceptCIDR := range except {
+		_, exceptNetwork, err := net.ParseCIDR(exceptCIDR)
+		if err != nil {
+			r.problems = append(r.problems, fmt.Sprintf("invalid ipBlock except CIDR %q", exceptCIDR))
+			continue
+		}
+		exceptOnes, _ := exceptNetwork.Mask.Size()
+		if !network.Contains(exceptNetwork.IP) || exceptOnes <= cidrOnes {
+			r.problems = append(r.problems, fmt.Sprintf("ipBlock except %s is not inside %s", exceptCIDR, cidr))
+		}
+	}
+	r.peers = append(r.peers, netv1.NetworkPolicyPeer{IPBlock: &netv1.IPBlock{CIDR: cidr, Except: except}})
+	return r
+}
+
+// Port allows a single port number
+func (r *NetworkPolicyRule) Port(port int32, protocol string) *NetworkPolicyRule {
+	if !r.validPort(port) {
+		return r
+	}
+	return r.addPort(intstr.FromInt32(port), nil, protocol)
+}
+
+// PortRange allows the ports from start to end, both included
+func (r *NetworkPolicyRule) PortRange(start, end int32, protocol string) *NetworkPolicyRule {
+	if !r.validPort(start) || !r.validPort(end) {
+		return r
+	}
+	if end < start {
+		r.problems = append(r.problems, fmt.Sprintf("port range %d-%d ends before it starts", start, end))
+		return r
+	}
+	return r.addPort(intstr.FromInt32(start), &end, protocol)
+}
+
+// NamedPort allows the container port with that name on the destination pods
+func (r *NetworkPolicyRule) NamedPort(name, protocol string) *NetworkPolicyRule {
+	if problems := validation.IsValidPortName(name); len(problems) > 0 {
+		r.problems = append(r.problems, fmt.Sprintf("invalid port name %q: %s", name, strings.Join(problems, ", ")))
+		return r
+	}
+	return r.addPort(intstr.FromString(name), nil, protocol)
+}
+
+func (r *NetworkPolicyRule) addPort(port intstr.IntOrString, endPort *int32, protocol string) *NetworkPolicyRule {
+	parsed, err := ParseProtocol(protocol)
+	if err != nil {
+		r.problems = append(r.problems, err.Error())
+		return r
+	}
+	r.ports = append(r.ports, netv1.NetworkPolicyPort{Port: &port, EndPort: endPort, Protocol: &parsed})
+	return r
+}
+
+func (r *NetworkPolicyRule) validPort(port int32) bool {
+	if port < 1 || port > 65535 {
+		r.problems = append(r.problems, fmt.Sprintf("port %d is outside 1-65535", port))
+		return false
+	}
+	return true
+}
+
+// NetworkPolicyBuilder builds a NetworkPolicy step by step and reports every invalid input when built.
+// Without SelectPods the policy applies to every pod of its namespace.
+type NetworkPolicyBuilder struct {
+	policy   netv1.NetworkPolicy
+	problems []string
+}
+
+// NewNetworkPolicyBuilder starts a policy with the default labels and no policy types
+func NewNetworkPolicyBuilder(namespace, name string) *NetworkPolicyBuilder {
+	return &NetworkPolicyBuilder{policy: netv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    MergeLabels(consts.DefaultLabels),
+		},
+	}}
+}
+
+// WithLabels adds labels to the policy object
+func (b *NetworkPolicyBuilder) WithLabels(labels map[string]string) *NetworkPolicyBuilder {
+	b.policy.Labels = MergeLabels(b.policy.Labels, labels)
+	return b
+}
+
+// SelectPods applies the policy to the pods matching the labels
+func (b *NetworkPolicyBuilder) SelectPods(podLabels map[string]string) *NetworkPolicyBuilder {
+	return b.SelectPodsMatching(metav1.LabelSelector{MatchLabels: podLabels})
+}
+
+// SelectPodsMatching applies the policy to the pods matching the selector
+func (b *NetworkPolicyBuilder) SelectPodsMatching(selector metav1.LabelSelector) *NetworkPolicyBuilder {
+	if _, err := metav1.LabelSelectorAsSelector(&selector); err != nil {
+		b.problems = append(b.problems, fmt.Sprintf("invalid pod selector: %v", err))
+	}
+	b.policy.Spec.PodSelector = selector
+	return b
+}
+
+// DenyAllIngress isolates the selected pods for ingress. Only traffic allowed by AllowIngress rules gets in.
+func (b *NetworkPolicyBuilder) DenyAllIngress() *NetworkPolicyBuilder {
+	b.addPolicyType(netv1.PolicyTypeIngress)
+	return b
+}
+
+// DenyAllEgress isolates the selected pods for egress. Only traffic allowed by AllowEgress rules gets out.
+func (b *NetworkPolicyBuilder) DenyAllEgress() *NetworkPolicyBuilder {
+	b.addPolicyType(netv1.PolicyTypeEgress)
+	return b
+}
+
+// AllowIngress adds a rule allowing traffic from its peers to its ports
+func (b *NetworkPolicyBuilder) AllowIngress(rule *NetworkPolicyRule) *NetworkPolicyBuilder {
+	b.addPolicyType(netv1.PolicyTypeIngress)
+	b.problems = append(b.problems, rule.problems...)
+	b.policy.Spec.Ingress = append(b.policy.Spec.Ingress, netv1.NetworkPolicyIngressRule{From: rule.peers, Ports: rule.ports})
+	return b
+}
+
+// AllowEgress adds a rule allowing traffic to its peers on its ports
+func (b *NetworkPolicyBuilder) AllowEgress(rule *NetworkPolicyRule) *NetworkPolicyBuilder {
+	b.addPolicyType(netv1.PolicyTypeEgress)
+	b.problems = append(b.problems, rule.problems...)
+	b.policy.Spec.Egress = append(b.policy.Spec.Egress, netv1.NetworkPolicyEgressRule{To: rule.peers, Ports: rule.ports})
+	return b
+}
+
+// Build validates the policy and returns a copy of it
+func (b *NetworkPolicyBuilder) Build() (*netv1.NetworkPolicy, error) {
+	problems := append([]string{}, b.problems...)
+	for _, msg := range validation.IsDNS1123Subdomain(b.policy.Name) {
+		problems = append(problems, fmt.Sprintf("name %q: %s", b.policy.Name, msg))
+	}
+	if b.policy.Namespace == "" {
+		problems = append(problems, "namespace must not be empty")
+	}
+	if len(b.policy.Spec.PolicyTypes) == 0 {
+		problems = append(problems, "the policy has no ingress or egress rules and denies nothing")
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid NetworkPolicy %s/%s: %s", b.policy.Namespace, b.policy.Name, strings.Join(problems, "; "))
+	}
+	return b.policy.DeepCopy(), nil
+}
+
+func (b *NetworkPolicyBuilder) addPolicyType(policyType netv1.PolicyType) {
+	for _, existing := range b.policy.Spec.PolicyTypes {
+		if existing == policyType {
+			return
+		}
+	}
+	b.policy.Spec.PolicyTypes = append(b.policy.Spec.PolicyTypes, policyType)
+}
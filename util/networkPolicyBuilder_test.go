@@ -0,0 +1,114 @@
+package util_test
+
+import (
+	"myproject/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("NetworkPolicyBuilder", func() {
+	It("should build ingress and egress rules", func() {
+		policy, err := util.NewNetworkPolicyBuilder("x", "web").
+			SelectPods(map[string]string{"app": "a"}).
+			AllowIngress(util.NewNetworkPolicyRule().
+				PodsInNamespaces(map[string]string{"app": "a"}, map[string]string{"team": "blue"}).
+				NamedPort("http", "tcp")).
+			AllowEgress(util.NewNetworkPolicyRule().
+				IPBlock("10.128.0.0/14", "10.130.0.0/16").
+				PortRange(8000, 8080, "TCP").
+				Port(9000, "SCTP")).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(policy.Labels).To(HaveKeyWithValue("managed", "openshift-testing"))
+		Expect(policy.Spec.PolicyTypes).To(ConsistOf(netv1.PolicyTypeIngress, netv1.PolicyTypeEgress))
+		Expect(policy.Spec.Ingress).To(HaveLen(1))
+		Expect(policy.Spec.Ingress[0].From[0].NamespaceSelector.MatchLabels).To(Equal(map[string]string{"team": "blue"}))
+		Expect(*policy.Spec.Ingress[0].Ports[0].Port).To(Equal(intstr.FromString("http")))
+
+		egressPorts := policy.Spec.Egress[0].Ports
+		Expect(egressPorts[0].Port.IntValue()).To(Equal(8000))
+		Expect(*egressPorts[0].EndPort).To(BeEquivalentTo(8080))
+		Expect(*egressPorts[1].Protocol).To(Equal(corev1.ProtocolSCTP))
+	})
+
+	It("should build deny-all policies that the simulator enforces", func() {
+		policy, err := util.NewNetworkPolicyBuilder("x", "deny-all").DenyAllIngress().DenyAllEgress().Build()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(policy.Spec.PolicyTypes).To(ConsistOf(netv1.PolicyTypeIngress, netv1.PolicyTypeEgress))
+
+		simulator, err := util.NewPolicySimulator(simNamespaces, []netv1.NetworkPolicy{*policy})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(simulator.Allowed(simY, simX, 80, corev1.ProtocolTCP)).To(BeFalse())
+		Expect(simulator.Allowed(simX, simY, 80, corev1.ProtocolTCP)).To(BeFalse())
+	})
+
+	It("should not share state between built policies", func() {
+		builder := util.NewNetworkPolicyBuilder("x", "web").DenyAllIngress()
+		first, err := builder.Build()
+		Expect(err).ToNot(HaveOccurred())
+
+		builder.AllowIngress(util.NewNetworkPolicyRule().Namespace("y"))
+		Expect(first.Spec.Ingress).To(BeEmpty())
+	})
+
+	DescribeTable("reports invalid input",
+		func(builder *util.NetworkPolicyBuilder, problem string) {
+			_, err := builder.Build()
+			Expect(err).To(MatchError(ContainSubstring(problem)))
+		},
+		Entry("no policy types", util.NewNetworkPolicyBuilder("x", "empty"), "denies nothing"),
+		Entry("invalid name", util.NewNetworkPolicyBuilder("x", "Not_Valid").DenyAllIngress(), `name "Not_Valid"`),
+		Entry("missing namespace", util.NewNetworkPolicyBuilder("", "web").DenyAllIngress(), "namespace must not be empty"),
+		Entry("unknown protocol", util.NewNetworkPolicyBuilder("x", "web").
+			AllowIngress(util.NewNetworkPolicyRule().Port(80, "ICMP")), `unsupported protocol "ICMP"`),
+		Entry("port out of range", util.NewNetworkPolicyBuilder("x", "web").
+			AllowIngress(util.NewNetworkPolicyRule().Port(70000, "TCP")), "port 70000 is outside 1-65535"),
+		Entry("reversed port range", util.NewNetworkPolicyBuilder("x", "web").
+			AllowIngress(util.NewNetworkPolicyRule().PortRange(90, 80, "TCP")), "ends before it starts"),
+		Entry("invalid port name", util.NewNetworkPolicyBuilder("x", "web").
+			AllowIngress(util.NewNetworkPolicyRule().NamedPort("not a port", "TCP")), `invalid port name "not a port"`),
+		Entry("invalid CIDR", util.NewNetworkPolicyBuilder("x", "web").
+			AllowEgress(util.NewNetworkPolicyRule().IPBlock("10.0.0.0/33")), `invalid ipBlock CIDR "10.0.0.0/33"`),
+		Entry("except outside the CIDR", util.NewNetworkPolicyBuilder("x", "web").
+			AllowEgress(util.NewNetworkPolicyRule().IPBlock("10.0.0.0/16", "10.1.0.0/24")), "10.1.0.0/24 is not inside 10.0.0.0/16"),
+		Entry("except equal to the CIDR", util.NewNetworkPolicyBuilder("x", "web").
+			AllowEgress(util.NewNetworkPolicyRule().IPBlock("10.0.0.0/16", "10.0.0.0/16")), "10.0.0.0/16 is not inside 10.0.0.0/16"),
+		Entry("invalid selector", util.NewNetworkPolicyBuilder("x", "web").
+			SelectPods(map[string]string{"app": "not valid!"}).DenyAllIngress(), "invalid pod selector"),
+		Entry("empty peer", util.NewNetworkPolicyBuilder("x", "web").
+			AllowIngress(util.NewNetworkPolicyRule().Peer(nil, nil)), "needs a pod or namespace selector"),
+	)
+})
+
+var _ = Describe("CreateNetworkPolicyPort", func() {
+	It("should set an integer port and the protocol", func() {
+		ports, err := util.CreateNetworkPolicyPort(5060, "SCTP")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ports).To(HaveLen(1))
+		Expect(ports[0].Port.Type).To(Equal(intstr.Int))
+		Expect(ports[0].Port.IntValue()).To(Equal(5060))
+		Expect(*ports[0].Protocol).To(Equal(corev1.ProtocolSCTP))
+	})
+
+	It("should reject unknown protocols", func() {
+		_, err := util.CreateNetworkPolicyPort(80, "HTTP")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("CreateNetworkPolicy", func() {
+	It("should create the built policy", func() {
+		clientset := fake.NewSimpleClientset()
+		policy, err := util.NewNetworkPolicyBuilder("x", "web").DenyAllIngress().Build()
+		Expect(err).ToNot(HaveOccurred())
+
+		created, err := util.CreateNetworkPolicy(clientset, policy)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(created.Namespace).To(Equal("x"))
+	})
+})
@@ -0,0 +1,341 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+// namespaceNameLabel is set by Kubernetes on every namespace
+const namespaceNameLabel = "kubernetes.io/metadata.name"
+
+// PolicySimulator decides offline whether NetworkPolicies allow traffic between endpoints.
+// It follows the Kubernetes semantics: a pod selected by a policy of a direction is isolated in
+// that direction and only accepts what some rule allows, and traffic needs both the egress of the
+// source and the ingress of the destination. Traffic from an endpoint to itself is always allowed,
+// and ipBlocks are matched against endpoint IPs as well as external addresses.
+type PolicySimulator struct {
+	namespaces map[string]map[string]string
+	policies   []compiledPolicy
+}
+
+type compiledPolicy struct {
+	name         string
+	namespace    string
+	podSelector  labels.Selector
+	ingress      bool
+	egress       bool
+	ingressRules []compiledRule
+	egressRules  []compiledRule
+}
+
+type compiledRule struct {
+	peers    []compiledPeer // Empty means every peer
+	ports    []netv1.NetworkPolicyPort
+	allPeers bool
+}
+
+type compiledPeer struct {
+	podSelector       labels.Selector // nil when not set
+	namespaceSelector labels.Selector // nil when not set
+	ipBlock           *compiledIPBlock
+}
+
+type compiledIPBlock struct {
+	cidr   *net.IPNet
+	except []*net.IPNet
+}
+
+// NewPolicySimulator compiles the policies. namespaces maps namespace names to their labels,
+// the kubernetes.io/metadata.name label is added automatically and missing namespaces have no other labels.
+func NewPolicySimulator(namespaces map[string]map[string]string, policies []netv1.NetworkPolicy) (*PolicySimulator, error) {
+	simulator := &PolicySimulator{namespaces: map[string]map[string]string{}}
+	for name, namespaceLabels := range namespaces {
+		simulator.namespaces[name] = MergeLabels(namespaceLabels, map[string]string{namespaceNameLabel: name})
+	}
+
+	for i := range policies {
+		compiled, err := compilePolicy(&policies[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid NetworkPolicy %s/%s: %v", policies[i].Namespace, policies[i].Name, err)
+		}
+		simulator.policies = append(simulator.policies, compiled)
+	}
+	return simulator, nil
+}
+
+// NewPolicySimulatorFromCluster reads the labels and NetworkPolicies of the namespaces from the cluster
+func NewPolicySimulatorFromCluster(clientset kubernetes.Interface, namespaces ...string) (*PolicySimulator, error) {
+	namespaceLabels := map[string]map[string]string{}
+	var policies []netv1.NetworkPolicy
+	for _, namespace := range namespaces {
+		if _, ok := namespaceLabels[namespace]; ok {
+			continue
+		}
+		ns, err := clientset.CoreV1().Namespaces().Get(context.TODO(), namespace, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get namespace %s: %v", namespace, err)
+		}
+		namespaceLabels[namespace] = ns.Labels
+
+		list, err := clientset.NetworkingV1().NetworkPolicies(namespace).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list network policies in %s: %v", namespace, err)
+		}
+		policies = append(policies, list.Items...)
+	}
+
+	LogDebug("Simulating %d network policies in namespaces %v", len(policies), namespaces)
+	return NewPolicySimulator(namespaceLabels, policies)
+}
+
+// Allowed reports whether the policies allow traffic from one endpoint to another on the port and
+// protocol. Whether anything listens there is up to Reachability.
+func (s *PolicySimulator) Allowed(from, to Endpoint, port int, protocol corev1.Protocol) bool {
+	if protocol == "" {
+		protocol = corev1.ProtocolTCP
+	}
+	if from.Name == to.Name {
+		return true
+	}
+	return s.egressAllowed(from, to, port, protocol) && s.ingressAllowed(from, to, port, protocol)
+}
+
+// Reachability builds the expected table of a connectivity matrix on the port. A destination is
+// reachable when it listens on the port (see Endpoint.Listens) and the policies allow the traffic.
+func (s *PolicySimulator) Reachability(endpoints []Endpoint, port MatrixPort) *Reachability {
+	names := make([]string, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		names = append(names, endpoint.Name)
+	}
+
+	reachability := NewReachability(names, false)
+	for _, from := range endpoints {
+		for _, to := range endpoints {
+			reachable := to.Listens(port) && s.Allowed(from, to, port.Port, port.Protocol)
+			reachability.Expect(from.Name, to.Name, reachable)
+		}
+	}
+	return reachability
+}
+
+func (s *PolicySimulator) ingressAllowed(from, to Endpoint, port int, protocol corev1.Protocol) bool {
+	isolated := false
+	for _, policy := range s.policies {
+		if !policy.ingress || !policy.selects(to) {
+			continue
+		}
+		isolated = true
+		for _, rule := range policy.ingressRules {
+			if rule.matches(s, policy.namespace, from, to, port, protocol) {
+				return true
+			}
+		}
+	}
+	return !isolated
+}
+
+func (s *PolicySimulator) egressAllowed(from, to Endpoint, port int, protocol corev1.Protocol) bool {
+	isolated := false
+	for _, policy := range s.policies {
+		if !policy.egress || !policy.selects(from) {
+			continue
+		}
+		isolated = true
+		for _, rule := range policy.egressRules {
+			if rule.matches(s, policy.namespace, to, to, port, protocol) {
+				return true
+			}
+		}
+	}
+	return !isolated
+}
+
+func (s *PolicySimulator) namespaceLabels(namespace string) labels.Set {
+	if namespaceLabels, ok := s.namespaces[namespace]; ok {
+		return namespaceLabels
+	}
+	return labels.Set{namespaceNameLabel: namespace}
+}
+
+func (p compiledPolicy) selects(endpoint Endpoint) bool {
+	return endpoint.Namespace == p.namespace && p.podSelector.Matches(labels.Set(endpoint.Labels))
+}
+
+// matches checks the peer and the port of a rule. destination owns the named ports.
+func (r compiledRule) matches(s *PolicySimulator, policyNamespace string, peer, destination Endpoint, port int, protocol corev1.Protocol) bool {
+	if !r.allPeers {
+		found := false
+		for _, candidate := range r.peers {
+			if candidate.matches(s, policyNamespace, peer) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(r.ports) == 0 {
+		return true
+	}
+	for _, policyPort := range r.ports {
+		if portMatches(policyPort, destination, port, protocol) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p compiledPeer) matches(s *PolicySimulator, policyNamespace string, endpoint Endpoint) bool {
+	if p.ipBlock != nil {
+		return p.ipBlock.contains(endpoint.IP)
+	}
+
+	if p.namespaceSelector == nil {
+		if endpoint.Namespace != policyNamespace {
+			return false
+		}
+	} else if !p.namespaceSelector.Matches(s.namespaceLabels(endpoint.Namespace)) {
+		return false
+	}
+	return p.podSelector == nil || p.podSelector.Matches(labels.Set(endpoint.Labels))
+}
+
+func (b *compiledIPBlock) contains(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil || !b.cidr.Contains(ip) {
+		return false
+	}
+	for _, except := range b.except {
+		if except.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// portMatches checks a policy port against the traffic, resolving named ports on the destination
+func portMatches(policyPort netv1.NetworkPolicyPort, destination Endpoint, port int, protocol corev1.Protocol) bool {
+	policyProtocol := corev1.ProtocolTCP
+	if policyPort.Protocol != nil {
+		policyProtocol = *policyPort.Protocol
+	}
+	if policyProtocol != protocol {
+		return false
+	}
+	if policyPort.Port == nil {
+		return true
+	}
+
+	if policyPort.Port.Type == intstr.String {
+		for _, containerPort := range destination.Ports {
+			containerProtocol := containerPort.Protocol
+			if containerProtocol == "" {
+				containerProtocol = corev1.ProtocolTCP
+			}
+			if containerPort.Name == policyPort.Port.StrVal && containerProtocol == protocol && int(containerPort.ContainerPort) == port {
+				return true
+			}
+		}
+		return false
+	}
+
+	start := policyPort.Port.IntValue()
+	if policyPort.EndPort != nil {
+		return port >= start && port <= int(*policyPort.EndPort)
+	}
+	return port == start
+}
+
+func compilePolicy(policy *netv1.NetworkPolicy) (compiledPolicy, error) {
+	podSelector, err := metav1.LabelSelectorAsSelector(&policy.Spec.PodSelector)
+	if err != nil {
+		return compiledPolicy{}, err
+	}
+	compiled := compiledPolicy{name: policy.Name, namespace: policy.Namespace, podSelector: podSelector}
+
+	// Without policyTypes, Ingress always applies and Egress only if there are egress rules
+	if len(policy.Spec.PolicyTypes) == 0 {
+		compiled.ingress = true
+		compiled.egress = len(policy.Spec.Egress) > 0
+	}
+	for _, policyType := range policy.Spec.PolicyTypes {
+		switch policyType {
+		case netv1.PolicyTypeIngress:
+			compiled.ingress = true
+		case netv1.PolicyTypeEgress:
+			compiled.egress = true
+		}
+	}
+
+	for _, rule := range policy.Spec.Ingress {
+		compiledRule, err := compileRule(rule.From, rule.Ports)
+		if err != nil {
+			return compiledPolicy{}, err
+		}
+		compiled.ingressRules = append(compiled.ingressRules, compiledRule)
+	}
+	for _, rule := range policy.Spec.Egress {
+		compiledRule, err := compileRule(rule.To, rule.Ports)
+		if err != nil {
+			return compiledPolicy{}, err
+		}
+		compiled.egressRules = append(compiled.egressRules, compiledRule)
+	}
+	return compiled, nil
+}
+
+func compileRule(peers []netv1.NetworkPolicyPeer, ports []netv1.NetworkPolicyPort) (compiledRule, error) {
+	rule := compiledRule{ports: ports, allPeers: len(peers) == 0}
+	for _, peer := range peers {
+		var compiled compiledPeer
+		if peer.IPBlock != nil {
+			ipBlock, err := compileIPBlock(peer.IPBlock)
+			if err != nil {
+				return compiledRule{}, err
+			}
+			compiled.ipBlock = ipBlock
+		}
+		if peer.PodSelector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(peer.PodSelector)
+			if err != nil {
+				return compiledRule{}, err
+			}
+			compiled.podSelector = selector
+		}
+		if peer.NamespaceSelector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(peer.NamespaceSelector)
+			if err != nil {
+				return compiledRule{}, err
+			}
+			compiled.namespaceSelector = selector
+		}
+		rule.peers = append(rule.peers, compiled)
+	}
+	return rule, nil
+}
+
+func compileIPBlock(ipBlock *netv1.IPBlock) (*compiledIPBlock, error) {
+	_, cidr, err := net.ParseCIDR(ipBlock.CIDR)
+	if err != nil {
+		return nil, err
+	}
+	compiled := &compiledIPBlock{cidr: cidr}
+	for _, except := range ipBlock.Except {
+		_, exceptNet, err := net.ParseCIDR(except)
+		if err != nil {
+			return nil, err
+		}
+		compiled.except = append(compiled.except, exceptNet)
+	}
+	return compiled, nil
+}
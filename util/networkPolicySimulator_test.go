@@ -0,0 +1,169 @@
+package util_test
+
+import (
+	"myproject/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var (
+	simX = util.Endpoint{Name: "x/a", Namespace: "x", IP: "10.128.0.10", Labels: map[string]string{"app": "a"},
+		Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 80}, {Name: "dns", ContainerPort: 53, Protocol: corev1.ProtocolUDP}}}
+	simXB = util.Endpoint{Name: "x/b", Namespace: "x", IP: "10.128.0.11", Labels: map[string]string{"app": "b"}}
+	simY  = util.Endpoint{Name: "y/a", Namespace: "y", IP: "10.129.0.10", Labels: map[string]string{"app": "a"}}
+	simZ  = util.Endpoint{Name: "z/c", Namespace: "z", IP: "10.130.0.10", Labels: map[string]string{"app": "c"}}
+
+	simNamespaces = map[string]map[string]string{"x": {"team": "blue"}, "y": {"team": "blue"}, "z": {"team": "red"}}
+)
+
+func simPolicy(namespace string, selector map[string]string, types []netv1.PolicyType, ingress []netv1.NetworkPolicyIngressRule, egress []netv1.NetworkPolicyEgressRule) netv1.NetworkPolicy {
+	return netv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: namespace},
+		Spec: netv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: selector},
+			PolicyTypes: types,
+			Ingress:     ingress,
+			Egress:      egress,
+		},
+	}
+}
+
+func simPort(port intstr.IntOrString, endPort *int32, protocol corev1.Protocol) netv1.NetworkPolicyPort {
+	return netv1.NetworkPolicyPort{Port: &port, EndPort: endPort, Protocol: &protocol}
+}
+
+var (
+	denyAllIngress    = simPolicy("x", nil, []netv1.PolicyType{netv1.PolicyTypeIngress}, nil, nil)
+	denyAllEgress     = simPolicy("x", nil, []netv1.PolicyType{netv1.PolicyTypeEgress}, nil, nil)
+	fromSameNamespace = simPolicy("x", nil, nil, []netv1.NetworkPolicyIngressRule{{
+		From: []netv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}},
+	}}, nil)
+	fromBlueNamespaces = simPolicy("x", map[string]string{"app": "a"}, nil, []netv1.NetworkPolicyIngressRule{{
+		From: []netv1.NetworkPolicyPeer{{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "blue"}}}},
+	}}, nil)
+	fromBlueAppA = simPolicy("x", nil, nil, []netv1.NetworkPolicyIngressRule{{
+		From: []netv1.NetworkPolicyPeer{{
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "blue"}},
+			PodSelector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": "a"}},
+		}},
+	}}, nil)
+	fromNamespaceY = simPolicy("x", nil, nil, []netv1.NetworkPolicyIngressRule{{
+		From: []netv1.NetworkPolicyPeer{{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"kubernetes.io/metadata.name": "y"}}}},
+	}}, nil)
+	fromIPBlock = simPolicy("x", nil, nil, []netv1.NetworkPolicyIngressRule{{
+		From: []netv1.NetworkPolicyPeer{{IPBlock: &netv1.IPBlock{CIDR: "10.128.0.0/14", Except: []string{"10.130.0.0/16"}}}},
+	}}, nil)
+	namedHTTPPort = simPolicy("x", nil, nil, []netv1.NetworkPolicyIngressRule{{
+		Ports: []netv1.NetworkPolicyPort{simPort(intstr.FromString("http"), nil, corev1.ProtocolTCP)},
+	}}, nil)
+	namedDNSPort = simPolicy("x", nil, nil, []netv1.NetworkPolicyIngressRule{{
+		Ports: []netv1.NetworkPolicyPort{simPort(intstr.FromString("dns"), nil, corev1.ProtocolUDP)},
+	}}, nil)
+	portRange = simPolicy("x", nil, nil, []netv1.NetworkPolicyIngressRule{{
+		Ports: []netv1.NetworkPolicyPort{simPort(intstr.FromInt32(8000), int32Ptr(8080), corev1.ProtocolTCP)},
+	}}, nil)
+	egressToY = simPolicy("x", nil, []netv1.PolicyType{netv1.PolicyTypeEgress}, nil, []netv1.NetworkPolicyEgressRule{{
+		To:    []netv1.NetworkPolicyPeer{{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"kubernetes.io/metadata.name": "y"}}}},
+		Ports: []netv1.NetworkPolicyPort{simPort(intstr.FromInt32(80), nil, corev1.ProtocolTCP)},
+	}})
+	denyAllIngressZ = simPolicy("z", nil, []netv1.PolicyType{netv1.PolicyTypeIngress}, nil, nil)
+)
+
+func int32Ptr(value int32) *int32 {
+	return &value
+}
+
+var _ = Describe("PolicySimulator", func() {
+	DescribeTable("decides whether traffic is allowed",
+		func(policies []netv1.NetworkPolicy, from, to util.Endpoint, port int, protocol corev1.Protocol, expected bool) {
+			simulator, err := util.NewPolicySimulator(simNamespaces, policies)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(simulator.Allowed(from, to, port, protocol)).To(Equal(expected))
+		},
+		Entry("no policies", nil, simY, simX, 80, corev1.ProtocolTCP, true),
+		Entry("deny all ingress", []netv1.NetworkPolicy{denyAllIngress}, simY, simX, 80, corev1.ProtocolTCP, false),
+		Entry("deny all ingress keeps egress", []netv1.NetworkPolicy{denyAllIngress}, simX, simY, 80, corev1.ProtocolTCP, true),
+		Entry("deny all ingress keeps loopback", []netv1.NetworkPolicy{denyAllIngress}, simX, simX, 80, corev1.ProtocolTCP, true),
+		Entry("deny all egress", []netv1.NetworkPolicy{denyAllEgress}, simX, simY, 80, corev1.ProtocolTCP, false),
+		Entry("deny all egress keeps ingress", []netv1.NetworkPolicy{denyAllEgress}, simY, simX, 80, corev1.ProtocolTCP, true),
+		Entry("same namespace allowed", []netv1.NetworkPolicy{fromSameNamespace}, simXB, simX, 80, corev1.ProtocolTCP, true),
+		Entry("other namespace blocked", []netv1.NetworkPolicy{fromSameNamespace}, simY, simX, 80, corev1.ProtocolTCP, false),
+		Entry("namespace selector matches", []netv1.NetworkPolicy{fromBlueNamespaces}, simY, simX, 80, corev1.ProtocolTCP, true),
+		Entry("namespace selector does not match", []netv1.NetworkPolicy{fromBlueNamespaces}, simZ, simX, 80, corev1.ProtocolTCP, false),
+		Entry("unselected pod stays open", []netv1.NetworkPolicy{fromBlueNamespaces}, simZ, simXB, 80, corev1.ProtocolTCP, true),
+		Entry("namespace and pod selector match", []netv1.NetworkPolicy{fromBlueAppA}, simY, simXB, 80, corev1.ProtocolTCP, true),
+		Entry("namespace matches but pod does not", []netv1.NetworkPolicy{fromBlueAppA}, simXB, simX, 80, corev1.ProtocolTCP, false),
+		Entry("namespace name label", []netv1.NetworkPolicy{fromNamespaceY}, simY, simX, 80, corev1.ProtocolTCP, true),
+		Entry("ipBlock matches", []netv1.NetworkPolicy{fromIPBlock}, simY, simX, 80, corev1.ProtocolTCP, true),
+		Entry("ipBlock except", []netv1.NetworkPolicy{fromIPBlock}, simZ, simX, 80, corev1.ProtocolTCP, false),
+		Entry("named port matches", []netv1.NetworkPolicy{namedHTTPPort}, simY, simX, 80, corev1.ProtocolTCP, true),
+		Entry("named port on another number", []netv1.NetworkPolicy{namedHTTPPort}, simY, simX, 8080, corev1.ProtocolTCP, false),
+		Entry("named port missing on destination", []netv1.NetworkPolicy{namedHTTPPort}, simY, simXB, 80, corev1.ProtocolTCP, false),
+		Entry("named UDP port", []netv1.NetworkPolicy{namedDNSPort}, simY, simX, 53, corev1.ProtocolUDP, true),
+		Entry("named UDP port over TCP", []netv1.NetworkPolicy{namedDNSPort}, simY, simX, 53, corev1.ProtocolTCP, false),
+		Entry("port range start", []netv1.NetworkPolicy{portRange}, simY, simX, 8000, corev1.ProtocolTCP, true),
+		Entry("port range end", []netv1.NetworkPolicy{portRange}, simY, simX, 8080, corev1.ProtocolTCP, true),
+		Entry("port out of range", []netv1.NetworkPolicy{portRange}, simY, simX, 8081, corev1.ProtocolTCP, false),
+		Entry("port range is TCP only", []netv1.NetworkPolicy{portRange}, simY, simX, 8000, corev1.ProtocolUDP, false),
+		Entry("egress rule allows", []netv1.NetworkPolicy{egressToY}, simX, simY, 80, corev1.ProtocolTCP, true),
+		Entry("egress rule port", []netv1.NetworkPolicy{egressToY}, simX, simY, 443, corev1.ProtocolTCP, false),
+		Entry("egress rule peer", []netv1.NetworkPolicy{egressToY}, simX, simZ, 80, corev1.ProtocolTCP, false),
+		Entry("egress allowed but ingress denied", []netv1.NetworkPolicy{egressToY, denyAllIngressZ}, simX, simZ, 80, corev1.ProtocolTCP, false),
+		Entry("policies are additive", []netv1.NetworkPolicy{denyAllIngress, fromNamespaceY}, simY, simX, 80, corev1.ProtocolTCP, true),
+	)
+
+	It("should reject invalid policies", func() {
+		invalid := simPolicy("x", nil, nil, []netv1.NetworkPolicyIngressRule{{
+			From: []netv1.NetworkPolicyPeer{{IPBlock: &netv1.IPBlock{CIDR: "not-a-cidr"}}},
+		}}, nil)
+		_, err := util.NewPolicySimulator(simNamespaces, []netv1.NetworkPolicy{invalid})
+		Expect(err).To(MatchError(ContainSubstring("invalid NetworkPolicy x/policy")))
+	})
+
+	It("should build the expected reachability of a matrix", func() {
+		simulator, err := util.NewPolicySimulator(simNamespaces, []netv1.NetworkPolicy{fromSameNamespace})
+		Expect(err).ToNot(HaveOccurred())
+
+		listening := []corev1.ContainerPort{{ContainerPort: 80}}
+		serverXB, serverY := simXB, simY
+		serverXB.Ports, serverY.Ports = listening, listening
+		reachability := simulator.Reachability([]util.Endpoint{simX, serverXB, serverY, simZ}, util.MatrixPort{Port: 80})
+		Expect(reachability.Get("x/b", "x/a")).To(BeTrue())
+		Expect(reachability.Get("x/a", "x/a")).To(BeTrue())
+		Expect(reachability.Get("y/a", "x/a")).To(BeFalse())
+		Expect(reachability.Get("y/a", "x/b")).To(BeFalse())
+		Expect(reachability.Get("x/a", "y/a")).To(BeTrue())
+	})
+
+	It("should not reach destinations that don't listen on the port", func() {
+		simulator, err := util.NewPolicySimulator(simNamespaces, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		reachability := simulator.Reachability([]util.Endpoint{simX, simZ}, util.MatrixPort{Port: 80})
+		Expect(reachability.Get("z/c", "x/a")).To(BeTrue())
+		Expect(reachability.Get("x/a", "z/c")).To(BeFalse())
+		Expect(reachability.Get("z/c", "z/c")).To(BeFalse())
+
+		udp := simulator.Reachability([]util.Endpoint{simX, simZ}, util.MatrixPort{Port: 53, Protocol: corev1.ProtocolUDP})
+		Expect(udp.Get("z/c", "x/a")).To(BeTrue())
+		Expect(simulator.Reachability([]util.Endpoint{simX, simZ}, util.MatrixPort{Port: 53}).Get("z/c", "x/a")).To(BeFalse())
+	})
+
+	It("should load the policies and namespace labels from the cluster", func() {
+		clientset := fake.NewSimpleClientset(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "x"}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "y", Labels: map[string]string{"team": "blue"}}},
+			&fromBlueNamespaces,
+		)
+
+		simulator, err := util.NewPolicySimulatorFromCluster(clientset, "x", "y", "x")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(simulator.Allowed(simY, simX, 80, corev1.ProtocolTCP)).To(BeTrue())
+		Expect(simulator.Allowed(simXB, simX, 80, corev1.ProtocolTCP)).To(BeFalse())
+	})
+})
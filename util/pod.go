@@ -4,14 +4,14 @@ import (
 	"context"
 	"time"
 	"bytes"
+	"errors"
 	"io"
 	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
-	"myproject/consts"
 )
 
 // ContainerConfig defines the configuration for a container in a Pod
@@ -21,26 +21,19 @@ type ContainerConfig struct {
 	Command   []string
 	Args      []string
 	Resources corev1.ResourceRequirements
+	Ports     []corev1.ContainerPort // Ports the container listens on, optional
 }
 
 // CreatePod creates a Pod with multiple containers specified by the ContainerConfig list.
-func CreatePod(config *rest.Config, namespace, podName string, containerConfigs []ContainerConfig, labels map[string]string, waitForCreation bool) (*corev1.Pod, error) {
+func CreatePod(clientset kubernetes.Interface, namespace, podName string, containerConfigs []ContainerConfig, labels map[string]string, waitForCreation bool) (*corev1.Pod, error) {
 	if podName == "" {
 		podName = GenerateRandomName()
 		LogInfo("Generated random Pod name: %s", podName)
 	}
 
 	if labels == nil {
-		labels = consts.DefaultLabels
-		labels["app"] = podName
-		LogInfo("Using default labels for VM: %s", podName)
-	}
-
-	// Create Kubernetes client
-	clientset, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		LogError("Failed to create Kubernetes client: %v", err)
-		return nil, err
+		labels = DefaultLabelsFor(podName)
+		LogInfo("Using default labels for Pod: %s", podName)
 	}
 
 	// Generate containers from the container configurations
@@ -54,7 +47,7 @@ func CreatePod(config *rest.Config, namespace, podName string, containerConfigs
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      podName,
 			Namespace: namespace,
-			Labels:    labels,
+			Labels:    MergeLabels(labels),
 		},
 		Spec: corev1.PodSpec{
 			Containers:    containers,
@@ -75,7 +68,7 @@ func CreatePod(config *rest.Config, namespace, podName string, containerConfigs
 
 	// Wait for the Pod to be ready, if requested
 	if waitForCreation {
-		err = WaitForPodState(clientset, namespace, podName, 10*time.Second, 120*time.Second, 0, true)
+		err = WaitForPodState(context.TODO(), clientset, namespace, podName, ExponentialBackoff(time.Second, 10*time.Second, 120*time.Second), true)
 		if err != nil {
 			LogError("Error waiting for Pod to be ready: %v", err)
 			return nil, err
@@ -87,7 +80,7 @@ func CreatePod(config *rest.Config, namespace, podName string, containerConfigs
 }
 
 // GetPod fetches the pod object from the Kubernetes API
-func GetPod(clientset *kubernetes.Clientset, namespace, podName string) (*corev1.Pod, error) {
+func GetPod(clientset kubernetes.Interface, namespace, podName string) (*corev1.Pod, error) {
 	pod, err := clientset.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
 	if err != nil {
 		LogError("Failed to fetch Pod %s in namespace %s: %v", podName, namespace, err)
@@ -99,24 +92,30 @@ func GetPod(clientset *kubernetes.Clientset, namespace, podName string) (*corev1
 }
 
 // RetryPodCreationWithWait creates a pod and waits for it to either run or complete successfully.
-func RetryPodCreationWithWait(clientset *kubernetes.Clientset, config *rest.Config, namespace, podName string, containers []ContainerConfig, labels map[string]string, interval, timeout time.Duration, retries int) (*corev1.Pod, error) {
+// Every attempt gets the full timeout and the whole operation stops when ctx is cancelled.
+// Only transient failures (see ClassifyPodFailure) and timeouts are retried, anything else is returned immediately.
+func RetryPodCreationWithWait(ctx context.Context, clientset kubernetes.Interface, namespace, podName string, containers []ContainerConfig, labels map[string]string, interval, timeout time.Duration, retries int) (*corev1.Pod, error) {
 	var createdPod *corev1.Pod
 	var err error
 
 	// Define the pod creation and check function
-	createAndCheckPod := func() (bool, error) {
+	createAndCheckPod := func(ctx context.Context) (bool, error) {
 		// Try creating the pod
 		LogInfo("Start Pod %s Creation", podName)
-		createdPod, err = CreatePod(config, namespace, podName, containers, labels, false)
+		createdPod, err = CreatePod(clientset, namespace, podName, containers, labels, false)
 		if err != nil {
 			LogWarn("Failed to create pod %s. Error: %v", podName, err)
+			// A rejected spec will be rejected again, so don't retry it
+			if apierrors.IsInvalid(err) || apierrors.IsBadRequest(err) {
+				return true, err
+			}
 			return false, err
 		}
 
 		LogInfo("Pod %s created successfully.", podName)
 
 		// Wait for the pod to reach the Running state or succeed
-		err = WaitForPodState(clientset, namespace, podName, interval, timeout, 0, true)
+		err = WaitForPodState(ctx, clientset, namespace, podName, ExponentialBackoff(time.Second, interval, timeout), true)
 		if err == nil {
 			LogInfo("Pod %s is running or completed successfully.", podName)
 			return true, nil
@@ -125,18 +124,32 @@ func RetryPodCreationWithWait(clientset *kubernetes.Clientset, config *rest.Conf
 		LogWarn("Pod %s failed or did not reach running/completed state. Error: %v", podName, err)
 
 		// Delete the pod if it fails
-		delErr := clientset.CoreV1().Pods(namespace).Delete(context.TODO(), podName, metav1.DeleteOptions{})
+		delErr := clientset.CoreV1().Pods(namespace).Delete(ctx, podName, metav1.DeleteOptions{})
 		if delErr != nil {
 			LogError("Failed to delete pod %s after failure: %v", podName, delErr)
 		}
 
+		// Only transient failures are worth another attempt
+		if !errors.Is(err, ErrPodTransient) && !errors.Is(err, ErrWaitTimeout) {
+			LogError("Pod %s failed with an error a new pod won't fix, not retrying", podName)
+			return true, err
+		}
+
+		// The next attempt reuses the name, so let the old pod finish terminating first
+		waitErr := WaitForDeletion(ctx, "Pod "+podName, ExponentialBackoff(time.Second, interval, timeout), func(ctx context.Context) error {
+			_, getErr := clientset.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+			return getErr
+		})
+		if waitErr != nil {
+			LogError("Pod %s was not deleted after failure: %v", podName, waitErr)
+		}
 		return false, err
 	}
 
-	// Use WaitFor to try creating the pod and waiting for it to reach running state
-	err = WaitFor(createAndCheckPod, interval, timeout, retries)
+	// Use WaitForWithContext to try creating the pod and waiting for it to reach running state
+	err = WaitForWithContext(ctx, ConstantBackoff(interval, timeout*time.Duration(retries+1), retries), createAndCheckPod)
 	if err != nil {
-		LogError("Failed to create pod %s", podName, err)
+		LogError("Failed to create pod %s: %v", podName, err)
 		return nil, err
 	}
 
@@ -144,7 +157,7 @@ func RetryPodCreationWithWait(clientset *kubernetes.Clientset, config *rest.Conf
 }
 
 // GetPodLogs fetches the logs from a specific pod
-func GetPodLogs(clientset *kubernetes.Clientset, namespace, podName string) (string, error) {
+func GetPodLogs(clientset kubernetes.Interface, namespace, podName string) (string, error) {
     podLogOpts := corev1.PodLogOptions{}
     podLogRequest := clientset.CoreV1().Pods(namespace).GetLogs(podName, &podLogOpts)
     podLogs, err := podLogRequest.Stream(context.TODO())
@@ -179,5 +192,6 @@ func GenerateContainerFromConfig(config ContainerConfig) corev1.Container {
 		Command:   config.Command,
 		Args:      config.Args,
 		Resources: config.Resources,
+		Ports:     config.Ports,
 	}
 }
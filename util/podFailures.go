@@ -0,0 +1,84 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ErrPodUnrecoverable is wrapped by pod waits that hit a state which retrying cannot fix.
+var ErrPodUnrecoverable = errors.New("pod is in an unrecoverable state")
+
+// ErrPodTransient is wrapped by pod waits that hit a failure which recreating the pod may fix, e.g. CrashLoopBackOff.
+var ErrPodTransient = errors.New("pod failed transiently")
+
+// PodFailureClass classifies why a pod is not (yet) running.
+type PodFailureClass int
+
+const (
+	// PodFailureNone means nothing indicates a failure (yet).
+	PodFailureNone PodFailureClass = iota
+	// PodFailureTransient means the pod failed in a way that a retry may resolve.
+	PodFailureTransient
+	// PodFailureTerminal means the pod will not recover without changing its spec or the cluster.
+	PodFailureTerminal
+)
+
+func (c PodFailureClass) String() string {
+	switch c {
+	case PodFailureTransient:
+		return "transient"
+	case PodFailureTerminal:
+		return "terminal"
+	default:
+		return "none"
+	}
+}
+
+// TerminalWaitingReasons lists container waiting reasons that will not resolve by waiting or recreating the pod.
+var TerminalWaitingReasons = map[string]bool{
+	"ErrImagePull":               true,
+	"ImagePullBackOff":           true,
+	"InvalidImageName":           true,
+	"ErrImageNeverPull":          true,
+	"CreateContainerConfigError": true,
+}
+
+// TransientWaitingReasons lists container waiting reasons that indicate a failure a retry may fix.
+var TransientWaitingReasons = map[string]bool{
+	"CrashLoopBackOff":     true,
+	"CreateContainerError": true,
+	"RunContainerError":    true,
+}
+
+// ClassifyPodFailure inspects the pod status and reports whether it is failing, and if so
+// whether the failure is terminal or transient, together with a short reason.
+func ClassifyPodFailure(pod *corev1.Pod) (PodFailureClass, string) {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse && condition.Reason == corev1.PodReasonUnschedulable {
+			return PodFailureTerminal, fmt.Sprintf("Unschedulable: %s", condition.Message)
+		}
+	}
+
+	statuses := append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...)
+	statuses = append(statuses, pod.Status.ContainerStatuses...)
+	class, reason := PodFailureNone, ""
+	for _, status := range statuses {
+		waiting := status.State.Waiting
+		if waiting == nil {
+			continue
+		}
+		if TerminalWaitingReasons[waiting.Reason] {
+			return PodFailureTerminal, fmt.Sprintf("container %s: %s %s", status.Name, waiting.Reason, waiting.Message)
+		}
+		if TransientWaitingReasons[waiting.Reason] {
+			class, reason = PodFailureTransient, fmt.Sprintf("container %s: %s", status.Name, waiting.Reason)
+		}
+	}
+
+	if class == PodFailureNone && pod.Status.Phase == corev1.PodFailed {
+		return PodFailureTransient, fmt.Sprintf("pod failed: %s", pod.Status.Reason)
+	}
+	return class, reason
+}
@@ -0,0 +1,123 @@
+package util_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"myproject/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func waitingPod(reason string) *corev1.Pod {
+	pod := newTestPod("server", corev1.PodPending)
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "httpd",
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason}},
+	}}
+	return pod
+}
+
+var _ = Describe("ClassifyPodFailure", func() {
+	DescribeTable("classifies pod states",
+		func(pod *corev1.Pod, expected util.PodFailureClass) {
+			class, _ := util.ClassifyPodFailure(pod)
+			Expect(class).To(Equal(expected))
+		},
+		Entry("pending pod", newTestPod("server", corev1.PodPending), util.PodFailureNone),
+		Entry("container creating", waitingPod("ContainerCreating"), util.PodFailureNone),
+		Entry("image pull error", waitingPod("ErrImagePull"), util.PodFailureTerminal),
+		Entry("invalid image name", waitingPod("InvalidImageName"), util.PodFailureTerminal),
+		Entry("missing config", waitingPod("CreateContainerConfigError"), util.PodFailureTerminal),
+		Entry("crash loop", waitingPod("CrashLoopBackOff"), util.PodFailureTransient),
+		Entry("failed pod", newTestPod("server", corev1.PodFailed), util.PodFailureTransient),
+		Entry("unschedulable pod", &corev1.Pod{Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{
+			Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: corev1.PodReasonUnschedulable,
+		}}}}, util.PodFailureTerminal),
+	)
+
+	It("should make WaitForPodState fail fast on unrecoverable pods", func() {
+		clientset := fake.NewSimpleClientset(waitingPod("ErrImagePull"))
+
+		start := time.Now()
+		err := util.WaitForPodState(context.Background(), clientset, "default", "server", util.ConstantBackoff(time.Second, time.Minute, 0), false)
+		Expect(errors.Is(err, util.ErrPodUnrecoverable)).To(BeTrue())
+		Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+	})
+})
+
+var _ = Describe("RetryPodCreationWithWait", func() {
+	var (
+		clientset *fake.Clientset
+		creates   int
+	)
+
+	// newRetryClient gives the first created pod the failing status and every later one a running status
+	newRetryClient := func(failing corev1.PodStatus) {
+		clientset = fake.NewSimpleClientset()
+		creates = 0
+		clientset.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			creates++
+			pod := action.(k8stesting.CreateAction).GetObject().(*corev1.Pod)
+			pod.Status = corev1.PodStatus{Phase: corev1.PodRunning}
+			if creates == 1 {
+				pod.Status = failing
+			}
+			return false, nil, nil
+		})
+	}
+
+	containers := []util.ContainerConfig{{Name: "httpd", Image: "httpd"}}
+
+	It("should recreate a pod that is crash looping while Running", func() {
+		newRetryClient(corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "httpd",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			}},
+		})
+
+		pod, err := util.RetryPodCreationWithWait(context.Background(), clientset, "default", "server", containers, nil, 100*time.Millisecond, 5*time.Second, 2)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pod.Status.ContainerStatuses).To(BeEmpty())
+		Expect(creates).To(Equal(2))
+	})
+
+	It("should wait for a failed pod to be deleted before creating it again", func() {
+		newRetryClient(corev1.PodStatus{Phase: corev1.PodFailed})
+		podsGVR := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+
+		// Like a pod in its grace period, the first deletion leaves the pod in place for a few gets
+		terminatingGets := -1
+		clientset.PrependReactor("delete", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if terminatingGets >= 0 {
+				return false, nil, nil
+			}
+			terminatingGets = 0
+			return true, nil, nil
+		})
+		clientset.PrependReactor("get", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if terminatingGets < 0 {
+				return false, nil, nil
+			}
+			if terminatingGets++; terminatingGets == 3 {
+				Expect(clientset.Tracker().Delete(podsGVR, "default", "server")).To(Succeed())
+			}
+			return false, nil, nil
+		})
+
+		pod, err := util.RetryPodCreationWithWait(context.Background(), clientset, "default", "server", containers, nil, 100*time.Millisecond, 5*time.Second, 2)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pod.Name).To(Equal("server"))
+		Expect(creates).To(Equal(2))
+		Expect(terminatingGets).To(BeNumerically(">=", 3))
+	})
+
+})
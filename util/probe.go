@@ -0,0 +1,268 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultProbeTimeout is used by probes created without a timeout
+const DefaultProbeTimeout = 5 * time.Second
+
+// probeReportPrefix starts the line every probe script prints last
+const probeReportPrefix = "PROBE "
+
+// ProbeErrorClass tells why a probe could not reach its target
+type ProbeErrorClass string
+
+const (
+	ProbeErrorNone    ProbeErrorClass = ""
+	ProbeErrorTimeout ProbeErrorClass = "timeout" // No answer in time, e.g. packets dropped by a NetworkPolicy
+	ProbeErrorRefused ProbeErrorClass = "refused" // The connection was actively refused
+	ProbeErrorDNS     ProbeErrorClass = "dns"     // The name could not be resolved
+	ProbeErrorUnknown ProbeErrorClass = "unknown"
+)
+
+// ProbeResult is the structured outcome of a probe
+type ProbeResult struct {
+	Probe      string          // Description of the probe
+	Reachable  bool            // The connection, echo or lookup succeeded
+	StatusCode int             // HTTP status code, 0 for other probes or when unreachable
+	Latency    time.Duration   // Time spent by the probe itself, without the executor overhead
+	ResolvedIP string          // Address the target name resolved to, if any
+	ErrorClass ProbeErrorClass // Set when not reachable
+	Output     string          // Raw output of the probe, for diagnostics
+}
+
+func (r ProbeResult) String() string {
+	if !r.Reachable {
+		return fmt.Sprintf("%s: unreachable (%s) after %s", r.Probe, r.ErrorClass, r.Latency.Round(time.Millisecond))
+	}
+	if r.StatusCode != 0 {
+		return fmt.Sprintf("%s: HTTP %d from %s in %s", r.Probe, r.StatusCode, r.ResolvedIP, r.Latency.Round(time.Millisecond))
+	}
+	return fmt.Sprintf("%s: reachable at %s in %s", r.Probe, r.ResolvedIP, r.Latency.Round(time.Millisecond))
+}
+
+// Probe is a connectivity check run as a POSIX shell script on the source of the traffic
+// (a pod or a VM), so the same probe works with every ProbeExecutor.
+type Probe interface {
+	// Script returns the shell script running the check. It must end by printing the report line.
+	Script() string
+	// Classify explains a non-zero exit code of the check using the output of the script
+	Classify(exitCode int, output string) ProbeErrorClass
+	String() string
+}
+
+// HTTPProbe sends a GET request with curl and records the status code
+type HTTPProbe struct {
+	URL     string
+	Timeout time.Duration
+}
+
+func (p HTTPProbe) Script() string {
+	host := p.URL
+	if parsed, err := url.Parse(p.URL); err == nil && parsed.Hostname() != "" {
+		host = parsed.Hostname()
+	}
+	body := fmt.Sprintf(`code=$(curl --silent --show-error --insecure --output /dev/null --max-time %d --write-out '%%{http_code}' %s); rc=$?`,
+		probeSeconds(p.Timeout), shellQuote(p.URL))
+	return probeScript(host, body)
+}
+
+// Classify maps curl exit codes
+func (p HTTPProbe) Classify(exitCode int, output string) ProbeErrorClass {
+	switch exitCode {
+	case 6:
+		return ProbeErrorDNS
+	case 7:
+		return ProbeErrorRefused
+	case 28:
+		return ProbeErrorTimeout
+	default:
+		return ProbeErrorUnknown
+	}
+}
+
+func (p HTTPProbe) String() string {
+	return fmt.Sprintf("HTTP %s", p.URL)
+}
+
+// TCPProbe opens a TCP connection
+type TCPProbe struct {
+	Host    string
+	Port    int
+	Timeout time.Duration
+}
+
+func (p TCPProbe) Script() string {
+	body := fmt.Sprintf(`timeout %d bash -c 'exec 3<>"/dev/tcp/$0/$1"' "$host" %d; rc=$?`, probeSeconds(p.Timeout), p.Port)
+	return probeScript(p.Host, body)
+}
+
+func (p TCPProbe) Classify(exitCode int, output string) ProbeErrorClass {
+	return classifySocketError(exitCode, output)
+}
+
+func (p TCPProbe) String() string {
+	return fmt.Sprintf("TCP %s:%d", p.Host, p.Port)
+}
+
+// UDPProbe sends a payload to a UDP echo server and expects it back.
+// The reply is read with dd, since bash reads byte by byte and would lose the rest of the datagram.
+type UDPProbe struct {
+	Host    string
+	Port    int
+	Payload string // Defaults to "probe"
+	Timeout time.Duration
+}
+
+func (p UDPProbe) Script() string {
+	payload := p.Payload
+	if payload == "" {
+		payload = "probe"
+	}
+	body := fmt.Sprintf(`reply=$(timeout %d bash -c 'exec 3<>"/dev/udp/$0/$1" && printf "%%s\n" "$2" >&3 && dd bs=65535 count=1 status=none <&3' "$host" %d %s); rc=$?; `+
+		`if [ $rc -eq 0 ] && [ "$reply" != %s ]; then echo "unexpected echo: $reply"; rc=1; fi`,
+		probeSeconds(p.Timeout), p.Port, shellQuote(payload), shellQuote(payload))
+	return probeScript(p.Host, body)
+}
+
+func (p UDPProbe) Classify(exitCode int, output string) ProbeErrorClass {
+	return classifySocketError(exitCode, output)
+}
+
+func (p UDPProbe) String() string {
+	return fmt.Sprintf("UDP %s:%d", p.Host, p.Port)
+}
+
+// DNSProbe resolves a name through the resolver of the source
+type DNSProbe struct {
+	Name    string
+	Timeout time.Duration
+}
+
+func (p DNSProbe) Script() string {
+	body := fmt.Sprintf(`out=$(timeout %d getent ahosts "$host"); rc=$?; ip=${out%%%% *}`, probeSeconds(p.Timeout))
+	return probeScript(p.Name, body)
+}
+
+func (p DNSProbe) Classify(exitCode int, output string) ProbeErrorClass {
+	if exitCode == 124 {
+		return ProbeErrorTimeout
+	}
+	return ProbeErrorDNS
+}
+
+func (p DNSProbe) String() string {
+	return fmt.Sprintf("DNS %s", p.Name)
+}
+
+// RunProbe runs the probe with the executor and parses its report.
+// An unreachable target is not an error, it is described by the result; errors mean the probe could not run.
+func RunProbe(ctx context.Context, executor ProbeExecutor, probe Probe) (ProbeResult, error) {
+	output, err := executor.RunScript(ctx, probe.Script())
+	if err != nil {
+		LogError("Failed to run probe %s from %s: %v", probe, executor, err)
+		return ProbeResult{Probe: probe.String(), Output: output}, fmt.Errorf("failed to run probe %s from %s: %v", probe, executor, err)
+	}
+
+	result, err := ParseProbeOutput(probe, output)
+	if err != nil {
+		return result, err
+	}
+	LogDebug("Probe from %s: %s", executor, result)
+	return result, nil
+}
+
+// ParseProbeOutput builds the result from the output of a probe script
+func ParseProbeOutput(probe Probe, output string) (ProbeResult, error) {
+	result := ProbeResult{Probe: probe.String(), Output: output}
+
+	var report string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, probeReportPrefix) {
+			report = strings.TrimPrefix(line, probeReportPrefix)
+		}
+	}
+	if report == "" {
+		return result, fmt.Errorf("probe %s printed no report: %q", probe, output)
+	}
+
+	fields := map[string]string{}
+	for _, field := range strings.Fields(report) {
+		if key, value, found := strings.Cut(field, "="); found {
+			fields[key] = value
+		}
+	}
+
+	exitCode, err := strconv.Atoi(fields["rc"])
+	if err != nil {
+		return result, fmt.Errorf("probe %s reported an invalid exit code: %q", probe, report)
+	}
+	if nanoseconds, err := strconv.ParseInt(fields["ns"], 10, 64); err == nil {
+		result.Latency = time.Duration(nanoseconds)
+	}
+	result.ResolvedIP = fields["ip"]
+
+	result.Reachable = exitCode == 0
+	if result.Reachable {
+		// curl reports 000 when no response was received
+		if code, err := strconv.Atoi(fields["code"]); err == nil {
+			result.StatusCode = code
+		}
+	} else {
+		result.ErrorClass = probe.Classify(exitCode, output)
+	}
+	return result, nil
+}
+
+// probeScript wraps the body of a check: it resolves the host, times the body and prints the report line.
+// The body runs with $host set and must set $rc, and may set $code and $ip.
+func probeScript(host, body string) string {
+	return strings.Join([]string{
+		"host=" + shellQuote(host),
+		`ip=$(getent ahosts "$host" 2>/dev/null | head -n 1 | cut -d ' ' -f 1)`,
+		"code=",
+		"start=$(date +%s%N)",
+		body,
+		"end=$(date +%s%N)",
+		fmt.Sprintf(`echo "%src=$rc ns=$((end-start)) ip=$ip code=$code"`, probeReportPrefix),
+	}, "\n")
+}
+
+// classifySocketError explains failures of the bash /dev/tcp and /dev/udp checks run through timeout(1)
+func classifySocketError(exitCode int, output string) ProbeErrorClass {
+	lower := strings.ToLower(output)
+	switch {
+	case exitCode == 124:
+		return ProbeErrorTimeout
+	case strings.Contains(lower, "connection refused"):
+		return ProbeErrorRefused
+	case strings.Contains(lower, "name or service not known"), strings.Contains(lower, "no address associated"),
+		strings.Contains(lower, "temporary failure in name resolution"):
+		return ProbeErrorDNS
+	default:
+		return ProbeErrorUnknown
+	}
+}
+
+// probeSeconds converts a probe timeout to whole seconds for curl and timeout(1)
+func probeSeconds(timeout time.Duration) int {
+	if timeout <= 0 {
+		timeout = DefaultProbeTimeout
+	}
+	seconds := int(timeout.Round(time.Second) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
+
+// shellQuote quotes a value for a POSIX shell
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,106 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// ProbeExecutor runs probe scripts on the source of the traffic and returns their combined output
+type ProbeExecutor interface {
+	RunScript(ctx context.Context, script string) (string, error)
+	String() string
+}
+
+// PodExecProbeExecutor runs probes inside an existing, running pod
+type PodExecProbeExecutor struct {
+	Config    *rest.Config
+	Clientset kubernetes.Interface
+	Namespace string
+	Pod       string
+	Container string // Defaults to the first container
+	Timeout   time.Duration
+}
+
+func (e PodExecProbeExecutor) RunScript(ctx context.Context, script string) (string, error) {
+	result, err := ExecInPod(ctx, e.Config, e.Clientset, e.Namespace, e.Pod, ExecOptions{
+		Container: e.Container,
+		Command:   []string{"sh", "-c", script},
+		Timeout:   e.Timeout,
+	})
+	if err != nil {
+		return "", err
+	}
+	return result.Stdout + result.Stderr, nil
+}
+
+func (e PodExecProbeExecutor) String() string {
+	return fmt.Sprintf("pod %s/%s", e.Namespace, e.Pod)
+}
+
+// EphemeralPodProbeExecutor runs every probe in a new pod that is deleted afterwards.
+// It is the slowest executor but needs nothing running beforehand.
+type EphemeralPodProbeExecutor struct {
+	Clientset kubernetes.Interface
+	Namespace string
+	Image     string
+	Labels    map[string]string // Labels of the probe pods, e.g. to match NetworkPolicy selectors
+	Timeout   time.Duration     // How long to wait for the pod to finish, defaults to 2 minutes
+}
+
+func (e EphemeralPodProbeExecutor) RunScript(ctx context.Context, script string) (string, error) {
+	podName := "probe-" + GenerateRandomName()
+	labels := MergeLabels(DefaultLabelsFor(podName), e.Labels)
+	containers := []ContainerConfig{{Name: "probe", Image: e.Image, Command: []string{"sh", "-c", script}}}
+
+	if _, err := CreatePod(e.Clientset, e.Namespace, podName, containers, labels, false); err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := e.Clientset.CoreV1().Pods(e.Namespace).Delete(context.TODO(), podName, metav1.DeleteOptions{}); err != nil {
+			LogWarn("Failed to delete probe pod %s: %v", podName, err)
+		}
+	}()
+
+	timeout := e.Timeout
+	if timeout == 0 {
+		timeout = 2 * time.Minute
+	}
+	err := WaitForWithContext(ctx, ExponentialBackoff(time.Second, 5*time.Second, timeout), func(ctx context.Context) (bool, error) {
+		pod, err := e.Clientset.CoreV1().Pods(e.Namespace).Get(ctx, podName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		if class, reason := ClassifyPodFailure(pod); class == PodFailureTerminal {
+			return true, fmt.Errorf("%w: pod %s: %s", ErrPodUnrecoverable, podName, reason)
+		}
+		return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("probe pod %s did not finish: %w", podName, err)
+	}
+
+	return GetContainerLogs(e.Clientset, e.Namespace, podName, "probe")
+}
+
+func (e EphemeralPodProbeExecutor) String() string {
+	return fmt.Sprintf("ephemeral pod in %s", e.Namespace)
+}
+
+// SSHProbeExecutor runs probes on a VM through an SSH connection
+type SSHProbeExecutor struct {
+	Client *SSHClient
+}
+
+func (e SSHProbeExecutor) RunScript(ctx context.Context, script string) (string, error) {
+	return e.Client.RunCommandWithContext(ctx, "sh -c "+shellQuote(script))
+}
+
+func (e SSHProbeExecutor) String() string {
+	return fmt.Sprintf("VM %s over SSH", e.Client.config.Host)
+}
@@ -0,0 +1,151 @@
+package util_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"time"
+
+	"myproject/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// localProbeExecutor runs probe scripts on the test machine
+type localProbeExecutor struct{}
+
+func (localProbeExecutor) RunScript(ctx context.Context, script string) (string, error) {
+	output, err := exec.CommandContext(ctx, "sh", "-c", script).CombinedOutput()
+	return string(output), err
+}
+
+func (localProbeExecutor) String() string {
+	return "local shell"
+}
+
+// closedPort returns a local TCP port nothing listens on
+func closedPort() int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).ToNot(HaveOccurred())
+	port := listener.Addr().(*net.TCPAddr).Port
+	Expect(listener.Close()).To(Succeed())
+	return port
+}
+
+var _ = Describe("Probes", func() {
+	run := func(probe util.Probe) util.ProbeResult {
+		result, err := util.RunProbe(context.Background(), localProbeExecutor{}, probe)
+		Expect(err).ToNot(HaveOccurred())
+		return result
+	}
+
+	BeforeEach(func() {
+		for _, tool := range []string{"sh", "bash", "curl", "getent", "timeout"} {
+			if _, err := exec.LookPath(tool); err != nil {
+				Skip(fmt.Sprintf("%s is not installed", tool))
+			}
+		}
+	})
+
+	It("should report the HTTP status code, resolved IP and latency", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		}))
+		DeferCleanup(server.Close)
+
+		result := run(util.HTTPProbe{URL: server.URL})
+		Expect(result.Reachable).To(BeTrue())
+		Expect(result.StatusCode).To(Equal(http.StatusTeapot))
+		Expect(result.ResolvedIP).To(Equal("127.0.0.1"))
+		Expect(result.Latency).To(BeNumerically(">", 0))
+	})
+
+	It("should classify refused HTTP and TCP connections", func() {
+		port := closedPort()
+
+		result := run(util.HTTPProbe{URL: fmt.Sprintf("http://127.0.0.1:%d", port)})
+		Expect(result.Reachable).To(BeFalse())
+		Expect(result.ErrorClass).To(Equal(util.ProbeErrorRefused))
+
+		result = run(util.TCPProbe{Host: "127.0.0.1", Port: port})
+		Expect(result.Reachable).To(BeFalse())
+		Expect(result.ErrorClass).To(Equal(util.ProbeErrorRefused))
+	})
+
+	It("should connect over TCP", func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(listener.Close)
+
+		result := run(util.TCPProbe{Host: "localhost", Port: listener.Addr().(*net.TCPAddr).Port})
+		Expect(result.Reachable).To(BeTrue())
+		Expect(result.ResolvedIP).ToNot(BeEmpty())
+	})
+
+	It("should get the payload back from a UDP echo server", func() {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(conn.Close)
+		go func() {
+			buffer := make([]byte, 1024)
+			for {
+				n, addr, err := conn.ReadFrom(buffer)
+				if err != nil {
+					return
+				}
+				_, _ = conn.WriteTo(buffer[:n], addr)
+			}
+		}()
+
+		result := run(util.UDPProbe{Host: "127.0.0.1", Port: conn.LocalAddr().(*net.UDPAddr).Port, Payload: "hello"})
+		Expect(result.Reachable).To(BeTrue(), result.Output)
+	})
+
+	It("should time out when nothing answers over UDP", func() {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(conn.Close)
+
+		result := run(util.UDPProbe{Host: "127.0.0.1", Port: conn.LocalAddr().(*net.UDPAddr).Port, Timeout: time.Second})
+		Expect(result.Reachable).To(BeFalse())
+		Expect(result.ErrorClass).To(Equal(util.ProbeErrorTimeout))
+	})
+
+	It("should classify refused UDP datagrams", func() {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		port := conn.LocalAddr().(*net.UDPAddr).Port
+		Expect(conn.Close()).To(Succeed())
+
+		result := run(util.UDPProbe{Host: "127.0.0.1", Port: port})
+		Expect(result.Reachable).To(BeFalse())
+		Expect(result.ErrorClass).To(Equal(util.ProbeErrorRefused))
+	})
+
+	It("should resolve names and report DNS failures", func() {
+		result := run(util.DNSProbe{Name: "localhost"})
+		Expect(result.Reachable).To(BeTrue())
+		Expect(result.ResolvedIP).To(Or(Equal("127.0.0.1"), Equal("::1")))
+
+		result = run(util.DNSProbe{Name: "does-not-exist.invalid"})
+		Expect(result.Reachable).To(BeFalse())
+		Expect(result.ErrorClass).To(Equal(util.ProbeErrorDNS))
+	})
+
+	It("should classify curl timeouts from the report", func() {
+		result, err := util.ParseProbeOutput(util.HTTPProbe{URL: "http://10.0.0.1"}, "curl: (28) Connection timed out\nPROBE rc=28 ns=5000000000 ip=10.0.0.1 code=000\n")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Reachable).To(BeFalse())
+		Expect(result.StatusCode).To(BeZero())
+		Expect(result.ErrorClass).To(Equal(util.ProbeErrorTimeout))
+		Expect(result.Latency).To(Equal(5 * time.Second))
+	})
+
+	It("should fail when the script printed no report", func() {
+		_, err := util.ParseProbeOutput(util.TCPProbe{Host: "server", Port: 80}, "sh: bash: not found\n")
+		Expect(err).To(MatchError(ContainSubstring("printed no report")))
+	})
+})
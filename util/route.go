@@ -3,16 +3,167 @@ package util
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	routev1 "github.com/openshift/api/route/v1"
 	routeclientset "github.com/openshift/client-go/route/clientset/versioned"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"myproject/consts"
 
 )
 
+// RouteOptions configures how a Route exposes its service. The zero value is a plain HTTP route
+// with an assigned hostname.
+type RouteOptions struct {
+	Hostname string // Optional hostname, empty means it will be auto-assigned
+
+	// Termination enables TLS: edge (TLS ends at the router), passthrough (TLS goes to the pod)
+	// or reencrypt (the router opens a new TLS connection to the pod). Empty means plain HTTP.
+	Termination routev1.TLSTerminationType
+	// InsecureEdgeTerminationPolicy handles plain HTTP requests on TLS routes: None, Allow or Redirect
+	InsecureEdgeTerminationPolicy routev1.InsecureEdgeTerminationPolicyType
+
+	// PEM encoded certificate, key and CA served by the router for edge and reencrypt routes.
+	// When empty the router serves its default certificate.
+	Certificate   string
+	Key           string
+	CACertificate string
+	// PEM encoded CA that signed the pod's certificate, reencrypt routes only
+	DestinationCACertificate string
+
+	// Weight of the service in the route (0-256), nil keeps the default of 100. Only matters with AlternateBackends.
+	Weight *int32
+	// AlternateBackends share the traffic with the service according to their weights, e.g. for canaries
+	AlternateBackends []RouteBackend
+
+	Router RouterOptions // HAProxy settings such as timeouts, whitelists and rate limits
+
+	Labels map[string]string // Added to the default labels
+}
+
+// RouteBackend is an alternate service of a route
+type RouteBackend struct {
+	ServiceName string
+	Weight      int32 // 0-256, 0 sends no traffic to the service
+}
+
+const (
+	// maxAlternateBackends is the number of alternate backends the Route API accepts
+	maxAlternateBackends = 3
+	// maxRouteWeight is the largest weight the Route API accepts
+	maxRouteWeight = 256
+	// defaultRouteWeight is the weight the API server sets when a backend has none
+	defaultRouteWeight = 100
+)
+
+// Validate checks that the options form a valid combination
+func (o RouteOptions) Validate() error {
+	var problems []string
+	switch o.Termination {
+	case "", routev1.TLSTerminationEdge, routev1.TLSTerminationReencrypt:
+	case routev1.TLSTerminationPassthrough:
+		if o.Certificate != "" || o.Key != "" || o.CACertificate != "" {
+			problems = append(problems, "passthrough routes can't have a certificate, key or CA, the pod serves its own")
+		}
+		if o.InsecureEdgeTerminationPolicy == routev1.InsecureEdgeTerminationPolicyAllow {
+			problems = append(problems, "passthrough routes only accept the None or Redirect insecure policy")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("unknown termination %q, expected edge, passthrough or reencrypt", o.Termination))
+	}
+
+	if o.Termination == "" && (o.InsecureEdgeTerminationPolicy != "" || o.Certificate != "" || o.Key != "" || o.CACertificate != "" || o.DestinationCACertificate != "") {
+		problems = append(problems, "TLS settings need a termination")
+	}
+	switch o.InsecureEdgeTerminationPolicy {
+	case "", routev1.InsecureEdgeTerminationPolicyNone, routev1.InsecureEdgeTerminationPolicyAllow, routev1.InsecureEdgeTerminationPolicyRedirect:
+	default:
+		problems = append(problems, fmt.Sprintf("unknown insecure edge termination policy %q, expected None, Allow or Redirect", o.InsecureEdgeTerminationPolicy))
+	}
+	if (o.Certificate == "") != (o.Key == "") {
+		problems = append(problems, "the certificate and key must be set together")
+	}
+	if o.DestinationCACertificate != "" && o.Termination != routev1.TLSTerminationReencrypt {
+		problems = append(problems, "the destination CA certificate is only used by reencrypt routes")
+	}
+
+	if o.Weight != nil && (*o.Weight < 0 || *o.Weight > maxRouteWeight) {
+		problems = append(problems, fmt.Sprintf("weight %d is outside 0-%d", *o.Weight, maxRouteWeight))
+	}
+	if len(o.AlternateBackends) > maxAlternateBackends {
+		problems = append(problems, fmt.Sprintf("%d alternate backends, a route accepts at most %d", len(o.AlternateBackends), maxAlternateBackends))
+	}
+	seen := map[string]bool{}
+	for _, backend := range o.AlternateBackends {
+		switch {
+		case backend.ServiceName == "":
+			problems = append(problems, "alternate backends need a service name")
+		case seen[backend.ServiceName]:
+			problems = append(problems, fmt.Sprintf("service %s is an alternate backend more than once", backend.ServiceName))
+		}
+		seen[backend.ServiceName] = true
+		if backend.Weight < 0 || backend.Weight > maxRouteWeight {
+			problems = append(problems, fmt.Sprintf("weight %d of service %s is outside 0-%d", backend.Weight, backend.ServiceName, maxRouteWeight))
+		}
+	}
+	problems = append(problems, o.Router.validate(o.Termination)...)
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid route options: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// tlsConfig returns the TLS part of the route spec, nil for plain HTTP
+func (o RouteOptions) tlsConfig() *routev1.TLSConfig {
+	if o.Termination == "" {
+		return nil
+	}
+	return &routev1.TLSConfig{
+		Termination:                   o.Termination,
+		InsecureEdgeTerminationPolicy: o.InsecureEdgeTerminationPolicy,
+		Certificate:                   o.Certificate,
+		Key:                           o.Key,
+		CACertificate:                 o.CACertificate,
+		DestinationCACertificate:      o.DestinationCACertificate,
+	}
+}
+
+// alternateBackends returns the alternate backends of the route spec
+func (o RouteOptions) alternateBackends() []routev1.RouteTargetReference {
+	var backends []routev1.RouteTargetReference
+	for _, backend := range o.AlternateBackends {
+		weight := backend.Weight
+		backends = append(backends, routev1.RouteTargetReference{Kind: "Service", Name: backend.ServiceName, Weight: &weight})
+	}
+	return backends
+}
+
+// RouteBackendWeights returns the weight of every service of a route, primary and alternates.
+// Backends without a weight count with the API default of 100.
+func RouteBackendWeights(route *routev1.Route) map[string]int32 {
+	weights := map[string]int32{}
+	for _, backend := range append([]routev1.RouteTargetReference{route.Spec.To}, route.Spec.AlternateBackends...) {
+		weight := int32(defaultRouteWeight)
+		if backend.Weight != nil {
+			weight = *backend.Weight
+		}
+		weights[backend.Name] = weight
+	}
+	return weights
+}
+
 // CreateRoute creates a Route for a given service in OpenShift
-func CreateRoute(routeClient *routeclientset.Clientset, namespace, routeName, serviceName string, targetPort interface{}, hostname string) error {
+func CreateRoute(routeClient routeclientset.Interface, namespace, routeName, serviceName string, targetPort interface{}, hostname string) error {
+	_, err := CreateRouteWithOptions(routeClient, namespace, routeName, serviceName, targetPort, RouteOptions{Hostname: hostname})
+	return err
+}
+
+// CreateRouteWithOptions creates a Route for a given service, with TLS termination and weighted backends if the options ask for them
+func CreateRouteWithOptions(routeClient routeclientset.Interface, namespace, routeName, serviceName string, targetPort interface{}, options RouteOptions) (*routev1.Route, error) {
 	var targetPortValue intstr.IntOrString
 
 	// Check if targetPort is a string or an int and handle accordingly
@@ -22,38 +173,61 @@ func CreateRoute(routeClient *routeclientset.Clientset, namespace, routeName, se
 	case string:
 		targetPortValue = intstr.FromString(v)
 	default:
-		return fmt.Errorf("unsupported type for targetPort: %T", targetPort)
+		return nil, fmt.Errorf("unsupported type for targetPort: %T", targetPort)
+	}
+
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	for _, backend := range options.AlternateBackends {
+		if backend.ServiceName == serviceName {
+			return nil, fmt.Errorf("invalid route options: service %s is both the primary and an alternate backend", serviceName)
+		}
 	}
 
 	// Define the route object
 	route := &routev1.Route{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      routeName,
-			Namespace: namespace,
+			Name:        routeName,
+			Namespace:   namespace,
+			Labels:      MergeLabels(consts.DefaultLabels, options.Labels),
+			Annotations: options.Router.annotations(),
 		},
 		Spec: routev1.RouteSpec{
-			Host: hostname, // Optional hostname, empty means it will be auto-assigned
+			Host: options.Hostname,
 			To: routev1.RouteTargetReference{
-				Kind: "Service",
-				Name: serviceName, // Route to the given service
+				Kind:   "Service",
+				Name:   serviceName, // Route to the given service
+				Weight: options.Weight,
 			},
+			AlternateBackends: options.alternateBackends(),
 			Port: &routev1.RoutePort{
 				TargetPort: targetPortValue, // Use the targetPort here
 			},
+			TLS: options.tlsConfig(),
 		},
 	}
 
 	// Create the Route in OpenShift
-	_, err := routeClient.RouteV1().Routes(namespace).Create(context.TODO(), route, metav1.CreateOptions{})
+	created, err := routeClient.RouteV1().Routes(namespace).Create(context.TODO(), route, metav1.CreateOptions{})
 	if err != nil {
-		return fmt.Errorf("failed to create Route: %v", err)
+		return nil, fmt.Errorf("failed to create Route: %v", err)
 	}
 
-	return nil
+	return created, nil
+}
+
+// RouteURL returns the URL of a route with an assigned host, https for TLS routes
+func RouteURL(route *routev1.Route) string {
+	scheme := "http"
+	if route.Spec.TLS != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s", scheme, route.Spec.Host)
 }
 
 // GetRouteURL returns the full URL for the given route
-func GetRouteURL(routeClient *routeclientset.Clientset, namespace, routeName string) (string, error) {
+func GetRouteURL(routeClient routeclientset.Interface, namespace, routeName string) (string, error) {
 	route, err := routeClient.RouteV1().Routes(namespace).Get(context.TODO(), routeName, metav1.GetOptions{})
 	if err != nil {
 		LogError("Failed to retrieve Route %s: %v", routeName, err)
@@ -64,13 +238,129 @@ func GetRouteURL(routeClient *routeclientset.Clientset, namespace, routeName str
 		return "", fmt.Errorf("Route %s has no assigned host", routeName)
 	}
 
-	url := fmt.Sprintf("http://%s", route.Spec.Host)
+	url := RouteURL(route)
 	LogInfo("Route URL for %s: %s", routeName, url)
 	return url, nil
 }
 
+// RouteAdmission is the state of a route on one router, i.e. one IngressController shard
+type RouteAdmission struct {
+	RouterName              string
+	Host                    string // Host the router serves the route at
+	RouterCanonicalHostname string // Name of the router's load balancer, the target for CNAME records
+	URL                     string // Host with the route's scheme
+	Admitted                bool
+	Reason                  string // Why the router rejected the route, e.g. HostAlreadyClaimed
+	Message                 string
+}
+
+// RouteRejectedError is returned when the routers refused a route
+type RouteRejectedError struct {
+	Route      string
+	Rejections []RouteAdmission
+}
+
+func (e *RouteRejectedError) Error() string {
+	reasons := make([]string, 0, len(e.Rejections))
+	for _, rejection := range e.Rejections {
+		reasons = append(reasons, fmt.Sprintf("%s: %s (%s)", rejection.RouterName, rejection.Reason, rejection.Message))
+	}
+	return fmt.Sprintf("route %s was rejected by %s", e.Route, strings.Join(reasons, ", "))
+}
+
+// HasReason reports whether a router rejected the route for the given reason
+func (e *RouteRejectedError) HasReason(reason string) bool {
+	for _, rejection := range e.Rejections {
+		if rejection.Reason == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// RouteAdmissions returns what every router that reported on the route decided. A router that has not
+// set the Admitted condition yet is reported as neither admitted nor rejected (empty Reason).
+func RouteAdmissions(route *routev1.Route) []RouteAdmission {
+	admissions := make([]RouteAdmission, 0, len(route.Status.Ingress))
+	for _, ingress := range route.Status.Ingress {
+		admission := RouteAdmission{
+			RouterName:              ingress.RouterName,
+			Host:                    ingress.Host,
+			RouterCanonicalHostname: ingress.RouterCanonicalHostname,
+		}
+		if ingress.Host != "" {
+			admission.URL = strings.TrimSuffix(RouteURL(route), route.Spec.Host) + ingress.Host
+		}
+		for _, condition := range ingress.Conditions {
+			if condition.Type != routev1.RouteAdmitted {
+				continue
+			}
+			admission.Admitted = condition.Status == corev1.ConditionTrue
+			if condition.Status == corev1.ConditionFalse {
+				admission.Reason, admission.Message = condition.Reason, condition.Message
+			}
+		}
+		admissions = append(admissions, admission)
+	}
+	return admissions
+}
+
+// WaitForRouteAdmitted waits until a router admits the route and returns its admission, whose URL is
+// what clients should use. With an empty routerName any router will do. Rejections end the wait with a
+// *RouteRejectedError, either from the given router or, without one, when every reporting router rejected it.
+func WaitForRouteAdmitted(ctx context.Context, routeClient routeclientset.Interface, namespace, routeName, routerName string, backoff Backoff) (*RouteAdmission, error) {
+	routes := routeClient.RouteV1().Routes(namespace)
+	getRoute := func(ctx context.Context) (runtime.Object, error) {
+		return routes.Get(ctx, routeName, metav1.GetOptions{})
+	}
+
+	var admitted *RouteAdmission
+	err := WaitForObject(ctx, backoff, routeName, getRoute, routes.Watch, func(obj runtime.Object) (bool, error) {
+		route, ok := obj.(*routev1.Route)
+		if !ok {
+			return false, fmt.Errorf("unexpected object type %T", obj)
+		}
+
+		var done bool
+		var err error
+		admitted, done, err = routeAdmission(route, routerName)
+		if !done {
+			LogInfo("Waiting for route %s to be admitted...", routeName)
+		}
+		return done, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return admitted, nil
+}
+
+// routeAdmission evaluates the admissions of a route with the rules of WaitForRouteAdmitted: done with
+// an admission once a router admitted it, done with a *RouteRejectedError once rejected
+func routeAdmission(route *routev1.Route, routerName string) (*RouteAdmission, bool, error) {
+	var reported, rejected []RouteAdmission
+	for _, admission := range RouteAdmissions(route) {
+		if routerName != "" && admission.RouterName != routerName {
+			continue
+		}
+		if admission.Admitted {
+			LogInfo("Route %s is admitted by router %s at %s", route.Name, admission.RouterName, admission.Host)
+			return &admission, true, nil
+		}
+		reported = append(reported, admission)
+		if admission.Reason != "" {
+			rejected = append(rejected, admission)
+		}
+	}
+
+	if len(rejected) > 0 && len(rejected) == len(reported) {
+		return nil, true, &RouteRejectedError{Route: route.Name, Rejections: rejected}
+	}
+	return nil, false, nil
+}
+
 // DeleteRoute deletes an OpenShift route
-func DeleteRoute(routeClient *routeclientset.Clientset, namespace, routeName string) error {
+func DeleteRoute(routeClient routeclientset.Interface, namespace, routeName string) error {
 	err := routeClient.RouteV1().Routes(namespace).Delete(context.TODO(), routeName, metav1.DeleteOptions{})
 	if err != nil {
 		LogError("Failed to delete Route %s: %v", routeName, err)
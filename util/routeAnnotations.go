@@ -0,0 +1,149 @@
+package util
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	routev1 "github.com/openshift/api/route/v1"
+)
+
+// Route annotations understood by the OpenShift HAProxy router
+const (
+	RouteTimeoutAnnotation                = "haproxy.router.openshift.io/timeout"
+	RouteIPWhitelistAnnotation            = "haproxy.router.openshift.io/ip_whitelist"
+	RouteRateLimitAnnotation              = "haproxy.router.openshift.io/rate-limit-connections"
+	RouteRateLimitConcurrentTCPAnnotation = "haproxy.router.openshift.io/rate-limit-connections.concurrent-tcp"
+	RouteRateLimitRateHTTPAnnotation      = "haproxy.router.openshift.io/rate-limit-connections.rate-http"
+	RouteRateLimitRateTCPAnnotation       = "haproxy.router.openshift.io/rate-limit-connections.rate-tcp"
+	RouteBalanceAnnotation                = "haproxy.router.openshift.io/balance"
+	RouteDisableCookiesAnnotation         = "haproxy.router.openshift.io/disable_cookies"
+	RouteHSTSAnnotation                   = "haproxy.router.openshift.io/hsts_header"
+)
+
+// RouteBalance is the HAProxy load balancing algorithm across the endpoints of a route
+type RouteBalance string
+
+const (
+	RouteBalanceRoundRobin RouteBalance = "roundrobin"
+	RouteBalanceLeastConn  RouteBalance = "leastconn"
+	RouteBalanceSource     RouteBalance = "source" // The same client address always reaches the same endpoint
+	RouteBalanceRandom     RouteBalance = "random"
+)
+
+// RouteRateLimit limits the connections of one client address. Set at least one limit.
+type RouteRateLimit struct {
+	ConcurrentTCP int // Concurrent TCP connections
+	RateHTTP      int // HTTP requests per 3 seconds
+	RateTCP       int // TCP connections per 3 seconds
+}
+
+// RouteHSTS is the Strict-Transport-Security header the router adds to the responses of a TLS route
+type RouteHSTS struct {
+	MaxAge            time.Duration
+	IncludeSubDomains bool
+	Preload           bool
+}
+
+// String returns the header value, e.g. "max-age=31536000;includeSubDomains;preload"
+func (h RouteHSTS) String() string {
+	value := fmt.Sprintf("max-age=%d", int64(h.MaxAge/time.Second))
+	if h.IncludeSubDomains {
+		value += ";includeSubDomains"
+	}
+	if h.Preload {
+		value += ";preload"
+	}
+	return value
+}
+
+// RouterOptions are typed HAProxy router settings, written to the route as annotations.
+// The zero value keeps the router defaults.
+type RouterOptions struct {
+	Timeout        time.Duration   // Server timeout, requests taking longer get a 504
+	IPWhitelist    []string        // Addresses or CIDRs allowed to use the route, everyone else is rejected
+	RateLimit      *RouteRateLimit // Per client address limits, rejected beyond them
+	Balance        RouteBalance    // Load balancing algorithm
+	DisableCookies bool            // Don't set the cookie that keeps a client on the same endpoint
+	HSTS           *RouteHSTS      // Edge and reencrypt routes only
+
+	Annotations map[string]string // Any other annotation, applied as is
+}
+
+// validate returns the problems of the options on a route with the given termination
+func (r RouterOptions) validate(termination routev1.TLSTerminationType) []string {
+	var problems []string
+	if r.Timeout < 0 || r.Timeout%time.Millisecond != 0 {
+		problems = append(problems, fmt.Sprintf("timeout %s must be a positive number of milliseconds", r.Timeout))
+	}
+	for _, source := range r.IPWhitelist {
+		if net.ParseIP(source) == nil {
+			if _, _, err := net.ParseCIDR(source); err != nil {
+				problems = append(problems, fmt.Sprintf("invalid whitelist entry %q, expected an IP or a CIDR", source))
+			}
+		}
+	}
+	if limit := r.RateLimit; limit != nil {
+		if limit.ConcurrentTCP < 0 || limit.RateHTTP < 0 || limit.RateTCP < 0 {
+			problems = append(problems, "rate limits can't be negative")
+		}
+		if limit.ConcurrentTCP == 0 && limit.RateHTTP == 0 && limit.RateTCP == 0 {
+			problems = append(problems, "the rate limit sets no limit")
+		}
+	}
+	switch r.Balance {
+	case "", RouteBalanceRoundRobin, RouteBalanceLeastConn, RouteBalanceSource, RouteBalanceRandom:
+	default:
+		problems = append(problems, fmt.Sprintf("unknown balance %q, expected roundrobin, leastconn, source or random", r.Balance))
+	}
+	if r.HSTS != nil {
+		if termination != routev1.TLSTerminationEdge && termination != routev1.TLSTerminationReencrypt {
+			problems = append(problems, "the HSTS header is only added to edge and reencrypt routes")
+		}
+		if r.HSTS.MaxAge < 0 {
+			problems = append(problems, "the HSTS max-age can't be negative")
+		}
+	}
+	return problems
+}
+
+// annotations returns the route annotations for the options
+func (r RouterOptions) annotations() map[string]string {
+	annotations := map[string]string{}
+	for key, value := range r.Annotations {
+		annotations[key] = value
+	}
+	if r.Timeout > 0 {
+		annotations[RouteTimeoutAnnotation] = fmt.Sprintf("%dms", r.Timeout.Milliseconds())
+	}
+	if len(r.IPWhitelist) > 0 {
+		annotations[RouteIPWhitelistAnnotation] = strings.Join(r.IPWhitelist, " ")
+	}
+	if limit := r.RateLimit; limit != nil {
+		annotations[RouteRateLimitAnnotation] = "true"
+		for key, value := range map[string]int{
+			RouteRateLimitConcurrentTCPAnnotation: limit.ConcurrentTCP,
+			RouteRateLimitRateHTTPAnnotation:      limit.RateHTTP,
+			RouteRateLimitRateTCPAnnotation:       limit.RateTCP,
+		} {
+			if value > 0 {
+				annotations[key] = strconv.Itoa(value)
+			}
+		}
+	}
+	if r.Balance != "" {
+		annotations[RouteBalanceAnnotation] = string(r.Balance)
+	}
+	if r.DisableCookies {
+		annotations[RouteDisableCookiesAnnotation] = "true"
+	}
+	if r.HSTS != nil {
+		annotations[RouteHSTSAnnotation] = r.HSTS.String()
+	}
+	if len(annotations) == 0 {
+		return nil
+	}
+	return annotations
+}
@@ -0,0 +1,220 @@
+package util_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"myproject/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	routev1 "github.com/openshift/api/route/v1"
+	routefake "github.com/openshift/client-go/route/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Routes", func() {
+	var client *routefake.Clientset
+
+	BeforeEach(func() {
+		client = routefake.NewSimpleClientset()
+	})
+
+	assignHost := func(name, host string) {
+		route, err := client.RouteV1().Routes("ns").Get(context.Background(), name, metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		route.Spec.Host = host
+		_, err = client.RouteV1().Routes("ns").Update(context.Background(), route, metav1.UpdateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	It("should create a plain HTTP route", func() {
+		Expect(util.CreateRoute(client, "ns", "web", "web-svc", 80, "")).To(Succeed())
+		assignHost("web", "web-ns.apps.example.com")
+
+		url, err := util.GetRouteURL(client, "ns", "web")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(url).To(Equal("http://web-ns.apps.example.com"))
+	})
+
+	It("should create a reencrypt route with its certificates and return an https URL", func() {
+		route, err := util.CreateRouteWithOptions(client, "ns", "secure", "web-svc", "https", util.RouteOptions{
+			Termination:                   routev1.TLSTerminationReencrypt,
+			InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyRedirect,
+			Certificate:                   "cert",
+			Key:                           "key",
+			CACertificate:                 "ca",
+			DestinationCACertificate:      "destination-ca",
+			Labels:                        map[string]string{"team": "networking"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(route.Labels).To(HaveKeyWithValue("managed", "openshift-testing"))
+		Expect(route.Labels).To(HaveKeyWithValue("team", "networking"))
+		Expect(route.Spec.TLS).To(Equal(&routev1.TLSConfig{
+			Termination:                   routev1.TLSTerminationReencrypt,
+			InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyRedirect,
+			Certificate:                   "cert",
+			Key:                           "key",
+			CACertificate:                 "ca",
+			DestinationCACertificate:      "destination-ca",
+		}))
+
+		assignHost("secure", "secure-ns.apps.example.com")
+		url, err := util.GetRouteURL(client, "ns", "secure")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(url).To(Equal("https://secure-ns.apps.example.com"))
+	})
+
+	It("should create a route with weighted alternate backends", func() {
+		weight := int32(3)
+		route, err := util.CreateRouteWithOptions(client, "ns", "canary", "stable-svc", 80, util.RouteOptions{
+			Weight:            &weight,
+			AlternateBackends: []util.RouteBackend{{ServiceName: "canary-svc", Weight: 1}, {ServiceName: "dark-svc", Weight: 0}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(route.Spec.AlternateBackends).To(HaveLen(2))
+		Expect(util.RouteBackendWeights(route)).To(Equal(map[string]int32{"stable-svc": 3, "canary-svc": 1, "dark-svc": 0}))
+
+		// Without a weight the primary counts with the API default
+		route.Spec.To.Weight = nil
+		Expect(util.RouteBackendWeights(route)).To(HaveKeyWithValue("stable-svc", int32(100)))
+	})
+
+	It("should write the router options as annotations", func() {
+		route, err := util.CreateRouteWithOptions(client, "ns", "tuned", "web-svc", 80, util.RouteOptions{
+			Termination: routev1.TLSTerminationEdge,
+			Router: util.RouterOptions{
+				Timeout:        90 * time.Second,
+				IPWhitelist:    []string{"10.0.0.0/8", "192.0.2.1"},
+				RateLimit:      &util.RouteRateLimit{RateHTTP: 10},
+				Balance:        util.RouteBalanceSource,
+				DisableCookies: true,
+				HSTS:           &util.RouteHSTS{MaxAge: time.Hour, IncludeSubDomains: true, Preload: true},
+				Annotations:    map[string]string{"router.openshift.io/cookie_name": "shop"},
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(route.Annotations).To(Equal(map[string]string{
+			util.RouteTimeoutAnnotation:           "90000ms",
+			util.RouteIPWhitelistAnnotation:       "10.0.0.0/8 192.0.2.1",
+			util.RouteRateLimitAnnotation:         "true",
+			util.RouteRateLimitRateHTTPAnnotation: "10",
+			util.RouteBalanceAnnotation:           "source",
+			util.RouteDisableCookiesAnnotation:    "true",
+			util.RouteHSTSAnnotation:              "max-age=3600;includeSubDomains;preload",
+			"router.openshift.io/cookie_name":     "shop",
+		}))
+
+		plain, err := util.CreateRouteWithOptions(client, "ns", "plain", "web-svc", 80, util.RouteOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(plain.Annotations).To(BeEmpty())
+	})
+
+	It("should not accept the primary service as alternate backend", func() {
+		_, err := util.CreateRouteWithOptions(client, "ns", "canary", "web-svc", 80, util.RouteOptions{
+			AlternateBackends: []util.RouteBackend{{ServiceName: "web-svc", Weight: 1}},
+		})
+		Expect(err).To(MatchError(ContainSubstring("both the primary and an alternate backend")))
+	})
+
+	DescribeTable("rejects invalid options",
+		func(options util.RouteOptions, problem string) {
+			_, err := util.CreateRouteWithOptions(client, "ns", "bad", "web-svc", 80, options)
+			Expect(err).To(MatchError(ContainSubstring(problem)))
+		},
+		Entry("unknown termination", util.RouteOptions{Termination: "mtls"}, `unknown termination "mtls"`),
+		Entry("certificate on passthrough", util.RouteOptions{Termination: routev1.TLSTerminationPassthrough, Certificate: "c", Key: "k"}, "passthrough routes can't have a certificate"),
+		Entry("Allow on passthrough", util.RouteOptions{Termination: routev1.TLSTerminationPassthrough, InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyAllow}, "only accept the None or Redirect"),
+		Entry("TLS settings without termination", util.RouteOptions{CACertificate: "ca"}, "TLS settings need a termination"),
+		Entry("certificate without key", util.RouteOptions{Termination: routev1.TLSTerminationEdge, Certificate: "c"}, "must be set together"),
+		Entry("destination CA on edge", util.RouteOptions{Termination: routev1.TLSTerminationEdge, DestinationCACertificate: "ca"}, "only used by reencrypt routes"),
+		Entry("weight out of range", util.RouteOptions{Weight: func() *int32 { w := int32(300); return &w }()}, "weight 300 is outside 0-256"),
+		Entry("too many alternate backends", util.RouteOptions{AlternateBackends: []util.RouteBackend{{ServiceName: "a"}, {ServiceName: "b"}, {ServiceName: "c"}, {ServiceName: "d"}}},
+			"4 alternate backends, a route accepts at most 3"),
+		Entry("duplicate alternate backend", util.RouteOptions{AlternateBackends: []util.RouteBackend{{ServiceName: "a"}, {ServiceName: "a"}}}, "service a is an alternate backend more than once"),
+		Entry("negative alternate weight", util.RouteOptions{AlternateBackends: []util.RouteBackend{{ServiceName: "a", Weight: -1}}}, "weight -1 of service a"),
+		Entry("sub-millisecond timeout", util.RouteOptions{Router: util.RouterOptions{Timeout: time.Microsecond}}, "must be a positive number of milliseconds"),
+		Entry("invalid whitelist entry", util.RouteOptions{Router: util.RouterOptions{IPWhitelist: []string{"10.0.0.0/33"}}}, `invalid whitelist entry "10.0.0.0/33"`),
+		Entry("empty rate limit", util.RouteOptions{Router: util.RouterOptions{RateLimit: &util.RouteRateLimit{}}}, "the rate limit sets no limit"),
+		Entry("unknown balance", util.RouteOptions{Router: util.RouterOptions{Balance: "first"}}, `unknown balance "first"`),
+		Entry("HSTS on a plain route", util.RouteOptions{Router: util.RouterOptions{HSTS: &util.RouteHSTS{MaxAge: time.Hour}}}, "only added to edge and reencrypt routes"),
+		Entry("unknown insecure policy", util.RouteOptions{Termination: routev1.TLSTerminationEdge, InsecureEdgeTerminationPolicy: "Upgrade"}, `unknown insecure edge termination policy "Upgrade"`),
+	)
+})
+
+var _ = Describe("Route admission", func() {
+	var client *routefake.Clientset
+
+	BeforeEach(func() {
+		client = routefake.NewSimpleClientset()
+		_, err := util.CreateRouteWithOptions(client, "ns", "web", "web-svc", 80, util.RouteOptions{Termination: routev1.TLSTerminationEdge})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	ingress := func(router, host string, status corev1.ConditionStatus, reason string) routev1.RouteIngress {
+		return routev1.RouteIngress{
+			RouterName:              router,
+			Host:                    host,
+			RouterCanonicalHostname: "router-" + router + ".apps.example.com",
+			Conditions:              []routev1.RouteIngressCondition{{Type: routev1.RouteAdmitted, Status: status, Reason: reason, Message: reason + " message"}},
+		}
+	}
+
+	setStatus := func(ingresses ...routev1.RouteIngress) {
+		route, err := client.RouteV1().Routes("ns").Get(context.Background(), "web", metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		route.Spec.Host = "web-ns.apps.example.com"
+		route.Status.Ingress = ingresses
+		_, err = client.RouteV1().Routes("ns").Update(context.Background(), route, metav1.UpdateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	wait := func(router string, timeout time.Duration) (*util.RouteAdmission, error) {
+		return util.WaitForRouteAdmitted(context.Background(), client, "ns", "web", router, util.ConstantBackoff(10*time.Millisecond, timeout, 0))
+	}
+
+	It("should return the admitted host once a router admits the route", func() {
+		go func() {
+			defer GinkgoRecover()
+			time.Sleep(50 * time.Millisecond)
+			setStatus(ingress("default", "web-ns.apps.example.com", corev1.ConditionTrue, ""))
+		}()
+
+		admission, err := wait("", time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(admission.RouterName).To(Equal("default"))
+		Expect(admission.RouterCanonicalHostname).To(Equal("router-default.apps.example.com"))
+		Expect(admission.URL).To(Equal("https://web-ns.apps.example.com"))
+	})
+
+	It("should wait for the requested shard", func() {
+		setStatus(ingress("default", "web-ns.apps.example.com", corev1.ConditionTrue, ""),
+			ingress("internal", "web-ns.internal.example.com", corev1.ConditionTrue, ""))
+
+		admission, err := wait("internal", time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(admission.URL).To(Equal("https://web-ns.internal.example.com"))
+
+		_, err = wait("sharded", 100*time.Millisecond)
+		Expect(err).To(MatchError(util.ErrWaitTimeout))
+	})
+
+	It("should report the rejection reason", func() {
+		setStatus(ingress("default", "web-ns.apps.example.com", corev1.ConditionFalse, "HostAlreadyClaimed"))
+
+		_, err := wait("", time.Second)
+		var rejected *util.RouteRejectedError
+		Expect(errors.As(err, &rejected)).To(BeTrue())
+		Expect(rejected.HasReason("HostAlreadyClaimed")).To(BeTrue())
+		Expect(err).To(MatchError(ContainSubstring("default: HostAlreadyClaimed (HostAlreadyClaimed message)")))
+	})
+
+	It("should keep waiting while another router may still admit the route", func() {
+		setStatus(ingress("default", "web-ns.apps.example.com", corev1.ConditionFalse, "HostAlreadyClaimed"),
+			routev1.RouteIngress{RouterName: "internal", Host: "web-ns.apps.example.com"})
+
+		_, err := wait("", 100*time.Millisecond)
+		Expect(err).To(MatchError(util.ErrWaitTimeout))
+	})
+})
@@ -0,0 +1,123 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTPResponseHead is the status and headers of a single response, as seen by the client
+type HTTPResponseHead struct {
+	StatusCode int // 0 when no response was received
+	Header     http.Header
+	ExitCode   int           // curl exit code, e.g. 52 when the router closed the connection without answering
+	Latency    time.Duration // Time until the request finished or failed
+}
+
+// Received reports whether a response came back at all
+func (h HTTPResponseHead) Received() bool {
+	return h.ExitCode == 0 && h.StatusCode != 0
+}
+
+// ClosedByServer reports whether the server accepted the connection and closed it without answering,
+// curl exit code 52 (empty reply) or 56 (connection reset), as the router does for refused clients.
+// Timeouts, name resolution and connection failures are not.
+func (h HTTPResponseHead) ClosedByServer() bool {
+	return h.ExitCode == 52 || h.ExitCode == 56
+}
+
+// FetchHTTPHead sends one GET request from the executor without following redirects and returns
+// the status and headers. A request that gets no response is not an error, the head tells why.
+func FetchHTTPHead(ctx context.Context, executor ProbeExecutor, url string, timeout time.Duration) (*HTTPResponseHead, error) {
+	script := strings.Join([]string{
+		"start=$(date +%s%N)",
+		fmt.Sprintf(`curl --silent --show-error --insecure --max-time %d --output /dev/null --dump-header - %s; rc=$?`, probeSeconds(timeout), shellQuote(url)),
+		"end=$(date +%s%N)",
+		fmt.Sprintf(`echo "%src=$rc ns=$((end-start))"`, probeReportPrefix),
+	}, "\n")
+	output, err := executor.RunScript(ctx, script)
+	if err != nil {
+		LogError("Failed to request %s from %s: %v", url, executor, err)
+		return nil, fmt.Errorf("failed to request %s from %s: %v", url, executor, err)
+	}
+	return ParseHTTPHead(output)
+}
+
+// ParseHTTPHead reads the output of the FetchHTTPHead script. With several response heads
+// (e.g. a 100 Continue) the last one wins.
+func ParseHTTPHead(output string) (*HTTPResponseHead, error) {
+	head := &HTTPResponseHead{Header: http.Header{}}
+	reported := false
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case strings.HasPrefix(line, probeReportPrefix):
+			reported = true
+			for _, field := range strings.Fields(strings.TrimPrefix(line, probeReportPrefix)) {
+				key, value, _ := strings.Cut(field, "=")
+				switch key {
+				case "rc":
+					head.ExitCode, _ = strconv.Atoi(value)
+				case "ns":
+					if nanoseconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+						head.Latency = time.Duration(nanoseconds)
+					}
+				}
+			}
+		case strings.HasPrefix(line, "HTTP/"):
+			head.Header = http.Header{}
+			if fields := strings.Fields(line); len(fields) > 1 {
+				head.StatusCode, _ = strconv.Atoi(fields[1])
+			}
+		case !reported && head.StatusCode != 0:
+			if key, value, found := strings.Cut(line, ":"); found {
+				head.Header.Add(strings.TrimSpace(key), strings.TrimSpace(value))
+			}
+		}
+	}
+	if !reported {
+		return head, fmt.Errorf("the request printed no report: %q", output)
+	}
+	return head, nil
+}
+
+// SampleHTTPStatusCodes sends the requests one after the other from the executor and returns the
+// status code of each, 0 for requests that got no response
+func SampleHTTPStatusCodes(ctx context.Context, executor ProbeExecutor, url string, requests int, timeout time.Duration) ([]int, error) {
+	heads, err := SampleHTTPHeads(ctx, executor, url, requests, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make([]int, 0, len(heads))
+	for _, head := range heads {
+		codes = append(codes, head.StatusCode)
+	}
+	return codes, nil
+}
+
+// SampleHTTPHeads sends the requests one after the other from the executor and returns the status
+// code and curl exit code of each. Headers and latency are not sampled.
+func SampleHTTPHeads(ctx context.Context, executor ProbeExecutor, url string, requests int, timeout time.Duration) ([]HTTPResponseHead, error) {
+	request := fmt.Sprintf(`curl --silent --insecure --max-time %d --output /dev/null --write-out '%%{http_code}' %s; printf ' %%d' $?`, probeSeconds(timeout), shellQuote(url))
+	samples, err := sampleHTTP(ctx, executor, url, requests, request)
+	if err != nil {
+		return nil, err
+	}
+
+	heads := make([]HTTPResponseHead, 0, len(samples))
+	for _, sample := range samples {
+		var head HTTPResponseHead
+		fields := strings.Fields(sample)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("unexpected sample %q from %s", sample, executor)
+		}
+		head.StatusCode, _ = strconv.Atoi(fields[0]) // curl prints 000 without a response
+		head.ExitCode, _ = strconv.Atoi(fields[1])
+		heads = append(heads, head)
+	}
+	return heads, nil
+}
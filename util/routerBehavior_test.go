@@ -0,0 +1,105 @@
+package util_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"sync/atomic"
+	"time"
+
+	"myproject/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Router behaviour requests", func() {
+	BeforeEach(func() {
+		for _, tool := range []string{"sh", "curl", "date"} {
+			if _, err := exec.LookPath(tool); err != nil {
+				Skip(fmt.Sprintf("%s is not installed", tool))
+			}
+		}
+	})
+
+	It("should return the status and headers of a response", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Set-Cookie", "a=1")
+			w.Header().Add("Set-Cookie", "b=2")
+			w.Header().Set("Strict-Transport-Security", "max-age=3600")
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		DeferCleanup(server.Close)
+
+		head, err := util.FetchHTTPHead(context.Background(), localProbeExecutor{}, server.URL, 5*time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(head.Received()).To(BeTrue())
+		Expect(head.StatusCode).To(Equal(http.StatusAccepted))
+		Expect(head.Header.Values("Set-Cookie")).To(Equal([]string{"a=1", "b=2"}))
+		Expect(head.Header.Get("Strict-Transport-Security")).To(Equal("max-age=3600"))
+		Expect(head.Latency).To(BeNumerically(">", 0))
+	})
+
+	It("should describe a request without response", func() {
+		head, err := util.FetchHTTPHead(context.Background(), localProbeExecutor{}, fmt.Sprintf("http://127.0.0.1:%d/", closedPort()), 5*time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(head.Received()).To(BeFalse())
+		Expect(head.ExitCode).To(Equal(7))
+	})
+
+	It("should sample status codes", func() {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Refuse everything after the third request, like a rate limit
+			if requests.Add(1) > 3 {
+				w.WriteHeader(http.StatusTooManyRequests)
+			}
+		}))
+		DeferCleanup(server.Close)
+
+		codes, err := util.SampleHTTPStatusCodes(context.Background(), localProbeExecutor{}, server.URL, 5, 5*time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(codes).To(Equal([]int{200, 200, 200, 429, 429}))
+	})
+
+	It("should tell connections closed by the server from other failures", func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(listener.Close)
+		go func() {
+			// Accept and close without answering, like the router refusing a client
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				_, _ = conn.Read(make([]byte, 1024))
+				conn.Close()
+			}
+		}()
+
+		heads, err := util.SampleHTTPHeads(context.Background(), localProbeExecutor{}, "http://"+listener.Addr().String()+"/", 2, 5*time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(heads).To(HaveLen(2))
+		Expect(heads[0].Received()).To(BeFalse())
+		Expect(heads[0].ClosedByServer()).To(BeTrue())
+
+		heads, err = util.SampleHTTPHeads(context.Background(), localProbeExecutor{}, fmt.Sprintf("http://127.0.0.1:%d/", closedPort()), 1, 5*time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(heads[0].ExitCode).To(Equal(7))
+		Expect(heads[0].ClosedByServer()).To(BeFalse())
+	})
+
+	It("should keep the last response head", func() {
+		head, err := util.ParseHTTPHead("HTTP/1.1 100 Continue\r\n\r\nHTTP/1.1 504 Gateway Time-out\r\nContent-Type: text/html\r\n\r\nPROBE rc=0 ns=3000000000\n")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(head.StatusCode).To(Equal(504))
+		Expect(head.Header).To(HaveLen(1))
+		Expect(head.Latency).To(Equal(3 * time.Second))
+
+		_, err = util.ParseHTTPHead("sh: curl: not found")
+		Expect(err).To(HaveOccurred())
+	})
+})
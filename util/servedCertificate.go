@@ -0,0 +1,84 @@
+package util
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// The ingress operator publishes the CA of the router's default certificate in this ConfigMap
+const (
+	defaultIngressCANamespace = "openshift-config-managed"
+	defaultIngressCAConfigMap = "default-ingress-cert"
+	defaultIngressCAKey       = "ca-bundle.crt"
+)
+
+// FetchServedCertificates opens a TLS connection to address with serverName as SNI and returns the
+// chain the server presents, leaf first. The chain is not verified, see VerifyServedCertificates.
+func FetchServedCertificates(ctx context.Context, address, serverName string) ([]*x509.Certificate, error) {
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{},
+		// The chain is verified separately so a wrong certificate can be reported instead of failing the handshake
+		Config: &tls.Config{ServerName: serverName, InsecureSkipVerify: true},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed TLS handshake with %s (SNI %s): %v", address, serverName, err)
+	}
+	defer conn.Close()
+
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("%s (SNI %s) presented no certificate", address, serverName)
+	}
+	LogDebug("%s (SNI %s) presented %s issued by %s", address, serverName, certs[0].Subject, certs[0].Issuer)
+	return certs, nil
+}
+
+// VerifyServedCertificates checks that the served chain leads to one of the roots and that the
+// leaf certificate is valid for serverName. A nil pool uses the system roots.
+func VerifyServedCertificates(certs []*x509.Certificate, roots *x509.CertPool, serverName string) error {
+	if len(certs) == 0 {
+		return fmt.Errorf("no certificate to verify")
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := certs[0].Verify(x509.VerifyOptions{DNSName: serverName, Roots: roots, Intermediates: intermediates})
+	if err != nil {
+		return fmt.Errorf("served certificate %s is not valid for %s: %v", certs[0].Subject, serverName, err)
+	}
+	return nil
+}
+
+// CertPoolFromPEM returns a pool with the PEM encoded certificates, for example a route's CA certificate
+func CertPoolFromPEM(pemCerts ...string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for _, pemCert := range pemCerts {
+		if !pool.AppendCertsFromPEM([]byte(pemCert)) {
+			return nil, fmt.Errorf("no PEM certificate found")
+		}
+	}
+	return pool, nil
+}
+
+// GetDefaultIngressCA returns a pool with the CA that signs the router's default certificate, which
+// edge and reencrypt routes without their own certificate serve
+func GetDefaultIngressCA(clientset kubernetes.Interface) (*x509.CertPool, error) {
+	cm, err := clientset.CoreV1().ConfigMaps(defaultIngressCANamespace).Get(context.TODO(), defaultIngressCAConfigMap, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the default ingress CA: %v", err)
+	}
+	pool, err := CertPoolFromPEM(cm.Data[defaultIngressCAKey])
+	if err != nil {
+		return nil, fmt.Errorf("invalid default ingress CA in %s/%s: %v", defaultIngressCANamespace, defaultIngressCAConfigMap, err)
+	}
+	return pool, nil
+}
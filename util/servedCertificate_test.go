@@ -0,0 +1,49 @@
+package util_test
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+
+	"myproject/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Served certificates", func() {
+	var server *httptest.Server
+
+	BeforeEach(func() {
+		server = httptest.NewTLSServer(http.NotFoundHandler())
+		DeferCleanup(server.Close)
+	})
+
+	serverCA := func() *x509.CertPool {
+		caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		pool, err := util.CertPoolFromPEM(string(caPEM))
+		Expect(err).ToNot(HaveOccurred())
+		return pool
+	}
+
+	It("should fetch and verify the chain for the SNI hostname", func() {
+		// The httptest certificate is valid for example.com
+		certs, err := util.FetchServedCertificates(context.Background(), server.Listener.Addr().String(), "example.com")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(certs).ToNot(BeEmpty())
+		Expect(util.VerifyServedCertificates(certs, serverCA(), "example.com")).To(Succeed())
+	})
+
+	It("should reject a certificate for another hostname or from an unknown CA", func() {
+		certs, err := util.FetchServedCertificates(context.Background(), server.Listener.Addr().String(), "app.example.org")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(util.VerifyServedCertificates(certs, serverCA(), "app.example.org")).To(MatchError(ContainSubstring("not valid for app.example.org")))
+		Expect(util.VerifyServedCertificates(certs, x509.NewCertPool(), "example.com")).To(MatchError(ContainSubstring("unknown authority")))
+	})
+
+	It("should reject PEM without certificates", func() {
+		_, err := util.CertPoolFromPEM("not a certificate")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,191 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ErrServiceAddressPending is returned while a service waits for its load balancer or node port
+var ErrServiceAddressPending = errors.New("the service has no address yet")
+
+// UnsupportedServiceError is returned for services that have no single address to connect to
+type UnsupportedServiceError struct {
+	Service string
+	Type    corev1.ServiceType
+	Reason  string
+}
+
+func (e *UnsupportedServiceError) Error() string {
+	return fmt.Sprintf("service %s of type %s can't be addressed: %s", e.Service, e.Type, e.Reason)
+}
+
+// ServiceEndpoint is where a client connects to reach one port of a service
+type ServiceEndpoint struct {
+	Type     corev1.ServiceType
+	Host     string // Cluster IP, node address, load balancer IP or hostname, or the ExternalName target
+	Port     int32  // Service port, the allocated node port for NodePort services, 0 for ExternalName services without ports
+	Protocol corev1.Protocol
+}
+
+// Address returns host:port, or only the host when there is no port
+func (e ServiceEndpoint) Address() string {
+	if e.Port == 0 {
+		return e.Host
+	}
+	return net.JoinHostPort(e.Host, strconv.Itoa(int(e.Port)))
+}
+
+// GetServiceEndpoint returns how to reach the named port of a service, the first port when portName
+// is empty. NodePort services are reached at the address of a Ready node, which may not answer when the
+// external traffic policy is Local and no endpoint runs there.
+func GetServiceEndpoint(clientset kubernetes.Interface, namespace, serviceName, portName string) (*ServiceEndpoint, error) {
+	service, err := clientset.CoreV1().Services(namespace).Get(context.TODO(), serviceName, metav1.GetOptions{})
+	if err != nil {
+		LogError("Failed to get service %s: %v", serviceName, err)
+		return nil, fmt.Errorf("failed to get service %s: %v", serviceName, err)
+	}
+	return serviceEndpoint(clientset, service, portName)
+}
+
+// WaitForServiceEndpoint waits until the service has an address, see GetServiceEndpoint. Services
+// that can never have one end the wait with an *UnsupportedServiceError.
+func WaitForServiceEndpoint(ctx context.Context, clientset kubernetes.Interface, namespace, serviceName, portName string, backoff Backoff) (*ServiceEndpoint, error) {
+	var endpoint *ServiceEndpoint
+	err := WaitForWithContext(ctx, backoff, func(ctx context.Context) (bool, error) {
+		var err error
+		endpoint, err = GetServiceEndpoint(clientset, namespace, serviceName, portName)
+		var unsupported *UnsupportedServiceError
+		if errors.As(err, &unsupported) {
+			return true, err
+		}
+		if err != nil {
+			LogInfo("Waiting for service %s to get an address...", serviceName)
+			return false, err
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	LogInfo("Service %s is reachable at %s", serviceName, endpoint.Address())
+	return endpoint, nil
+}
+
+// serviceEndpoint resolves the host of the service and picks the port
+func serviceEndpoint(clientset kubernetes.Interface, service *corev1.Service, portName string) (*ServiceEndpoint, error) {
+	host, err := serviceHost(clientset, service)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := &ServiceEndpoint{Type: serviceTypeOf(service), Host: host}
+
+	if len(service.Spec.Ports) == 0 && portName == "" && endpoint.Type == corev1.ServiceTypeExternalName {
+		return endpoint, nil
+	}
+	var port *corev1.ServicePort
+	for i := range service.Spec.Ports {
+		if portName == "" || service.Spec.Ports[i].Name == portName {
+			port = &service.Spec.Ports[i]
+			break
+		}
+	}
+	if port == nil {
+		return nil, fmt.Errorf("service %s has no port named %q", service.Name, portName)
+	}
+
+	endpoint.Port, endpoint.Protocol = port.Port, port.Protocol
+	if endpoint.Type == corev1.ServiceTypeNodePort {
+		if port.NodePort == 0 {
+			return nil, fmt.Errorf("port %d of service %s has no node port allocated: %w", port.Port, service.Name, ErrServiceAddressPending)
+		}
+		endpoint.Port = port.NodePort
+	}
+	return endpoint, nil
+}
+
+// serviceHost returns the address a client uses for the service, depending on its type
+func serviceHost(clientset kubernetes.Interface, service *corev1.Service) (string, error) {
+	switch serviceTypeOf(service) {
+	case corev1.ServiceTypeClusterIP:
+		if service.Spec.ClusterIP == "" || service.Spec.ClusterIP == corev1.ClusterIPNone {
+			return "", &UnsupportedServiceError{Service: service.Name, Type: corev1.ServiceTypeClusterIP, Reason: "headless services have no cluster IP, resolve the pods through DNS"}
+		}
+		return service.Spec.ClusterIP, nil
+	case corev1.ServiceTypeNodePort:
+		return readyNodeAddress(clientset)
+	case corev1.ServiceTypeLoadBalancer:
+		if address := loadBalancerAddress(service.Status.LoadBalancer.Ingress); address != "" {
+			return address, nil
+		}
+		return "", fmt.Errorf("load balancer of service %s: %w", service.Name, ErrServiceAddressPending)
+	case corev1.ServiceTypeExternalName:
+		if service.Spec.ExternalName == "" {
+			return "", &UnsupportedServiceError{Service: service.Name, Type: corev1.ServiceTypeExternalName, Reason: "externalName is empty"}
+		}
+		return service.Spec.ExternalName, nil
+	default:
+		return "", &UnsupportedServiceError{Service: service.Name, Type: service.Spec.Type, Reason: "unknown service type"}
+	}
+}
+
+// serviceTypeOf returns the type of the service, ClusterIP when unset
+func serviceTypeOf(service *corev1.Service) corev1.ServiceType {
+	if service.Spec.Type == "" {
+		return corev1.ServiceTypeClusterIP
+	}
+	return service.Spec.Type
+}
+
+// loadBalancerAddress returns the first IP or hostname of the load balancer, empty while none is assigned.
+// Cloud load balancers such as AWS ELBs only report a hostname.
+func loadBalancerAddress(ingress []corev1.LoadBalancerIngress) string {
+	for _, lb := range ingress {
+		if lb.IP != "" {
+			return lb.IP
+		}
+		if lb.Hostname != "" {
+			return lb.Hostname
+		}
+	}
+	return ""
+}
+
+// readyNodeAddress returns the internal IP of the first Ready node, or its external IP when it has no
+// internal one. Node ports are open on every node.
+func readyNodeAddress(clientset kubernetes.Interface) (string, error) {
+	nodes, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to list nodes: %v", err)
+	}
+
+	for _, node := range nodes.Items {
+		if !nodeReady(&node) {
+			continue
+		}
+		for _, addressType := range []corev1.NodeAddressType{corev1.NodeInternalIP, corev1.NodeExternalIP} {
+			for _, address := range node.Status.Addresses {
+				if address.Type == addressType && address.Address != "" {
+					return address.Address, nil
+				}
+			}
+		}
+	}
+	return "", fmt.Errorf("no Ready node has an IP address to reach node ports at")
+}
+
+// nodeReady reports whether the node's Ready condition is true
+func nodeReady(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package util_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"myproject/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("Service endpoints", func() {
+	var clientset *fake.Clientset
+
+	node := func(name string, ready corev1.ConditionStatus, addresses ...corev1.NodeAddress) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}},
+				Addresses:  addresses,
+			},
+		}
+	}
+	service := func(name string, spec corev1.ServiceSpec, ingress ...corev1.LoadBalancerIngress) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+			Spec:       spec,
+			Status:     corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{Ingress: ingress}},
+		}
+	}
+	ports := []corev1.ServicePort{
+		{Name: "http", Port: 80, NodePort: 30080, Protocol: corev1.ProtocolTCP},
+		{Name: "dns", Port: 53, NodePort: 30053, Protocol: corev1.ProtocolUDP},
+	}
+
+	BeforeEach(func() {
+		clientset = fake.NewSimpleClientset(
+			node("master-0", corev1.ConditionFalse, corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "10.0.0.1"}),
+			node("worker-0", corev1.ConditionTrue,
+				corev1.NodeAddress{Type: corev1.NodeHostName, Address: "worker-0"},
+				corev1.NodeAddress{Type: corev1.NodeExternalIP, Address: "203.0.113.7"},
+				corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "10.0.0.2"}),
+			service("cluster", corev1.ServiceSpec{ClusterIP: "172.30.0.10", Ports: ports}),
+			service("headless", corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP, ClusterIP: corev1.ClusterIPNone, Ports: ports}),
+			service("nodeport", corev1.ServiceSpec{Type: corev1.ServiceTypeNodePort, ClusterIP: "172.30.0.11", Ports: ports}),
+			service("elb", corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer, Ports: ports}, corev1.LoadBalancerIngress{Hostname: "a1b2.elb.example.com"}),
+			service("pending", corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer, Ports: ports}),
+			service("external", corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: "db.example.com"}),
+		)
+	})
+
+	DescribeTable("resolves the endpoint of every service type",
+		func(serviceName, portName string, expected util.ServiceEndpoint, address string) {
+			endpoint, err := util.GetServiceEndpoint(clientset, "ns", serviceName, portName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*endpoint).To(Equal(expected))
+			Expect(endpoint.Address()).To(Equal(address))
+		},
+		Entry("ClusterIP", "cluster", "", util.ServiceEndpoint{Type: corev1.ServiceTypeClusterIP, Host: "172.30.0.10", Port: 80, Protocol: corev1.ProtocolTCP}, "172.30.0.10:80"),
+		Entry("named port", "cluster", "dns", util.ServiceEndpoint{Type: corev1.ServiceTypeClusterIP, Host: "172.30.0.10", Port: 53, Protocol: corev1.ProtocolUDP}, "172.30.0.10:53"),
+		Entry("NodePort", "nodeport", "http", util.ServiceEndpoint{Type: corev1.ServiceTypeNodePort, Host: "10.0.0.2", Port: 30080, Protocol: corev1.ProtocolTCP}, "10.0.0.2:30080"),
+		Entry("LoadBalancer hostname", "elb", "", util.ServiceEndpoint{Type: corev1.ServiceTypeLoadBalancer, Host: "a1b2.elb.example.com", Port: 80, Protocol: corev1.ProtocolTCP}, "a1b2.elb.example.com:80"),
+		Entry("ExternalName", "external", "", util.ServiceEndpoint{Type: corev1.ServiceTypeExternalName, Host: "db.example.com"}, "db.example.com"),
+	)
+
+	It("should return the address of every addressable service", func() {
+		for serviceName, expected := range map[string]string{
+			"cluster":  "172.30.0.10",
+			"nodeport": "10.0.0.2",
+			"elb":      "a1b2.elb.example.com",
+			"external": "db.example.com",
+		} {
+			ip, err := util.GetServiceIP(clientset, "ns", serviceName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ip).To(Equal(expected), serviceName)
+		}
+	})
+
+	It("should report what can't be addressed", func() {
+		_, err := util.GetServiceIP(clientset, "ns", "pending")
+		Expect(err).To(MatchError(util.ErrServiceAddressPending))
+
+		_, err = util.GetServiceIP(clientset, "ns", "headless")
+		var unsupported *util.UnsupportedServiceError
+		Expect(errors.As(err, &unsupported)).To(BeTrue())
+		Expect(unsupported.Service).To(Equal("headless"))
+
+		_, err = util.GetServiceEndpoint(clientset, "ns", "cluster", "grpc")
+		Expect(err).To(MatchError(`service cluster has no port named "grpc"`))
+	})
+
+	It("should fail the NodePort endpoint without a Ready node", func() {
+		Expect(clientset.CoreV1().Nodes().Delete(context.Background(), "worker-0", metav1.DeleteOptions{})).To(Succeed())
+
+		_, err := util.GetServiceEndpoint(clientset, "ns", "nodeport", "")
+		Expect(err).To(MatchError(ContainSubstring("no Ready node")))
+	})
+
+	It("should stop waiting for services that never get an address", func() {
+		_, err := util.WaitForServiceEndpoint(context.Background(), clientset, "ns", "headless", "", util.ConstantBackoff(10*time.Millisecond, time.Minute, 0))
+		Expect(err).To(MatchError(util.ErrTerminalCondition))
+		var unsupported *util.UnsupportedServiceError
+		Expect(errors.As(err, &unsupported)).To(BeTrue())
+
+		_, err = util.WaitForServiceEndpoint(context.Background(), clientset, "ns", "pending", "", util.ConstantBackoff(10*time.Millisecond, 50*time.Millisecond, 0))
+		Expect(err).To(MatchError(util.ErrWaitTimeout))
+		Expect(err).To(MatchError(util.ErrServiceAddressPending))
+	})
+
+	It("should create a LoadBalancer service that only gets a hostname", func() {
+		clientset.PrependReactor("create", "services", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			created := action.(k8stesting.CreateAction).GetObject().(*corev1.Service)
+			created.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{Hostname: "c3d4.elb.example.com"}}
+			return false, nil, nil
+		})
+
+		service, err := util.CreateService(clientset, "ns", "web", "LoadBalancer", []corev1.ServicePort{util.GeneratePort("http", 80, 8080, "TCP")}, nil, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(service.Status.LoadBalancer.Ingress[0].Hostname).To(Equal("c3d4.elb.example.com"))
+	})
+})
@@ -9,6 +9,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"myproject/consts"
 )
 
 // GeneratePort generates a ServicePort object based on the provided values
@@ -32,10 +33,11 @@ func GeneratePort(name string, port, targetPort int, protocol string) corev1.Ser
 }
 
 // CreateService creates a Kubernetes service of a specified type (ClusterIP, NodePort, LoadBalancer, or Headless).
-func CreateService(clientset *kubernetes.Clientset, namespace, serviceName string, serviceType string, ports []corev1.ServicePort, labels map[string]string) (*corev1.Service, error) {
-	// Set default labels if not provided
-	if labels == nil {
-		labels = map[string]string{
+// selector picks the pods of the service, labels are added to the default labels of the service itself.
+func CreateService(clientset kubernetes.Interface, namespace, serviceName string, serviceType string, ports []corev1.ServicePort, selector, labels map[string]string) (*corev1.Service, error) {
+	// Set default selector if not provided
+	if selector == nil {
+		selector = map[string]string{
 			"app": serviceName,
 		}
 	}
@@ -45,10 +47,11 @@ func CreateService(clientset *kubernetes.Clientset, namespace, serviceName strin
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      serviceName,
 			Namespace: namespace,
+			Labels:    MergeLabels(consts.DefaultLabels, labels),
 		},
 		Spec: corev1.ServiceSpec{
 			Ports:    ports,
-			Selector: labels,
+			Selector: selector,
 		},
 	}
 
@@ -81,7 +84,7 @@ func CreateService(clientset *kubernetes.Clientset, namespace, serviceName strin
 	// If the service type is LoadBalancer, wait for the external IP to be assigned
 	if serviceType == "LoadBalancer" {
 		LogInfo("Waiting for the LoadBalancer service %s to get an external IP...", serviceName)
-		err := WaitForServiceReady(clientset, namespace, serviceName, 5*time.Second, 120*time.Second)
+		err := WaitForServiceReady(context.TODO(), clientset, namespace, serviceName, ExponentialBackoff(time.Second, 5*time.Second, 120*time.Second))
 		if err != nil {
 			LogError("Error waiting for LoadBalancer service %s to be ready: %v", serviceName, err)
 			return nil, err
@@ -94,15 +97,18 @@ func CreateService(clientset *kubernetes.Clientset, namespace, serviceName strin
 			return nil, err
 		}
 
-		LogInfo("Service %s is ready with external IP: %s", serviceName, service.Status.LoadBalancer.Ingress[0].IP)
+		LogInfo("Service %s is ready with external address: %s", serviceName, loadBalancerAddress(service.Status.LoadBalancer.Ingress))
 	}
 
 	LogInfo("Service %s of type %s created successfully", serviceName, serviceType)
 	return service, nil
 }
 
-// GetServiceIP fetches the IP of a service, handling both LoadBalancer and ClusterIP types
-func GetServiceIP(clientset *kubernetes.Clientset, namespace, serviceName string) (string, error) {
+// GetServiceIP fetches the address of a service: the cluster IP, the address of a Ready node for NodePort
+// services, the load balancer IP or hostname, or the ExternalName target. Unassigned load balancers
+// return an error wrapping ErrServiceAddressPending, headless and unknown types an *UnsupportedServiceError.
+// Use GetServiceEndpoint for the port to go with it.
+func GetServiceIP(clientset kubernetes.Interface, namespace, serviceName string) (string, error) {
 	// Fetch the service object
 	service, err := clientset.CoreV1().Services(namespace).Get(context.TODO(), serviceName, metav1.GetOptions{})
 	if err != nil {
@@ -110,28 +116,18 @@ func GetServiceIP(clientset *kubernetes.Clientset, namespace, serviceName string
 		return "", err
 	}
 
-	// Handle LoadBalancer service: return external IP
-	if service.Spec.Type == corev1.ServiceTypeLoadBalancer {
-		if len(service.Status.LoadBalancer.Ingress) > 0 {
-			return service.Status.LoadBalancer.Ingress[0].IP, nil
-		}
-		LogError("LoadBalancer service %s has no external IP assigned", serviceName)
-		return "", nil
-	}
-
-	// Handle ClusterIP service: return ClusterIP
-	if service.Spec.Type == corev1.ServiceTypeClusterIP {
-		LogInfo("Service %s has a ClusterIP: %s", serviceName, service.Spec.ClusterIP)
-		return service.Spec.ClusterIP, nil
+	host, err := serviceHost(clientset, service)
+	if err != nil {
+		LogError("Failed to get the address of service %s: %v", serviceName, err)
+		return "", err
 	}
 
-	// Handle unsupported service types
-	LogError("Unsupported service type %s for service %s", service.Spec.Type, serviceName)
-	return "", nil
+	LogInfo("Service %s of type %s has the address %s", serviceName, serviceTypeOf(service), host)
+	return host, nil
 }
 
 // GetServiceDNSName retrieves the DNS name of a service in the cluster dynamically
-func GetServiceDNSName(clientset *kubernetes.Clientset, namespace, serviceName string) (string, error) {
+func GetServiceDNSName(clientset kubernetes.Interface, namespace, serviceName string) (string, error) {
 	// Fetch the service from the Kubernetes cluster
 	service, err := clientset.CoreV1().Services(namespace).Get(context.TODO(), serviceName, metav1.GetOptions{})
 	if err != nil {
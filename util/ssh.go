@@ -1,6 +1,7 @@
 package util
 
 import (
+    "context"
     "golang.org/x/crypto/ssh"
     "io/ioutil"
 	"io"
@@ -88,6 +89,12 @@ func NewSSHClient(config *SSHConfig) (*ssh.Client, error) {
 
 // RunCommand runs a command on the remote VM and returns the output
 func (s *SSHClient) RunCommand(cmd string) (string, error) {
+    return s.RunCommandWithContext(context.Background(), cmd)
+}
+
+// RunCommandWithContext runs a command on the remote VM and returns the output. When the context
+// ends first, the command is killed and its session closed.
+func (s *SSHClient) RunCommandWithContext(ctx context.Context, cmd string) (string, error) {
     session, err := s.client.NewSession()
     if err != nil {
         LogError("Failed to create session: %v", err)
@@ -95,8 +102,24 @@ func (s *SSHClient) RunCommand(cmd string) (string, error) {
     }
     defer session.Close()
 
+    finished := make(chan struct{})
+    defer close(finished)
+    go func() {
+        select {
+        case <-ctx.Done():
+            // Not every server honours signals, closing the session unblocks the command anyway
+            session.Signal(ssh.SIGKILL)
+            session.Close()
+        case <-finished:
+        }
+    }()
+
     // Run the command
     output, err := session.CombinedOutput(cmd)
+    if ctxErr := ctx.Err(); ctxErr != nil {
+        LogError("Command interrupted: %v", ctxErr)
+        return "", fmt.Errorf("command interrupted: %w", ctxErr)
+    }
     if err != nil {
         LogError("Failed to run command: %v", err)
         return "", err
@@ -0,0 +1,95 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// StandInResponse is the body returned by the stand-in server
+const StandInResponse = "openshift-testing stand-in"
+
+// StandInRequest is a request received by the stand-in server
+type StandInRequest struct {
+	RemoteAddr string
+	Path       string
+	Time       time.Time
+}
+
+// StandInServer is an HTTP server running next to the suite that plays an external destination
+// for egress specs. The cluster must be able to reach the runner at Host.
+type StandInServer struct {
+	Host     string // Address the cluster reaches the server at
+	Port     int
+	server   *http.Server
+	mu       sync.Mutex
+	requests []StandInRequest
+}
+
+// StartStandInServer listens on listenAddress (e.g. ":8080", or ":0" for a random port) and
+// serves 200 responses in the background until Close is called
+func StartStandInServer(listenAddress, advertisedHost string) (*StandInServer, error) {
+	if advertisedHost == "" {
+		return nil, fmt.Errorf("the stand-in server needs the address the cluster reaches it at")
+	}
+	listener, err := net.Listen("tcp", listenAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %v", listenAddress, err)
+	}
+
+	s := &StandInServer{Host: advertisedHost, Port: listener.Addr().(*net.TCPAddr).Port}
+	s.server = &http.Server{Handler: http.HandlerFunc(s.serve), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			LogError("Stand-in server stopped: %v", err)
+		}
+	}()
+
+	LogInfo("Stand-in server listening on %s, reachable at %s", listener.Addr(), s.URL())
+	return s, nil
+}
+
+func (s *StandInServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, StandInRequest{RemoteAddr: r.RemoteAddr, Path: r.URL.Path, Time: time.Now()})
+	s.mu.Unlock()
+
+	LogDebug("Stand-in server got %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+	fmt.Fprint(w, StandInResponse)
+}
+
+// URL returns the address to request from the cluster
+func (s *StandInServer) URL() string {
+	return "http://" + net.JoinHostPort(s.Host, strconv.Itoa(s.Port)) + "/"
+}
+
+// CIDR returns the single address CIDR of the host for EgressFirewall rules, or an error if Host is a name
+func (s *StandInServer) CIDR() (string, error) {
+	ip := net.ParseIP(s.Host)
+	if ip == nil {
+		return "", fmt.Errorf("stand-in host %s is not an IP address", s.Host)
+	}
+	if ip.To4() != nil {
+		return ip.String() + "/32", nil
+	}
+	return ip.String() + "/128", nil
+}
+
+// Requests returns a copy of the requests received so far
+func (s *StandInServer) Requests() []StandInRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]StandInRequest{}, s.requests...)
+}
+
+// Close stops the server
+func (s *StandInServer) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.server.Shutdown(ctx)
+}
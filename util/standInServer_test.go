@@ -0,0 +1,42 @@
+package util_test
+
+import (
+	"io"
+	"net/http"
+
+	"myproject/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StandInServer", func() {
+	It("should answer and record requests", func() {
+		server, err := util.StartStandInServer("127.0.0.1:0", "127.0.0.1")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(server.Close)
+
+		response, err := http.Get(server.URL() + "probe")
+		Expect(err).ToNot(HaveOccurred())
+		defer response.Body.Close()
+		body, err := io.ReadAll(response.Body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(body)).To(Equal(util.StandInResponse))
+
+		requests := server.Requests()
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].Path).To(Equal("/probe"))
+		Expect(requests[0].RemoteAddr).To(HavePrefix("127.0.0.1:"))
+	})
+
+	It("should give the CIDR of IP hosts only", func() {
+		Expect((&util.StandInServer{Host: "192.0.2.10"}).CIDR()).To(Equal("192.0.2.10/32"))
+		Expect((&util.StandInServer{Host: "2001:db8::1"}).CIDR()).To(Equal("2001:db8::1/128"))
+		_, err := (&util.StandInServer{Host: "runner.example.com"}).CIDR()
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should require the advertised host", func() {
+		_, err := util.StartStandInServer("127.0.0.1:0", "")
+		Expect(err).To(HaveOccurred())
+	})
+})
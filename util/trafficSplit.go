@@ -0,0 +1,131 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sampleReportPrefix starts the line printed for every request by the sampling script
+const sampleReportPrefix = "SAMPLE "
+
+// SampleHTTPResponses sends the requests one after the other from the executor and returns the first
+// line of every response body, in order. Failed requests give an empty body. Every request opens a
+// new connection without cookies, so sticky sessions don't skew the result.
+func SampleHTTPResponses(ctx context.Context, executor ProbeExecutor, url string, requests int, timeout time.Duration) ([]string, error) {
+	request := fmt.Sprintf(`curl --silent --insecure --max-time %d %s | head -n 1 | tr -d '\r'`, probeSeconds(timeout), shellQuote(url))
+	return sampleHTTP(ctx, executor, url, requests, request)
+}
+
+// sampleHTTP runs the request command the given number of times and returns what it printed each time
+func sampleHTTP(ctx context.Context, executor ProbeExecutor, url string, requests int, request string) ([]string, error) {
+	if requests <= 0 {
+		return nil, fmt.Errorf("the number of requests must be positive, got %d", requests)
+	}
+	script := fmt.Sprintf(`i=0; while [ $i -lt %d ]; do sample=$(%s); echo "%s$sample"; i=$((i+1)); done`,
+		requests, request, sampleReportPrefix)
+
+	output, err := executor.RunScript(ctx, script)
+	if err != nil {
+		LogError("Failed to sample %s from %s: %v", url, executor, err)
+		return nil, fmt.Errorf("failed to sample %s from %s: %v", url, executor, err)
+	}
+
+	var samples []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, sampleReportPrefix) {
+			samples = append(samples, strings.TrimSpace(strings.TrimPrefix(line, sampleReportPrefix)))
+		}
+	}
+	if len(samples) != requests {
+		return samples, fmt.Errorf("sampling %s from %s reported %d of %d requests", url, executor, len(samples), requests)
+	}
+	return samples, nil
+}
+
+// TrafficSplit counts which backend answered each request
+type TrafficSplit struct {
+	Total     int
+	Counts    map[string]int // Requests per backend identifier
+	Unmatched []string       // Bodies of failed requests or of requests no backend claims
+}
+
+// NewTrafficSplit attributes every response body to the backend whose identifier it is, ignoring
+// surrounding whitespace. Bodies are compared whole, so identifiers such as pod-1 and pod-10 can't
+// be confused.
+func NewTrafficSplit(bodies []string, identifiers []string) TrafficSplit {
+	split := TrafficSplit{Total: len(bodies), Counts: map[string]int{}}
+	for _, identifier := range identifiers {
+		split.Counts[identifier] = 0
+	}
+	for _, body := range bodies {
+		identifier := strings.TrimSpace(body)
+		if _, known := split.Counts[identifier]; known && identifier != "" {
+			split.Counts[identifier]++
+		} else {
+			split.Unmatched = append(split.Unmatched, body)
+		}
+	}
+	return split
+}
+
+// Share returns the fraction of the requests answered by the backend
+func (s TrafficSplit) Share(identifier string) float64 {
+	if s.Total == 0 {
+		return 0
+	}
+	return float64(s.Counts[identifier]) / float64(s.Total)
+}
+
+// Check compares the observed shares with the ones the weights ask for. Tolerance is the largest
+// accepted difference per backend as a fraction of all requests, e.g. 0.1 for ten percentage points.
+// Any unmatched response fails the check.
+func (s TrafficSplit) Check(weights map[string]int32, tolerance float64) error {
+	var total int32
+	for _, weight := range weights {
+		total += weight
+	}
+	if total == 0 {
+		return fmt.Errorf("all backend weights are 0, no traffic is expected")
+	}
+
+	var problems []string
+	if len(s.Unmatched) > 0 {
+		problems = append(problems, fmt.Sprintf("%d responses came from no known backend: %q", len(s.Unmatched), s.Unmatched))
+	}
+	for _, identifier := range sortedKeys(weights) {
+		expected := float64(weights[identifier]) / float64(total)
+		if observed := s.Share(identifier); math.Abs(observed-expected) > tolerance {
+			problems = append(problems, fmt.Sprintf("%s answered %.1f%% of the requests, expected %.1f%% ± %.1f%%", identifier, observed*100, expected*100, tolerance*100))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("unexpected traffic split (%s): %s", s, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (s TrafficSplit) String() string {
+	parts := make([]string, 0, len(s.Counts)+1)
+	for _, identifier := range sortedKeys(s.Counts) {
+		parts = append(parts, fmt.Sprintf("%s=%d", identifier, s.Counts[identifier]))
+	}
+	if len(s.Unmatched) > 0 {
+		parts = append(parts, fmt.Sprintf("unmatched=%d", len(s.Unmatched)))
+	}
+	return fmt.Sprintf("%s of %d", strings.Join(parts, " "), s.Total)
+}
+
+// sortedKeys returns the keys of the map in order, for stable messages
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
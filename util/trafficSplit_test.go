@@ -0,0 +1,81 @@
+package util_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"sync/atomic"
+	"time"
+
+	"myproject/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Traffic split", func() {
+	It("should not confuse identifiers containing each other", func() {
+		split := util.NewTrafficSplit([]string{"pod-10", "pod-1", "pod-10", "<h1>pod-1</h1>"}, []string{"pod-1", "pod-10"})
+		Expect(split.Counts).To(Equal(map[string]int{"pod-1": 1, "pod-10": 2}))
+		Expect(split.Unmatched).To(Equal([]string{"<h1>pod-1</h1>"}))
+	})
+
+	It("should attribute bodies to backends and compare with the weights", func() {
+		split := util.NewTrafficSplit([]string{"pod-a", "pod-a", " pod-b ", "pod-a", "", "pod-c"}, []string{"pod-a", "pod-b"})
+		Expect(split.Total).To(Equal(6))
+		Expect(split.Counts).To(Equal(map[string]int{"pod-a": 3, "pod-b": 1}))
+		Expect(split.Unmatched).To(Equal([]string{"", "pod-c"}))
+		Expect(split.String()).To(Equal("pod-a=3 pod-b=1 unmatched=2 of 6"))
+
+		err := split.Check(map[string]int32{"pod-a": 1, "pod-b": 1}, 0.5)
+		Expect(err).To(MatchError(ContainSubstring(`2 responses came from no known backend: ["" "pod-c"]`)))
+	})
+
+	DescribeTable("checks the shares against the weights",
+		func(counts map[string]int, weights map[string]int32, tolerance float64, problem string) {
+			var bodies []string
+			for identifier, count := range counts {
+				for i := 0; i < count; i++ {
+					bodies = append(bodies, identifier)
+				}
+			}
+			err := util.NewTrafficSplit(bodies, []string{"stable", "canary"}).Check(weights, tolerance)
+			if problem == "" {
+				Expect(err).ToNot(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(ContainSubstring(problem)))
+			}
+		},
+		Entry("within tolerance", map[string]int{"stable": 70, "canary": 30}, map[string]int32{"stable": 3, "canary": 1}, 0.1, ""),
+		Entry("outside tolerance", map[string]int{"stable": 50, "canary": 50}, map[string]int32{"stable": 3, "canary": 1}, 0.1,
+			"canary answered 50.0% of the requests, expected 25.0% ± 10.0%"),
+		Entry("backend with weight 0 gets traffic", map[string]int{"stable": 90, "canary": 10}, map[string]int32{"stable": 1, "canary": 0}, 0.05,
+			"canary answered 10.0% of the requests, expected 0.0%"),
+		Entry("all weights 0", map[string]int{"stable": 1}, map[string]int32{"stable": 0, "canary": 0}, 0.1, "all backend weights are 0"),
+	)
+
+	It("should sample response bodies with curl", func() {
+		if _, err := exec.LookPath("curl"); err != nil {
+			Skip("curl is not installed")
+		}
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Every fourth request goes to the canary
+			if requests.Add(1)%4 == 0 {
+				fmt.Fprintln(w, "canary")
+				return
+			}
+			fmt.Fprintln(w, "stable")
+		}))
+		DeferCleanup(server.Close)
+
+		bodies, err := util.SampleHTTPResponses(context.Background(), localProbeExecutor{}, server.URL, 8, 5*time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(bodies).To(HaveLen(8))
+
+		split := util.NewTrafficSplit(bodies, []string{"stable", "canary"})
+		Expect(split.Counts).To(Equal(map[string]int{"stable": 6, "canary": 2}))
+		Expect(split.Check(map[string]int32{"stable": 3, "canary": 1}, 0)).To(Succeed())
+	})
+})
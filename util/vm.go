@@ -14,7 +14,6 @@ import (
 	kubecli "kubevirt.io/client-go/kubecli"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
-	"k8s.io/client-go/rest"
 	"myproject/consts"
 )
 
@@ -77,13 +76,18 @@ func addSSHKeyToCloudInit(existingData, sshPublicKey string) string {
 	return existingData
 }
 
-// CreateVM creates a VM using the given parameters and optionally adds an SSH public key
-func CreateVM(config *rest.Config, namespace, templateName, vmName string, resourceRequirements *kubevirtv1.ResourceRequirements, labels map[string]string, waitForCreation bool, scriptPath, sshPublicKeyPath string) (*kubevirtv1.VirtualMachine, error) {
+// CreateVM creates a VM using the given parameters and optionally adds an SSH public key.
+// The template is looked up in templateNamespace, or in consts.DefaultTemplateNamespace if it is empty.
+func CreateVM(templateClient templateclientset.Interface, virtClient kubecli.KubevirtClient, namespace, templateNamespace, templateName, vmName string, resourceRequirements *kubevirtv1.ResourceRequirements, labels map[string]string, waitForCreation bool, scriptPath, sshPublicKeyPath string) (*kubevirtv1.VirtualMachine, error) {
     if templateName == "" {
 		templateName = consts.DefaultTemplateName
-		LogInfo("Using the default template", templateName)
+		LogInfo("Using the default template: %s", templateName)
 	}
     
+	if templateNamespace == "" {
+		templateNamespace = consts.DefaultTemplateNamespace
+	}
+
     if vmName == "" {
 		vmName = GenerateRandomName()
 		LogInfo("Generated random VM name: %s", vmName)
@@ -96,8 +100,7 @@ func CreateVM(config *rest.Config, namespace, templateName, vmName string, resou
 	}
 
 	if labels == nil {
-		labels = consts.DefaultLabels
-		labels["app"] = vmName
+		labels = DefaultLabelsFor(vmName)
 		LogInfo("Using default labels for VM: %s", vmName)
 	}
 
@@ -111,15 +114,9 @@ func CreateVM(config *rest.Config, namespace, templateName, vmName string, resou
 		LogInfo("Successfully read external script from %s", scriptPath)
 	}
 
-	templateClient, err := templateclientset.NewForConfig(config)
-	if err != nil {
-		LogError("Failed to create template client: %v", err)
-		return nil, err
-	}
-
-	template, err := templateClient.TemplateV1().Templates(consts.DefaultTemplateNamespace).Get(context.TODO(), templateName, metav1.GetOptions{})
+	template, err := templateClient.TemplateV1().Templates(templateNamespace).Get(context.TODO(), templateName, metav1.GetOptions{})
 	if err != nil {
-		LogError("Failed to fetch template: %v", err)
+		LogError("Failed to fetch template %s/%s: %v", templateNamespace, templateName, err)
 		return nil, err
 	}
 
@@ -142,21 +139,9 @@ func CreateVM(config *rest.Config, namespace, templateName, vmName string, resou
 			vm.Spec.Template.Spec.Domain.Resources = *resourceRequirements
 			vm.ObjectMeta.Name = vmName
 
-			if vm.ObjectMeta.Labels == nil {
-				vm.ObjectMeta.Labels = labels
-			} else {
-				for key, value := range labels {
-					vm.ObjectMeta.Labels[key] = value
-				}
-			}
-
-			if vm.Spec.Template.ObjectMeta.Labels == nil {
-				vm.Spec.Template.ObjectMeta.Labels = labels
-			} else {
-				for key, value := range labels {
-					vm.Spec.Template.ObjectMeta.Labels[key] = value
-				}
-			}
+			// The VM and its VMI template get their own copies, so neither shares the caller's map
+			vm.ObjectMeta.Labels = MergeLabels(vm.ObjectMeta.Labels, labels)
+			vm.Spec.Template.ObjectMeta.Labels = MergeLabels(vm.Spec.Template.ObjectMeta.Labels, labels)
 
 			running := true
 			vm.Spec.Running = &running
@@ -200,18 +185,13 @@ func CreateVM(config *rest.Config, namespace, templateName, vmName string, resou
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      vmName,
 			Namespace: namespace,
+			Labels:    MergeLabels(labels),
 		},
 		Spec: templatev1.TemplateInstanceSpec{
 			Template: *template,
 		},
 	}
 
-	virtClient, err := kubecli.GetKubevirtClientFromRESTConfig(config)
-	if err != nil {
-		LogError("Failed to create KubeVirt client: %v", err)
-		return nil, err
-	}
-
 	_, err = templateClient.TemplateV1().TemplateInstances(namespace).Create(context.TODO(), templateInstance, metav1.CreateOptions{})
 	if err != nil {
 		LogError("Failed to create TemplateInstance: %v", err)
@@ -220,7 +200,7 @@ func CreateVM(config *rest.Config, namespace, templateName, vmName string, resou
 
 	if waitForCreation {
 		LogInfo("Waiting for the TemplateInstance %s to be created", vmName)
-		err = WaitForTemplateInstanceReady(templateClient, namespace, vmName, 5*time.Second, 120*time.Second)
+		err = WaitForTemplateInstanceReady(context.TODO(), templateClient, namespace, vmName, ExponentialBackoff(time.Second, 5*time.Second, 120*time.Second))
 		if err != nil {
 			LogError("Error waiting for TemplateInstance: %v", err)
 			return nil, err
@@ -228,7 +208,7 @@ func CreateVM(config *rest.Config, namespace, templateName, vmName string, resou
 		LogInfo("TemplateInstance %s has been created", vmName)
 
 		LogInfo("Waiting for the VM %s to be created", vmName)
-		err = WaitForVMReady(virtClient, namespace, vmName, 5*time.Second, 120*time.Second)
+		err = WaitForVMReady(context.TODO(), virtClient, namespace, vmName, ExponentialBackoff(time.Second, 5*time.Second, 120*time.Second))
 		if err != nil {
 			LogError("Error waiting for VM: %v", err)
 			return nil, err
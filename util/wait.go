@@ -1,56 +1,153 @@
 package util
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math/rand"
 	"time"
 )
 
-// WaitFor function accepts a checking function, interval, timeout, and maxRetries to wait for a resource to be in a ready state.
-func WaitFor(checkFunc func() (bool, error), interval, timeout time.Duration, maxRetries int) error {
+// Typed errors returned by the wait helpers. Callers can test for them with errors.Is.
+var (
+	// ErrWaitTimeout is returned when the timeout (or the context deadline) expires before the condition is met.
+	ErrWaitTimeout = errors.New("timeout reached while waiting")
+	// ErrRetriesExhausted is returned when the maximum number of attempts was used without the condition being met.
+	ErrRetriesExhausted = errors.New("max retries reached")
+	// ErrTerminalCondition is returned when the condition reports an error together with done=true.
+	ErrTerminalCondition = errors.New("terminal condition error")
+)
+
+// ConditionFunc is checked by WaitForWithContext. Returning (true, nil) ends the wait successfully,
+// (true, err) aborts it with a terminal error and (false, err) logs the error and tries again.
+type ConditionFunc func(ctx context.Context) (bool, error)
+
+// Backoff describes how WaitForWithContext spaces its attempts and when it gives up.
+type Backoff struct {
+	Interval    time.Duration // Delay after the first attempt
+	Factor      float64       // Multiplier applied to the delay after every attempt, values <= 1 keep it constant
+	Jitter      float64       // Random extra fraction of the delay (0.1 adds up to 10%)
+	MaxInterval time.Duration // Upper bound for a single delay, 0 means no cap
+	Timeout     time.Duration // Overall deadline, 0 means no deadline besides the context
+	MaxRetries  int           // Maximum number of attempts, 0 means unlimited
+}
+
+// ConstantBackoff returns a Backoff that waits the same interval between attempts.
+func ConstantBackoff(interval, timeout time.Duration, maxRetries int) Backoff {
+	return Backoff{
+		Interval:   interval,
+		Factor:     1,
+		Timeout:    timeout,
+		MaxRetries: maxRetries,
+	}
+}
+
+// ExponentialBackoff returns a Backoff that doubles the delay after each attempt, up to maxInterval, with a small jitter.
+func ExponentialBackoff(interval, maxInterval, timeout time.Duration) Backoff {
+	return Backoff{
+		Interval:    interval,
+		Factor:      2,
+		Jitter:      0.1,
+		MaxInterval: maxInterval,
+		Timeout:     timeout,
+	}
+}
+
+// delay returns the sleep duration to use for the given (zero based) attempt.
+func (b Backoff) delay(attempt int) time.Duration {
+	d := float64(b.Interval)
+	if b.Factor > 1 {
+		for i := 0; i < attempt; i++ {
+			d *= b.Factor
+			if b.MaxInterval > 0 && d >= float64(b.MaxInterval) {
+				break
+			}
+		}
+	}
+	if b.Jitter > 0 {
+		d += d * b.Jitter * rand.Float64()
+	}
+	// Cap after the jitter so MaxInterval stays an upper bound
+	if b.MaxInterval > 0 && d > float64(b.MaxInterval) {
+		d = float64(b.MaxInterval)
+	}
+	return time.Duration(d)
+}
+
+// WaitForWithContext checks the condition immediately and then again according to the backoff,
+// until it is met, the backoff gives up, or the context is cancelled.
+func WaitForWithContext(ctx context.Context, backoff Backoff, checkFunc ConditionFunc) error {
+	if backoff.Interval <= 0 {
+		return fmt.Errorf("invalid parameters: interval must be greater than 0")
+	}
 	// Validate that at least one stopping condition is valid.
-	if timeout <= 0 && maxRetries <= 0 {
+	if backoff.Timeout <= 0 && backoff.MaxRetries <= 0 && ctx.Done() == nil {
 		return fmt.Errorf("invalid parameters: both timeout and maxRetries cannot be less than or equal to 0")
 	}
 
-	// Initialize timeout and retry counters
-	timeoutChan := time.After(timeout)
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
-
-	retries := 0
+	if backoff.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, backoff.Timeout)
+		defer cancel()
+	}
 
-	for {
-		select {
-		case <-timeoutChan:
-			if timeout > 0 {
-				return fmt.Errorf("timeout reached while waiting")
-			}
-		case <-ticker.C:
-			// Check the condition function
-			ready, err := checkFunc()
-			if err != nil {
-				LogError("Error during wait: %v", err) // Log the error but don't return it immediately
-				if ready {
-					return err
-				}
-			}
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		if ctx.Err() != nil {
+			return waitContextError(ctx, lastErr)
+		}
 
-			// If the check succeeds, exit the function.
+		ready, err := checkFunc(ctx)
+		if err != nil {
+			LogError("Error during wait: %v", err) // Log the error but don't return it unless it is terminal
 			if ready {
-				return nil
+				return fmt.Errorf("%w: %w", ErrTerminalCondition, err)
 			}
+			lastErr = err
+		}
+
+		// If the check succeeds, exit the function.
+		if ready {
+			return nil
+		}
 
-			// Increment retry count only when retries are applicable
-			if maxRetries > 0 {
-				retries++
-				LogInfo("Retrying... Attempt %d/%d", retries, maxRetries)
-				if retries >= maxRetries {
-					return fmt.Errorf("max retries reached (%d/%d)", retries, maxRetries)
+		if backoff.MaxRetries > 0 {
+			LogInfo("Retrying... Attempt %d/%d", attempt+1, backoff.MaxRetries)
+			if attempt+1 >= backoff.MaxRetries {
+				if lastErr != nil {
+					return fmt.Errorf("%w (%d/%d): %w", ErrRetriesExhausted, attempt+1, backoff.MaxRetries, lastErr)
 				}
-			} else {
-				LogInfo("Retrying...")
+				return fmt.Errorf("%w (%d/%d)", ErrRetriesExhausted, attempt+1, backoff.MaxRetries)
 			}
+		} else {
+			LogInfo("Retrying...")
 		}
+
+		timer := time.NewTimer(backoff.delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return waitContextError(ctx, lastErr)
+		case <-timer.C:
+		}
+	}
+}
+
+// waitContextError converts a finished context into the matching typed wait error.
+func waitContextError(ctx context.Context, lastErr error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		if lastErr != nil {
+			return fmt.Errorf("%w: %w", ErrWaitTimeout, lastErr)
+		}
+		return ErrWaitTimeout
 	}
+	return fmt.Errorf("wait cancelled: %w", ctx.Err())
 }
 
+// WaitFor function accepts a checking function, interval, timeout, and maxRetries to wait for a resource to be in a ready state.
+// It is a shorthand for WaitForWithContext with a constant backoff and no cancellation.
+func WaitFor(checkFunc func() (bool, error), interval, timeout time.Duration, maxRetries int) error {
+	return WaitForWithContext(context.Background(), ConstantBackoff(interval, timeout, maxRetries), func(context.Context) (bool, error) {
+		return checkFunc()
+	})
+}
@@ -0,0 +1,65 @@
+package util_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"myproject/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WaitForWithContext", func() {
+	It("should check the condition immediately", func() {
+		start := time.Now()
+		err := util.WaitForWithContext(context.Background(), util.ConstantBackoff(time.Minute, time.Minute, 0), func(context.Context) (bool, error) {
+			return true, nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+	})
+
+	It("should return ErrWaitTimeout when the timeout expires", func() {
+		err := util.WaitForWithContext(context.Background(), util.ConstantBackoff(10*time.Millisecond, 50*time.Millisecond, 0), func(context.Context) (bool, error) {
+			return false, nil
+		})
+		Expect(errors.Is(err, util.ErrWaitTimeout)).To(BeTrue())
+	})
+
+	It("should return ErrRetriesExhausted after the maximum attempts", func() {
+		attempts := 0
+		err := util.WaitForWithContext(context.Background(), util.ConstantBackoff(time.Millisecond, 0, 3), func(context.Context) (bool, error) {
+			attempts++
+			return false, nil
+		})
+		Expect(errors.Is(err, util.ErrRetriesExhausted)).To(BeTrue())
+		Expect(attempts).To(Equal(3))
+	})
+
+	It("should wrap terminal condition errors", func() {
+		cause := errors.New("boom")
+		err := util.WaitForWithContext(context.Background(), util.ConstantBackoff(time.Millisecond, time.Second, 0), func(context.Context) (bool, error) {
+			return true, cause
+		})
+		Expect(errors.Is(err, util.ErrTerminalCondition)).To(BeTrue())
+		Expect(errors.Is(err, cause)).To(BeTrue())
+	})
+
+	It("should stop when the context is cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := util.WaitForWithContext(ctx, util.ExponentialBackoff(time.Millisecond, 10*time.Millisecond, 0), func(context.Context) (bool, error) {
+			return false, nil
+		})
+		Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+	})
+
+	It("should never exceed the maximum interval, jitter included", func() {
+		backoff := util.ExponentialBackoff(time.Second, 10*time.Second, time.Minute)
+		for attempt := 0; attempt < 50; attempt++ {
+			Expect(util.BackoffDelay(backoff, attempt)).To(BeNumerically("<=", 10*time.Second))
+		}
+		Expect(util.BackoffDelay(backoff, 20)).To(Equal(10 * time.Second))
+	})
+})
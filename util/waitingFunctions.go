@@ -2,69 +2,131 @@ package util
 
 import (
 	"context"
-	"time"
 	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	templateclientset "github.com/openshift/client-go/template/clientset/versioned"
 	templatev1 "github.com/openshift/api/template/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
+	kubevirtv1 "kubevirt.io/api/core/v1"
 	"kubevirt.io/client-go/kubecli"
 )
 
 // WaitForPodState waits for a Pod to reach a specific state (running, completed, or failed) based on the provided options.
-func WaitForPodState(clientset *kubernetes.Clientset, namespace, podName string, interval, timeout time.Duration, retries int, failOnFailure bool) error {
-	return WaitFor(func() (bool, error) {
-		pod, err := GetPod(clientset, namespace, podName)
+// It reacts to watch events and only polls with the backoff if the watch breaks.
+// On failure the returned *WaitError describes the last observed pod and its recent Events.
+func WaitForPodState(ctx context.Context, clientset kubernetes.Interface, namespace, podName string, backoff Backoff, failOnFailure bool) error {
+	pods := clientset.CoreV1().Pods(namespace)
+	getPod := func(ctx context.Context) (runtime.Object, error) {
+		pod, err := pods.Get(ctx, podName, metav1.GetOptions{})
 		if err != nil {
 			LogError("Error fetching pod: %v", err)
-			return false, err
+			return nil, err
 		}
+		return pod, nil
+	}
 
-		switch pod.Status.Phase {
-		case corev1.PodRunning:
-			LogInfo("Pod %s is now running.", podName)
-			return true, nil
-		case corev1.PodSucceeded:
-			LogInfo("Pod %s has completed successfully.", podName)
-			return true, nil
-		case corev1.PodFailed:
-			LogWarn("Pod %s has failed. This is considered a terminal state.", podName)
-			// Decide whether to treat failure as terminal or not based on the failOnFailure flag
-			if failOnFailure {
-				return true, fmt.Errorf("pod %s has failed", podName)
-			}
-			// If failOnFailure is false, just log the failure and continue
-			return true, nil
-		default:
-			LogInfo("Pod %s is in phase %s.", podName, pod.Status.Phase)
-			return false, nil
+	var lastPod *corev1.Pod
+	err := WaitForObject(ctx, backoff, podName, getPod, pods.Watch, func(obj runtime.Object) (bool, error) {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok {
+			return false, fmt.Errorf("unexpected object type %T", obj)
+		}
+		lastPod = pod
+		return podStateReached(pod, failOnFailure)
+	})
+	if err != nil {
+		diag := WaitDiagnostics{Kind: "Pod", Namespace: namespace, Name: podName}
+		if lastPod != nil {
+			diag = PodDiagnostics(lastPod)
+		}
+		return newWaitError(err, diag, clientset, "Pod")
+	}
+	return nil
+}
+
+// podStateReached reports whether the pod is running or finished, treating failure as terminal if requested.
+// Unrecoverable states (see ClassifyPodFailure) always end the wait with ErrPodUnrecoverable, and
+// transient ones such as a crash-looping container with ErrPodTransient, even if the phase is Running.
+// A failed pod only ends the wait with an error if failOnFailure is set.
+func podStateReached(pod *corev1.Pod, failOnFailure bool) (bool, error) {
+	switch class, reason := ClassifyPodFailure(pod); class {
+	case PodFailureTerminal:
+		LogWarn("Pod %s is in an unrecoverable state: %s", pod.Name, reason)
+		return true, fmt.Errorf("%w: pod %s: %s", ErrPodUnrecoverable, pod.Name, reason)
+	case PodFailureTransient:
+		if failOnFailure || pod.Status.Phase != corev1.PodFailed {
+			LogWarn("Pod %s failed transiently: %s", pod.Name, reason)
+			return true, fmt.Errorf("%w: pod %s: %s", ErrPodTransient, pod.Name, reason)
+		}
+	}
+
+	switch pod.Status.Phase {
+	case corev1.PodRunning:
+		LogInfo("Pod %s is now running.", pod.Name)
+		return true, nil
+	case corev1.PodSucceeded:
+		LogInfo("Pod %s has completed successfully.", pod.Name)
+		return true, nil
+	case corev1.PodFailed:
+		LogWarn("Pod %s has failed. This is considered a terminal state.", pod.Name)
+		// Decide whether to treat failure as terminal or not based on the failOnFailure flag
+		if failOnFailure {
+			return true, fmt.Errorf("pod %s has failed", pod.Name)
 		}
-	}, interval, timeout, retries)
+		// If failOnFailure is false, just log the failure and continue
+		return true, nil
+	default:
+		LogInfo("Pod %s is in phase %s.", pod.Name, pod.Status.Phase)
+		return false, nil
+	}
 }
 
 // WaitForVMReady waits for a KubeVirt VM to be ready.
-func WaitForVMReady(virtClient kubecli.KubevirtClient, namespace, vmName string, interval, timeout time.Duration) error {
-	return WaitFor(func() (bool, error) {
-		vm, err := virtClient.VirtualMachine(namespace).Get(context.TODO(), vmName, metav1.GetOptions{})
+// It reacts to watch events and only polls with the backoff if the watch breaks.
+// On failure the returned *WaitError describes the last observed VM and its recent Events.
+func WaitForVMReady(ctx context.Context, virtClient kubecli.KubevirtClient, namespace, vmName string, backoff Backoff) error {
+	vms := virtClient.VirtualMachine(namespace)
+	getVM := func(ctx context.Context) (runtime.Object, error) {
+		vm, err := vms.Get(ctx, vmName, metav1.GetOptions{})
 		if err != nil {
 			LogError("Error fetching VM: %v", err)
-			return false, err
+			return nil, err
 		}
+		return vm, nil
+	}
+
+	var lastVM *kubevirtv1.VirtualMachine
+	err := WaitForObject(ctx, backoff, vmName, getVM, vms.Watch, func(obj runtime.Object) (bool, error) {
+		vm, ok := obj.(*kubevirtv1.VirtualMachine)
+		if !ok {
+			return false, fmt.Errorf("unexpected object type %T", obj)
+		}
+		lastVM = vm
 
 		if vm.Status.Ready {
 			LogInfo("VM %s is ready.", vmName)
 		}
 
 		return vm.Status.Ready, nil
-	}, interval, timeout, 0)
+	})
+	if err != nil {
+		diag := WaitDiagnostics{Kind: "VirtualMachine", Namespace: namespace, Name: vmName}
+		if lastVM != nil {
+			diag = VMDiagnostics(lastVM)
+		}
+		return newWaitError(err, diag, virtClient, "VirtualMachine", "VirtualMachineInstance")
+	}
+	return nil
 }
 
 // WaitForTemplateInstanceReady waits for an OpenShift TemplateInstance to be instantiated.
-func WaitForTemplateInstanceReady(templateClient *templateclientset.Clientset, namespace, templateInstanceName string, interval, timeout time.Duration) error {
-	return WaitFor(func() (bool, error) {
-		templateInstance, err := templateClient.TemplateV1().TemplateInstances(namespace).Get(context.TODO(), templateInstanceName, metav1.GetOptions{})
+func WaitForTemplateInstanceReady(ctx context.Context, templateClient templateclientset.Interface, namespace, templateInstanceName string, backoff Backoff) error {
+	return WaitForWithContext(ctx, backoff, func(ctx context.Context) (bool, error) {
+		templateInstance, err := templateClient.TemplateV1().TemplateInstances(namespace).Get(ctx, templateInstanceName, metav1.GetOptions{})
 		if err != nil {
 			LogError("Error fetching TemplateInstance: %v", err)
 			return false, err
@@ -77,25 +139,51 @@ func WaitForTemplateInstanceReady(templateClient *templateclientset.Clientset, n
 			}
 		}
 		return false, nil
-	}, interval, timeout, 0)
+	})
 }
 
-// WaitForServiceReady waits for a LoadBalancer service to have an external IP assigned.
-func WaitForServiceReady(clientset *kubernetes.Clientset, namespace, serviceName string, interval, timeout time.Duration) error {
-	return WaitFor(func() (bool, error) {
-		service, err := clientset.CoreV1().Services(namespace).Get(context.TODO(), serviceName, metav1.GetOptions{})
+// WaitForServiceReady waits for a LoadBalancer service to have an external IP or hostname assigned.
+// It reacts to watch events and only polls with the backoff if the watch breaks.
+func WaitForServiceReady(ctx context.Context, clientset kubernetes.Interface, namespace, serviceName string, backoff Backoff) error {
+	services := clientset.CoreV1().Services(namespace)
+	getService := func(ctx context.Context) (runtime.Object, error) {
+		service, err := services.Get(ctx, serviceName, metav1.GetOptions{})
 		if err != nil {
 			LogError("Error fetching service: %v", err)
-			return false, err
+			return nil, err
 		}
+		return service, nil
+	}
 
-		// For LoadBalancer type services, check if an external IP is assigned
-		if len(service.Status.LoadBalancer.Ingress) > 0 && service.Status.LoadBalancer.Ingress[0].IP != "" {
-			LogInfo("Service %s has an external IP: %s", serviceName, service.Status.LoadBalancer.Ingress[0].IP)
+	return WaitForObject(ctx, backoff, serviceName, getService, services.Watch, func(obj runtime.Object) (bool, error) {
+		service, ok := obj.(*corev1.Service)
+		if !ok {
+			return false, fmt.Errorf("unexpected object type %T", obj)
+		}
+
+		// For LoadBalancer type services, check if an external IP or hostname is assigned
+		if address := loadBalancerAddress(service.Status.LoadBalancer.Ingress); address != "" {
+			LogInfo("Service %s has an external address: %s", serviceName, address)
 			return true, nil
 		}
 
-		LogInfo("Waiting for service %s to get an external IP...", serviceName)
+		LogInfo("Waiting for service %s to get an external address...", serviceName)
+		return false, nil
+	})
+}
+
+// WaitForDeletion waits until getFunc reports the object as NotFound, e.g. once its finalizers have completed.
+func WaitForDeletion(ctx context.Context, name string, backoff Backoff, getFunc func(ctx context.Context) error) error {
+	return WaitForWithContext(ctx, backoff, func(ctx context.Context) (bool, error) {
+		err := getFunc(ctx)
+		if apierrors.IsNotFound(err) {
+			LogInfo("%s has been deleted.", name)
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		LogInfo("Waiting for %s to be deleted...", name)
 		return false, nil
-	}, interval, timeout, 0)
+	})
 }
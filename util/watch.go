@@ -0,0 +1,196 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// ErrObjectDeleted is returned when the object is deleted while waiting for it
+var ErrObjectDeleted = errors.New("was deleted while waiting for it")
+
+// errWatchClosed is returned by watchUntil when the server closed the watch, which it does routinely
+var errWatchClosed = errors.New("watch channel closed")
+
+// maxWatchRestarts is how often in a row a watch that made no progress is re-established before polling
+const maxWatchRestarts = 3
+
+// WatchFunc starts a watch with the given options, e.g. clientset.CoreV1().Pods(namespace).Watch.
+type WatchFunc func(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+
+// GetFunc fetches the current version of the watched object.
+type GetFunc func(ctx context.Context) (runtime.Object, error)
+
+// ObjectConditionFunc evaluates an observed object with the same semantics as ConditionFunc.
+type ObjectConditionFunc func(obj runtime.Object) (bool, error)
+
+// WaitForObject waits for the named object to satisfy the condition.
+// It checks the current object first, then reacts to watch events. Closed watches are re-established
+// and expired resource versions re-fetched; polling with the backoff is the fallback when the watch
+// cannot be started or keeps breaking. The object being deleted ends the wait with ErrObjectDeleted.
+func WaitForObject(ctx context.Context, backoff Backoff, name string, getFunc GetFunc, watchFunc WatchFunc, condition ObjectConditionFunc) error {
+	if backoff.Interval <= 0 {
+		return fmt.Errorf("invalid parameters: interval must be greater than 0")
+	}
+
+	// A watch has no attempts, so a retry budget is turned into an equivalent deadline
+	timeout := backoff.Timeout
+	if timeout <= 0 && backoff.MaxRetries > 0 {
+		timeout = backoff.Interval * time.Duration(backoff.MaxRetries)
+	}
+	if timeout <= 0 && ctx.Done() == nil {
+		return fmt.Errorf("invalid parameters: both timeout and maxRetries cannot be less than or equal to 0")
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	poll := func(ctx context.Context) (bool, error) {
+		obj, err := getFunc(ctx)
+		if err != nil {
+			return false, err
+		}
+		return condition(obj)
+	}
+
+	// Check the current state, which also gives us a resource version to watch from
+	var lastErr error
+	resourceVersion := ""
+	checkCurrent := func() (bool, error) {
+		obj, err := getFunc(ctx)
+		if err != nil {
+			LogDebug("Fetch of %s failed, relying on the watch: %v", name, err)
+			lastErr = err
+			return false, nil
+		}
+		done, condErr := condition(obj)
+		if done {
+			if condErr != nil {
+				return true, fmt.Errorf("%w: %w", ErrTerminalCondition, condErr)
+			}
+			return true, nil
+		}
+		lastErr = condErr
+		if accessor, accErr := meta.Accessor(obj); accErr == nil {
+			resourceVersion = accessor.GetResourceVersion()
+		}
+		return false, nil
+	}
+	if done, err := checkCurrent(); done {
+		return err
+	}
+
+	// The server ends watches after a while and expires old resource versions, so the watch is
+	// re-established a few times before falling back to polling
+	restarts := 0
+	for {
+		done, lastVersion, err := watchUntil(ctx, name, resourceVersion, watchFunc, condition)
+		if done {
+			return err
+		}
+		if ctx.Err() != nil {
+			return waitContextError(ctx, lastErr)
+		}
+		if lastVersion != resourceVersion {
+			restarts = 0 // The watch made progress before it ended
+		}
+		restarts++
+		if restarts > maxWatchRestarts {
+			LogWarn("Watch for %s keeps ending (%v), falling back to polling", name, err)
+			break
+		}
+
+		switch {
+		case errors.Is(err, errWatchClosed):
+			LogDebug("Watch for %s closed, watching again from version %q", name, lastVersion)
+			resourceVersion = lastVersion
+			continue
+		case apierrors.IsResourceExpired(err) || apierrors.IsGone(err):
+			LogDebug("Resource version of %s expired, fetching it again", name)
+			resourceVersion = ""
+			if done, err := checkCurrent(); done {
+				return err
+			}
+			continue
+		}
+		LogWarn("Watch for %s ended early (%v), falling back to polling", name, err)
+		break
+	}
+
+	fallback := backoff
+	fallback.Timeout = 0
+	fallback.MaxRetries = 0
+	return WaitForWithContext(ctx, fallback, poll)
+}
+
+// watchUntil consumes watch events for the named object until the condition is met or the object is
+// deleted, which is terminal. Otherwise it returns done=false with the error that ended the watch
+// (errWatchClosed when the server closed it) and the last resource version it saw.
+func watchUntil(ctx context.Context, name, resourceVersion string, watchFunc WatchFunc, condition ObjectConditionFunc) (bool, string, error) {
+	watcher, err := watchFunc(ctx, metav1.ListOptions{
+		FieldSelector:       fields.OneTermEqualSelector("metadata.name", name).String(),
+		ResourceVersion:     resourceVersion,
+		AllowWatchBookmarks: true,
+	})
+	if err != nil {
+		return false, resourceVersion, err
+	}
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false, resourceVersion, ctx.Err()
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return false, resourceVersion, errWatchClosed
+			}
+			if event.Type == watch.Error {
+				if status, isStatus := event.Object.(*metav1.Status); isStatus {
+					return false, resourceVersion, apierrors.FromObject(status)
+				}
+				return false, resourceVersion, fmt.Errorf("watch error: %v", event.Object)
+			}
+
+			accessor, err := meta.Accessor(event.Object)
+			if err != nil {
+				continue
+			}
+			if event.Type == watch.Bookmark {
+				resourceVersion = accessor.GetResourceVersion()
+				continue
+			}
+			// Fake clientsets ignore field selectors, so filter by name here as well
+			if accessor.GetName() != name {
+				continue
+			}
+			if version := accessor.GetResourceVersion(); version != "" {
+				resourceVersion = version
+			}
+			if event.Type == watch.Deleted {
+				return true, resourceVersion, fmt.Errorf("%w: %s %w", ErrTerminalCondition, name, ErrObjectDeleted)
+			}
+
+			done, err := condition(event.Object)
+			if err != nil {
+				LogError("Error during wait: %v", err)
+			}
+			if done {
+				if err != nil {
+					return true, resourceVersion, fmt.Errorf("%w: %w", ErrTerminalCondition, err)
+				}
+				return true, resourceVersion, nil
+			}
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package util_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"myproject/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func newTestPod(name string, phase corev1.PodPhase) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Status:     corev1.PodStatus{Phase: phase},
+	}
+}
+
+var _ = Describe("WaitForPodState", func() {
+	It("should return on the first matching watch event", func() {
+		clientset := fake.NewSimpleClientset(newTestPod("server", corev1.PodPending))
+		watcher := watch.NewFake()
+		clientset.PrependWatchReactor("pods", k8stesting.DefaultWatchReactor(watcher, nil))
+
+		go func() {
+			defer GinkgoRecover()
+			watcher.Modify(newTestPod("other", corev1.PodRunning))
+			watcher.Modify(newTestPod("server", corev1.PodRunning))
+		}()
+
+		// The polling interval is longer than the timeout, so only the watch can satisfy the wait
+		err := util.WaitForPodState(context.Background(), clientset, "default", "server", util.ConstantBackoff(time.Minute, 5*time.Second, 0), true)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should fall back to polling when the watch cannot be started", func() {
+		clientset := fake.NewSimpleClientset(newTestPod("server", corev1.PodPending))
+		clientset.PrependWatchReactor("pods", func(k8stesting.Action) (bool, watch.Interface, error) {
+			return true, nil, errors.New("watch not supported")
+		})
+
+		go func() {
+			defer GinkgoRecover()
+			time.Sleep(50 * time.Millisecond)
+			_, err := clientset.CoreV1().Pods("default").UpdateStatus(context.Background(), newTestPod("server", corev1.PodSucceeded), metav1.UpdateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+		}()
+
+		err := util.WaitForPodState(context.Background(), clientset, "default", "server", util.ConstantBackoff(10*time.Millisecond, 5*time.Second, 0), true)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should report a failed pod as a terminal error", func() {
+		clientset := fake.NewSimpleClientset()
+		watcher := watch.NewFake()
+		clientset.PrependWatchReactor("pods", k8stesting.DefaultWatchReactor(watcher, nil))
+
+		go func() {
+			defer GinkgoRecover()
+			watcher.Add(newTestPod("server", corev1.PodFailed))
+		}()
+
+		err := util.WaitForPodState(context.Background(), clientset, "default", "server", util.ConstantBackoff(time.Minute, 5*time.Second, 0), true)
+		Expect(errors.Is(err, util.ErrTerminalCondition)).To(BeTrue())
+	})
+
+	It("should fail fast when the pod is deleted", func() {
+		clientset := fake.NewSimpleClientset(newTestPod("server", corev1.PodPending))
+		watcher := watch.NewFake()
+		clientset.PrependWatchReactor("pods", k8stesting.DefaultWatchReactor(watcher, nil))
+
+		go func() {
+			defer GinkgoRecover()
+			watcher.Delete(newTestPod("other", corev1.PodPending))
+			watcher.Delete(newTestPod("server", corev1.PodPending))
+		}()
+
+		err := util.WaitForPodState(context.Background(), clientset, "default", "server", util.ConstantBackoff(time.Minute, 5*time.Second, 0), true)
+		Expect(err).To(MatchError(util.ErrTerminalCondition))
+		Expect(err).To(MatchError(util.ErrObjectDeleted))
+	})
+
+	It("should watch again when the server closes the watch", func() {
+		clientset := fake.NewSimpleClientset(newTestPod("server", corev1.PodPending))
+		first, second := watch.NewFake(), watch.NewFake()
+		watches := 0
+		clientset.PrependWatchReactor("pods", func(k8stesting.Action) (bool, watch.Interface, error) {
+			watches++
+			if watches == 1 {
+				return true, first, nil
+			}
+			return true, second, nil
+		})
+
+		go func() {
+			defer GinkgoRecover()
+			first.Stop()
+			second.Modify(newTestPod("server", corev1.PodRunning))
+		}()
+
+		// The polling interval is longer than the timeout, so only a new watch can satisfy the wait
+		err := util.WaitForPodState(context.Background(), clientset, "default", "server", util.ConstantBackoff(time.Minute, 5*time.Second, 0), true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(watches).To(Equal(2))
+	})
+
+	It("should fetch the pod again when its resource version expired", func() {
+		clientset := fake.NewSimpleClientset(newTestPod("server", corev1.PodPending))
+		expired, fresh := watch.NewFake(), watch.NewFake()
+		watches := 0
+		clientset.PrependWatchReactor("pods", func(k8stesting.Action) (bool, watch.Interface, error) {
+			watches++
+			if watches == 1 {
+				return true, expired, nil
+			}
+			return true, fresh, nil
+		})
+
+		go func() {
+			defer GinkgoRecover()
+			// The pod changes without an event on the expired watch, only the new fetch sees it
+			_, err := clientset.CoreV1().Pods("default").UpdateStatus(context.Background(), newTestPod("server", corev1.PodRunning), metav1.UpdateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			expired.Error(&metav1.Status{Status: metav1.StatusFailure, Code: http.StatusGone, Reason: metav1.StatusReasonExpired, Message: "too old resource version"})
+		}()
+
+		err := util.WaitForPodState(context.Background(), clientset, "default", "server", util.ConstantBackoff(time.Minute, 5*time.Second, 0), true)
+		Expect(err).ToNot(HaveOccurred())
+	})
+})